@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+func newAnalyzeCSVCmd() *cobra.Command {
+	var settleBand float64
+
+	cmd := &cobra.Command{
+		Use:   "analyze-csv <file.csv>",
+		Short: "Compute metrics for a samples.csv file",
+		Long: `Read a file in the samples.csv format (it need not be inside a run
+directory) and print its step-response metrics.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !(settleBand > 0) {
+				return fmt.Errorf("--settle-band must be > 0, got %g", settleBand)
+			}
+			samples, err := artifacts.ReadSamplesCSV(args[0])
+			if err != nil {
+				return err
+			}
+			if len(samples) == 0 {
+				return fmt.Errorf("%s: no samples", args[0])
+			}
+
+			metrics := analysis.Compute(samples, settleBand)
+			values := make(map[string]any)
+			for k, v := range metrics.Values() {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					values[k] = nil
+				} else {
+					values[k] = v
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			_, _ = fmt.Fprintf(out, "File:    %s\n", args[0])
+			_, _ = fmt.Fprintf(out, "Samples: %d\n", len(samples))
+			writeInfoSection(out, "Metrics", values, artifacts.DefaultUnits())
+			return nil
+		},
+	}
+
+	cmd.Flags().Float64Var(&settleBand, "settle-band", 0.02, "settling band as a fraction of the target (e.g. 0.02 for ±2%)")
+
+	return cmd
+}
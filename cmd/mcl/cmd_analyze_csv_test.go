@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+// standaloneCSV writes a simulated run's samples.csv into a bare temp directory.
+func standaloneCSV(t *testing.T) string {
+	t.Helper()
+	samples, _, err := experiment.RunStep(sim.NewDCMotor(), pid.New(0.05, 0.1, 0), experiment.StepConfig{
+		TargetRPM: 1000, DT: 0.01, Duration: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := (&artifacts.RunDir{Dir: dir}).WriteSamplesCSV(samples); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "samples.csv")
+}
+
+func TestAnalyzeCSV_MatchesCompute(t *testing.T) {
+	path := standaloneCSV(t)
+	samples, err := artifacts.ReadSamplesCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, band := range []float64{0.02, 0.05} {
+		out, err := execCmd(newAnalyzeCSVCmd(), path, "--settle-band", fmt.Sprint(band))
+		if err != nil {
+			t.Fatalf("analyze-csv failed: %v", err)
+		}
+		if !strings.Contains(out, "Samples: 500\n") {
+			t.Errorf("output missing the sample count:\n%s", out)
+		}
+
+		want := analysis.Compute(samples, band)
+		width := 0 // names are padded to the longest one
+		for k := range want.Values() {
+			width = max(width, len(k))
+		}
+		for _, line := range []string{
+			fmt.Sprintf("%-*s  %.6g %%", width, "overshoot_percent", want.OvershootPercent),
+			fmt.Sprintf("%-*s  %.6g s", width, "settling_time_seconds", want.SettlingTimeSeconds),
+			fmt.Sprintf("%-*s  %.6g rpm*s", width, "iae", want.IAE),
+		} {
+			if !strings.Contains(out, line) {
+				t.Errorf("band %v: output missing %q:\n%s", band, line, out)
+			}
+		}
+	}
+}
+
+func TestAnalyzeCSV_Errors(t *testing.T) {
+	if _, err := execCmd(newAnalyzeCSVCmd(), filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("analyze-csv should fail for a missing file")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.csv")
+	if err := os.WriteFile(bad, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execCmd(newAnalyzeCSVCmd(), bad); err == nil || !strings.Contains(err.Error(), "missing column") {
+		t.Errorf("error = %v, want a missing column error", err)
+	}
+
+	if _, err := execCmd(newAnalyzeCSVCmd(), standaloneCSV(t), "--settle-band", "0"); err == nil {
+		t.Error("analyze-csv should reject a zero settle band")
+	}
+}
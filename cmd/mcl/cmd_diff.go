@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+func newDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <a> <b>",
+		Short: "Show the scenario params that differ between two scenarios or runs",
+		Long: `Compare the scenario params of two scenario files (as written by
+"sim step --dump-config"), runs (a run directory or its metadata.json), or one
+of each, and print the params that differ. Both sides are resolved with the
+defaults of missing params filled in, so only effective differences are shown.
+Run ids, timestamps and the environment are not params and never differ.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := loadDiffParams(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := loadDiffParams(args[1])
+			if err != nil {
+				return err
+			}
+			writeParamDiff(cmd.OutOrStdout(), args[0], args[1], diffParams(a, b))
+			return nil
+		},
+	}
+	return cmd
+}
+
+// loadDiffParams returns the resolved scenario params of path: a run
+// directory, a metadata.json, or a scenario YAML file.
+func loadDiffParams(path string) (map[string]any, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() && !strings.EqualFold(filepath.Ext(path), ".json") {
+		sc, err := loadStepConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		return sc.params(), nil
+	}
+
+	dir := path
+	if !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	md, err := artifacts.ReadMetadata(dir)
+	if err != nil {
+		return nil, runFileError(dir, "metadata.json", err)
+	}
+	sc, err := stepScenarioFromParams(md.Params)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sc.params(), nil
+}
+
+// paramDiff is one param whose values differ; a nil value means the param is
+// absent on that side.
+type paramDiff struct {
+	key  string
+	a, b any
+}
+
+// diffParams returns the params whose values differ between a and b, sorted by key.
+func diffParams(a, b map[string]any) []paramDiff {
+	keys := make(map[string]bool, len(a))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	var diffs []paramDiff
+	for _, k := range sortedParamKeys(keys) {
+		if !reflect.DeepEqual(a[k], b[k]) {
+			diffs = append(diffs, paramDiff{key: k, a: a[k], b: b[k]})
+		}
+	}
+	return diffs
+}
+
+func sortedParamKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeParamDiff prints one "key  a -> b" line per difference, aligned on the
+// longest key.
+func writeParamDiff(w io.Writer, nameA, nameB string, diffs []paramDiff) {
+	_, _ = fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+	if len(diffs) == 0 {
+		_, _ = fmt.Fprintln(w, "No differences")
+		return
+	}
+	width := 0
+	for _, d := range diffs {
+		width = max(width, len(d.key))
+	}
+	for _, d := range diffs {
+		_, _ = fmt.Fprintf(w, "  %-*s  %s -> %s\n", width, d.key, diffValue(d.a), diffValue(d.b))
+	}
+}
+
+func diffValue(v any) string {
+	if v == nil {
+		return "(absent)"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiff_Scenarios(t *testing.T) {
+	pathA, sc := scenarioTemplate(t)
+	sc.Kp = 0.03
+	sc.WarmStart = true
+	pathB := filepath.Join(t.TempDir(), "b.yaml")
+	if err := dumpStepConfig(pathB, sc); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := execCmd(newDiffCmd(), pathA, pathB)
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	want := []string{
+		"--- " + pathA,
+		"+++ " + pathB,
+		"  kp          0.02 -> 0.03",
+		"  warm_start  false -> true",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diff output:\n%s\nwant:\n%s", out, strings.Join(want, "\n"))
+	}
+}
+
+func TestDiff_RunsAndScenarios(t *testing.T) {
+	// Two runs of the same scenario differ only in volatile fields: no params differ
+	runA, runB := runSimStepCLI(t, "--no-plots"), runSimStepCLI(t, "--no-plots", "--tag", "other")
+	out, err := execCmd(newDiffCmd(), runA, filepath.Join(runB, "metadata.json"))
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if !strings.Contains(out, "No differences") {
+		t.Errorf("identical runs: diff output:\n%s", out)
+	}
+
+	// A scenario against a run: the scenario's duration and dt differ from the run's
+	scenario, _ := scenarioTemplate(t)
+	out, err = execCmd(newDiffCmd(), scenario, runA)
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if !strings.Contains(out, "duration_s") || strings.Contains(out, "  kp ") || strings.Contains(out, "run_id") {
+		t.Errorf("scenario vs run: diff output:\n%s", out)
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	scenario, _ := scenarioTemplate(t)
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(bad, []byte("kp: fast\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{scenario},
+		{scenario, filepath.Join(t.TempDir(), "missing.yaml")},
+		{scenario, t.TempDir()}, // not a run directory
+		{scenario, bad},
+	} {
+		if _, err := execCmd(newDiffCmd(), args...); err == nil {
+			t.Errorf("diff %v: want an error", args)
+		}
+	}
+}
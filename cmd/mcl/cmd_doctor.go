@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/plotting"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func newDoctorCmd() *cobra.Command {
+	var tmpDir string
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that simulation, CSV and plotting work on this machine",
+		Long: `Run a self-test: write a scratch directory, run a short step simulation,
+write samples.csv and read it back, and render a plot (which needs the font
+assets bundled with gonum/plot). Each check is reported as PASS or FAIL; the
+scratch directory is removed afterwards.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := runDoctor(tmpDir)
+			return reportDoctor(cmd.OutOrStdout(), results)
+		},
+	}
+
+	cmd.Flags().StringVar(&tmpDir, "tmp", os.TempDir(), "directory to create the scratch directory in")
+
+	return cmd
+}
+
+// doctorResult is the outcome of one doctor check.
+type doctorResult struct {
+	Name string
+	Err  error
+}
+
+// errDoctorSkipped marks a check that could not run because one it depends on failed.
+var errDoctorSkipped = errors.New("skipped: an earlier check failed")
+
+// runDoctor runs the self-test checks in a scratch directory created under
+// baseDir, in order. Each check builds on the previous ones, so after a failure
+// the remaining checks are reported as skipped.
+func runDoctor(baseDir string) []doctorResult {
+	var (
+		dir     string
+		samples []experiment.Sample
+	)
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"scratch directory is writable", func() error {
+			var err error
+			if dir, err = os.MkdirTemp(baseDir, "mcl-doctor-"); err != nil {
+				return err
+			}
+			return os.WriteFile(filepath.Join(dir, "probe"), []byte("ok\n"), 0o644)
+		}},
+		{"step simulation runs", func() error {
+			var err error
+			cfg := experiment.StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 0.5}
+			if samples, _, err = experiment.RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg); err != nil {
+				return err
+			}
+			if len(samples) != 500 {
+				return fmt.Errorf("got %d samples, want 500", len(samples))
+			}
+			if last := samples[len(samples)-1].Actual; !(last > 0) || math.IsInf(last, 0) {
+				return fmt.Errorf("final speed %g RPM, want a positive finite speed", last)
+			}
+			return nil
+		}},
+		{"samples.csv writes and reads back", func() error {
+			run := artifacts.RunDir{Dir: dir}
+			if err := run.WriteSamplesCSV(samples); err != nil {
+				return err
+			}
+			got, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+			if err != nil {
+				return err
+			}
+			if len(got) != len(samples) {
+				return fmt.Errorf("read %d samples, wrote %d", len(got), len(samples))
+			}
+			for i := range got {
+				if math.Abs(got[i].Actual-samples[i].Actual) > 1e-6 {
+					return fmt.Errorf("sample %d: read actual %g, wrote %g", i, got[i].Actual, samples[i].Actual)
+				}
+			}
+			return nil
+		}},
+		{"plots render (fonts)", func() error {
+			if err := plotting.WriteVelocityPlot(dir, samples, plotting.PlotTheme{}); err != nil {
+				return err
+			}
+			info, err := os.Stat(filepath.Join(dir, "velocity.png"))
+			if err != nil {
+				return err
+			}
+			if info.Size() == 0 {
+				return fmt.Errorf("velocity.png is empty")
+			}
+			return nil
+		}},
+	}
+
+	results := make([]doctorResult, 0, len(checks))
+	failed := false
+	for _, c := range checks {
+		err := errDoctorSkipped
+		if !failed {
+			err = c.run()
+			failed = err != nil
+		}
+		results = append(results, doctorResult{Name: c.name, Err: err})
+	}
+	if dir != "" {
+		_ = os.RemoveAll(dir) // best effort; it is a scratch directory
+	}
+	return results
+}
+
+// reportDoctor prints one PASS/FAIL line per check and returns an error if any failed.
+func reportDoctor(w io.Writer, results []doctorResult) error {
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			_, _ = fmt.Fprintf(w, "FAIL  %s: %v\n", r.Name, r.Err)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "PASS  %s\n", r.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("doctor: %d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDoctor_AllChecksPass(t *testing.T) {
+	base := t.TempDir()
+	results := runDoctor(base)
+	if len(results) != 4 {
+		t.Fatalf("%d checks, want 4", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Name, r.Err)
+		}
+	}
+
+	// The scratch directory is cleaned up
+	if entries, _ := os.ReadDir(base); len(entries) != 0 {
+		t.Errorf("scratch directory left behind: %v", entries)
+	}
+}
+
+func TestDoctor_Command(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newDoctorCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--tmp", t.TempDir()})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("doctor error = %v\n%s", err, out.String())
+	}
+	if n := strings.Count(out.String(), "PASS  "); n != 4 {
+		t.Errorf("output has %d PASS lines, want 4:\n%s", n, out.String())
+	}
+}
+
+func TestDoctor_UnwritableScratchSkipsTheRest(t *testing.T) {
+	results := runDoctor(filepath.Join(t.TempDir(), "missing"))
+	if results[0].Err == nil {
+		t.Fatal("scratch directory check passed for a missing base directory")
+	}
+	for _, r := range results[1:] {
+		if !errors.Is(r.Err, errDoctorSkipped) {
+			t.Errorf("%s: err = %v, want skipped", r.Name, r.Err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := reportDoctor(&out, results); err == nil || !strings.Contains(err.Error(), "4 of 4 checks failed") {
+		t.Errorf("reportDoctor() error = %v, want 4 of 4 failed", err)
+	}
+	if !strings.HasPrefix(out.String(), "FAIL  scratch directory is writable: ") {
+		t.Errorf("output = %q", out.String())
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+func newExportCmd() *cobra.Command {
+	var (
+		format string
+		out    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <runDir>...",
+		Short: "Combine the samples of several runs into one CSV file",
+		Long: `Read <runDir>/samples.csv of each run and write them as one long-format
+table, with a leading run_id column from each run's metadata.json, e.g. for
+cross-run analysis in pandas. Signal columns are the union over the runs; a
+signal a run lacks is an empty cell.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "long" {
+				return fmt.Errorf("unsupported --format %q (supported: long)", format)
+			}
+			runs, err := readLongRuns(args)
+			if err != nil {
+				return err
+			}
+
+			if out == "" {
+				return artifacts.WriteLongCSV(cmd.OutOrStdout(), runs)
+			}
+			f, err := os.Create(out)
+			if err != nil {
+				return err
+			}
+			if err := artifacts.WriteLongCSV(f, runs); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			n := 0
+			for _, r := range runs {
+				n += len(r.Samples)
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d samples of %d runs to %s\n", n, len(runs), out)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "long", "output layout: long (one row per sample with a run_id column)")
+	cmd.Flags().StringVar(&out, "out", "", "output CSV file (default: stdout)")
+
+	return cmd
+}
+
+// readLongRuns reads the run id and samples of each run directory, in order.
+func readLongRuns(dirs []string) ([]artifacts.LongRun, error) {
+	runs := make([]artifacts.LongRun, 0, len(dirs))
+	for _, dir := range dirs {
+		md, err := artifacts.ReadMetadata(dir)
+		if err != nil {
+			return nil, runFileError(dir, "metadata.json", err)
+		}
+		samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, runFileError(dir, "samples.csv", err)
+			}
+			return nil, err
+		}
+		runs = append(runs, artifacts.LongRun{RunID: md.RunID, Samples: samples})
+	}
+	return runs, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+// exportRuns creates runs with distinct run IDs: deterministic runs timestamped
+// a minute apart.
+func exportRuns(t *testing.T, args ...[]string) []string {
+	t.Helper()
+	dirs := make([]string, len(args))
+	for i, a := range args {
+		t.Setenv(sourceDateEpochEnv, fmt.Sprint(1700000000+60*i))
+		dirs[i] = runSimStepCLI(t, append([]string{"--deterministic", "--no-plots"}, a...)...)
+	}
+	return dirs
+}
+
+func TestExport_Long(t *testing.T) {
+	dirs := exportRuns(t, nil, []string{"--duration", "5"}, []string{"--observe", "position", "--target", "1"})
+	out := filepath.Join(t.TempDir(), "combined.csv")
+	if _, err := execCmd(newExportCmd(), "--format", "long", "--out", out, dirs[0], dirs[1], dirs[2]); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0][0] != "run_id" {
+		t.Fatalf("header = %v, want run_id first", records[0])
+	}
+
+	// One row per input sample, labelled with its run's id in input order
+	counts := make(map[string]int)
+	var order []string
+	for _, rec := range records[1:] {
+		if counts[rec[0]] == 0 {
+			order = append(order, rec[0])
+		}
+		counts[rec[0]]++
+	}
+	total := 0
+	for i, dir := range dirs {
+		md, err := artifacts.ReadMetadata(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += len(samples)
+		if counts[md.RunID] != len(samples) {
+			t.Errorf("run %s: %d rows, want %d", md.RunID, counts[md.RunID], len(samples))
+		}
+		if i < len(order) && order[i] != md.RunID {
+			t.Errorf("run %d in the export is %s, want %s", i, order[i], md.RunID)
+		}
+	}
+	if len(records)-1 != total {
+		t.Errorf("%d rows, want the sum of the inputs, %d", len(records)-1, total)
+	}
+	if len(counts) != len(dirs) {
+		t.Errorf("%d run ids, want %d", len(counts), len(dirs))
+	}
+}
+
+func TestExport_Stdout(t *testing.T) {
+	dirs := exportRuns(t, nil)
+	out, err := execCmd(newExportCmd(), dirs[0])
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if lines := strings.Count(out, "\n"); lines != 1001 {
+		t.Errorf("stdout has %d lines, want header + 1000 samples", lines)
+	}
+	if !strings.HasPrefix(out, "run_id,t,dt,") {
+		t.Errorf("stdout starts with %q", out[:20])
+	}
+}
+
+func TestExport_Errors(t *testing.T) {
+	dirs := exportRuns(t, nil)
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no runs", nil, "requires at least 1 arg"},
+		{"unknown format", []string{"--format", "wide", dirs[0]}, "unsupported --format"},
+		{"not a run", []string{t.TempDir()}, "no metadata.json"},
+		{"same run twice", []string{dirs[0], dirs[0]}, "duplicate run id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := execCmd(newExportCmd(), tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("export error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newGenScenariosCmd() *cobra.Command {
+	var (
+		grid   []string
+		outDir string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gen-scenarios <template.yaml> [key=v1,v2,...]...",
+		Short: "Expand a scenario template over a parameter grid",
+		Long: `Read a scenario file (as written by "sim step --dump-config") and write one
+scenario file per combination of the grid values into --out. Grid axes are
+given as key=v1,v2,... with --grid or as extra arguments; keys are the
+scenario's params (e.g. kp, target_rpm). Each file is usable with
+"sim step --config".`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			template, err := loadStepConfig(args[0])
+			if err != nil {
+				return err
+			}
+			axes, err := parseGrid(append(append([]string(nil), grid...), args[1:]...))
+			if err != nil {
+				return err
+			}
+			if len(axes) == 0 {
+				return fmt.Errorf("no grid axes given (e.g. --grid kp=0.01,0.02)")
+			}
+
+			combos, err := expandGrid(template.params(), axes)
+			if err != nil {
+				return err
+			}
+			// Validate every combination before writing any file
+			scenarios := make([]stepScenario, len(combos))
+			for i, c := range combos {
+				if scenarios[i], err = stepScenarioFromParams(c.params); err != nil {
+					return fmt.Errorf("%s: %w", c.label, err)
+				}
+			}
+			if err := os.MkdirAll(outDir, 0o755); err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for i, c := range combos {
+				name := fmt.Sprintf("scenario_%03d.yaml", i+1)
+				if err := dumpStepConfig(filepath.Join(outDir, name), scenarios[i]); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(out, "%s  %s\n", name, c.label)
+			}
+			_, _ = fmt.Fprintf(out, "Wrote %d scenarios to %s\n", len(combos), outDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&grid, "grid", nil, "grid axis key=v1,v2,... (repeatable)")
+	cmd.Flags().StringVar(&outDir, "out", "scenarios", "directory for the generated scenario files")
+
+	return cmd
+}
+
+// gridAxis is one parameter and the values it takes in the grid.
+type gridAxis struct {
+	key    string
+	values []string
+}
+
+// parseGrid parses key=v1,v2,... axes, keeping their order.
+func parseGrid(specs []string) ([]gridAxis, error) {
+	var axes []gridAxis
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		key, list, ok := strings.Cut(spec, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("grid axis %q: want key=v1,v2,...", spec)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("grid axis %q given more than once", key)
+		}
+		seen[key] = true
+
+		values := strings.Split(list, ",")
+		for _, v := range values {
+			if v == "" {
+				return nil, fmt.Errorf("grid axis %q: empty value", spec)
+			}
+		}
+		axes = append(axes, gridAxis{key: key, values: values})
+	}
+	return axes, nil
+}
+
+// gridCombo is one point of the grid: the template params with the grid
+// values substituted, and a "key=value ..." label.
+type gridCombo struct {
+	params map[string]any
+	label  string
+}
+
+// expandGrid returns the cartesian product of axes applied to params, with
+// the last axis varying fastest. Each value is parsed as the type of the
+// template's value for its key, so unknown keys and mistyped values are errors.
+func expandGrid(params map[string]any, axes []gridAxis) ([]gridCombo, error) {
+	typed := make([][]any, len(axes))
+	for i, a := range axes {
+		tmpl, ok := params[a.key]
+		if !ok {
+			return nil, fmt.Errorf("grid axis %q: not a scenario parameter", a.key)
+		}
+		for _, s := range a.values {
+			v, err := parseParamLike(tmpl, s)
+			if err != nil {
+				return nil, fmt.Errorf("grid axis %q: %w", a.key, err)
+			}
+			typed[i] = append(typed[i], v)
+		}
+	}
+
+	combos := []gridCombo{{params: params}}
+	for i, a := range axes {
+		var next []gridCombo
+		for _, c := range combos {
+			for j, v := range typed[i] {
+				p := make(map[string]any, len(c.params))
+				for k, old := range c.params {
+					p[k] = old
+				}
+				p[a.key] = v
+				label := strings.TrimSpace(c.label + " " + a.key + "=" + a.values[j])
+				next = append(next, gridCombo{params: p, label: label})
+			}
+		}
+		combos = next
+	}
+	return combos, nil
+}
+
+// parseParamLike parses s as the same type as the template value tmpl.
+func parseParamLike(tmpl any, s string) (any, error) {
+	var (
+		v   any
+		err error
+	)
+	switch tmpl.(type) {
+	case float64:
+		v, err = strconv.ParseFloat(s, 64)
+	case int64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case bool:
+		v, err = strconv.ParseBool(s)
+	case string:
+		v = s
+	default:
+		err = fmt.Errorf("unsupported parameter type %T", tmpl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
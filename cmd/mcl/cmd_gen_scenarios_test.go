@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scenarioTemplate writes a scenario file to a temp directory.
+func scenarioTemplate(t *testing.T) (string, stepScenario) {
+	t.Helper()
+	sc := stepScenario{Kp: 0.02, Ki: 0.05, Observe: "velocity", TargetRPM: 1000, DurationS: 5, DTS: 0.01,
+		OutMinV: -24, OutMaxV: 24, Kt: 1, Reference: referenceConfig{Type: "step"}}
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	if err := dumpStepConfig(path, sc); err != nil {
+		t.Fatal(err)
+	}
+	return path, sc
+}
+
+func TestGenScenarios_Grid(t *testing.T) {
+	template, base := scenarioTemplate(t)
+	outDir := filepath.Join(t.TempDir(), "grid")
+
+	// One axis via --grid, the others as arguments
+	out, err := execCmd(newGenScenariosCmd(), template, "--grid", "kp=0.01,0.02", "ki=0.05,0.1,0.2", "warm_start=true", "--out", outDir)
+	if err != nil {
+		t.Fatalf("gen-scenarios failed: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(outDir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 6 {
+		t.Fatalf("wrote %d files, want 2*3*1 = 6", len(files))
+	}
+	if !strings.Contains(out, "Wrote 6 scenarios") {
+		t.Errorf("output missing the summary:\n%s", out)
+	}
+
+	seen := map[[2]float64]bool{}
+	for _, f := range files {
+		sc, err := loadStepConfig(f)
+		if err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+		seen[[2]float64{sc.Kp, sc.Ki}] = true
+
+		// Everything but the grid keys comes from the template
+		want := base
+		want.Kp, want.Ki, want.WarmStart = sc.Kp, sc.Ki, true
+		if sc != want {
+			t.Errorf("%s = %+v, want %+v", filepath.Base(f), sc, want)
+		}
+	}
+	for _, kp := range []float64{0.01, 0.02} {
+		for _, ki := range []float64{0.05, 0.1, 0.2} {
+			if !seen[[2]float64{kp, ki}] {
+				t.Errorf("no scenario with kp=%v ki=%v", kp, ki)
+			}
+		}
+	}
+
+	// The last axis varies fastest
+	first, err := loadStepConfig(filepath.Join(outDir, "scenario_002.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Kp != 0.01 || first.Ki != 0.1 {
+		t.Errorf("scenario_002 has kp=%v ki=%v, want kp=0.01 ki=0.1", first.Kp, first.Ki)
+	}
+	if !strings.Contains(out, "scenario_002.yaml  kp=0.01 ki=0.1 warm_start=true") {
+		t.Errorf("output missing the scenario_002 label:\n%s", out)
+	}
+}
+
+func TestGenScenarios_UsableWithConfig(t *testing.T) {
+	template, _ := scenarioTemplate(t)
+	outDir := t.TempDir()
+	if _, err := execCmd(newGenScenariosCmd(), template, "--grid", "kp=0.03", "--out", outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := runSimStepCLI(t, "--no-plots", "--config", filepath.Join(outDir, "scenario_001.yaml"))
+	if _, err := os.Stat(filepath.Join(dir, "metrics.json")); err != nil {
+		t.Errorf("run from a generated scenario: %v", err)
+	}
+}
+
+func TestGenScenarios_Errors(t *testing.T) {
+	template, _ := scenarioTemplate(t)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{template}, "no grid axes"},
+		{[]string{template, "kp"}, "want key=v1,v2"},
+		{[]string{template, "kp=0.1,"}, "empty value"},
+		{[]string{template, "kp=0.1", "kp=0.2"}, "more than once"},
+		{[]string{template, "gain=1"}, "not a scenario parameter"},
+		{[]string{template, "kp=fast"}, `"kp"`},
+		{[]string{template, "anti_windup=sometimes"}, "anti-windup"},
+		{[]string{filepath.Join(t.TempDir(), "missing.yaml"), "kp=1"}, "missing.yaml"},
+	}
+	for _, tt := range tests {
+		outDir := filepath.Join(t.TempDir(), "out")
+		_, err := execCmd(newGenScenariosCmd(), append(tt.args, "--out", outDir)...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want it to mention %q", tt.args[1:], err, tt.want)
+		}
+		if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+			t.Errorf("%v: output directory created despite the error", tt.args[1:])
+		}
+	}
+}
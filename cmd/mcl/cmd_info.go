@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+func newInfoCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "info <runDir>",
+		Short: "Print a run's metadata and metrics",
+		Long:  "Print a summary of <runDir>/metadata.json and <runDir>/metrics.json, or both files as one JSON object with --json.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+			md, err := artifacts.ReadMetadata(dir)
+			if err != nil {
+				return runFileError(dir, "metadata.json", err)
+			}
+			metrics, err := artifacts.ReadMetrics(dir)
+			if err != nil {
+				return runFileError(dir, "metrics.json", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if asJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					Metadata artifacts.Metadata `json:"metadata"`
+					Metrics  map[string]any     `json:"metrics"`
+				}{md, metrics})
+			}
+			writeRunInfo(out, md, metrics)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print metadata and metrics as a single JSON object")
+
+	return cmd
+}
+
+// runFileError explains a missing run file instead of reporting a bare path error.
+func runFileError(dir, name string, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: no %s (is this a run directory written by mcl?)", dir, name)
+	}
+	return fmt.Errorf("%s: reading %s: %w", dir, name, err)
+}
+
+// writeRunInfo prints the run header, then params and metrics sorted by name,
+// with units from the metadata when known (dimensionless "1" is omitted).
+func writeRunInfo(w io.Writer, md artifacts.Metadata, metrics map[string]any) {
+	_, _ = fmt.Fprintf(w, "Run:        %s\n", md.RunID)
+	_, _ = fmt.Fprintf(w, "Experiment: %s/%s/%s\n", md.Kind, md.Plant, md.Experiment)
+	_, _ = fmt.Fprintf(w, "Created:    %s\n", md.CreatedAtUTC)
+	if len(md.Tags) > 0 {
+		_, _ = fmt.Fprintf(w, "Tags:       %s\n", strings.Join(md.Tags, ", "))
+	}
+
+	writeInfoSection(w, "Params", md.Params, nil)
+	writeInfoSection(w, "Metrics", metrics, md.Units)
+}
+
+func writeInfoSection(w io.Writer, title string, values map[string]any, units map[string]string) {
+	_, _ = fmt.Fprintf(w, "\n%s:\n", title)
+	keys := make([]string, 0, len(values))
+	width := 0
+	for k := range values {
+		keys = append(keys, k)
+		width = max(width, len(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := values[k]
+		var s string
+		switch x := v.(type) {
+		case nil:
+			s = "n/a"
+		case float64:
+			s = fmt.Sprintf("%.6g", x)
+		default:
+			s = fmt.Sprint(x)
+		}
+		if u := units[k]; u != "" && u != "1" && v != nil {
+			s += " " + u
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s  %s\n", width, k, s)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+// fakeRunDir writes a minimal metadata.json and metrics.json (unless skipped).
+func fakeRunDir(t *testing.T, withMetrics bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	md := artifacts.Metadata{
+		RunID: "20260101T000000Z_sim_dc-motor_step", CreatedAtUTC: "2026-01-01T00:00:00Z",
+		Kind: "sim", Plant: "dc-motor", Experiment: "step",
+		Params: map[string]any{"kp": 0.02, "warm_start": false},
+		Tags:   []string{"baseline", "pi"},
+		Units:  map[string]string{"overshoot_percent": "%", "settling_time_seconds": "s", "saturation_fraction": "1"},
+	}
+	if err := artifacts.WriteJSON(filepath.Join(dir, "metadata.json"), md); err != nil {
+		t.Fatal(err)
+	}
+	if withMetrics {
+		metrics := []byte(`{"overshoot_percent": 3.25, "settling_time_seconds": null, "saturation_fraction": 0.5}`)
+		if err := os.WriteFile(filepath.Join(dir, "metrics.json"), metrics, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestInfo_Text(t *testing.T) {
+	out, err := execCmd(newInfoCmd(), fakeRunDir(t, true))
+	if err != nil {
+		t.Fatalf("info failed: %v", err)
+	}
+	for _, want := range []string{
+		"Run:        20260101T000000Z_sim_dc-motor_step\n",
+		"Experiment: sim/dc-motor/step\n",
+		"Tags:       baseline, pi\n",
+		"  kp          0.02\n",
+		"  warm_start  false\n",
+		"  overshoot_percent      3.25 %\n",
+		"  saturation_fraction    0.5\n",
+		"  settling_time_seconds  n/a\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestInfo_JSON(t *testing.T) {
+	out, err := execCmd(newInfoCmd(), fakeRunDir(t, true), "--json")
+	if err != nil {
+		t.Fatalf("info --json failed: %v", err)
+	}
+	var got struct {
+		Metadata artifacts.Metadata `json:"metadata"`
+		Metrics  map[string]any     `json:"metrics"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if got.Metadata.Plant != "dc-motor" || got.Metrics["overshoot_percent"] != 3.25 {
+		t.Errorf("decoded = %+v, want the run's metadata and metrics", got)
+	}
+}
+
+func TestInfo_MissingFiles(t *testing.T) {
+	if _, err := execCmd(newInfoCmd(), t.TempDir()); err == nil || !strings.Contains(err.Error(), "no metadata.json") {
+		t.Errorf("error = %v, want a missing metadata.json message", err)
+	}
+	if _, err := execCmd(newInfoCmd(), fakeRunDir(t, false)); err == nil || !strings.Contains(err.Error(), "no metrics.json") {
+		t.Errorf("error = %v, want a missing metrics.json message", err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+func newListCmd() *cobra.Command {
+	var (
+		base   string
+		filter string
+		tag    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List runs in an output directory",
+		Long: `List runs in an output directory, optionally filtered by tag or by an
+expression over params, metrics and run stats, e.g. --filter "kp>0.03,overshoot_percent<5".`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := artifacts.ParseFilter(filter)
+			if err != nil {
+				return err
+			}
+
+			runs, err := artifacts.BuildIndex(base)
+			if err != nil {
+				return err
+			}
+			if tag != "" {
+				runs = artifacts.FilterByTag(runs, tag)
+			}
+
+			var predErr error
+			runs = artifacts.FilterIndex(runs, func(md artifacts.Metadata) bool {
+				metrics, err := artifacts.ReadMetrics(md.Dir)
+				if err != nil && !errors.Is(err, os.ErrNotExist) {
+					predErr = err
+					return false
+				}
+				values := artifacts.RunValues(md, metrics)
+				stats, err := artifacts.ReadRunStats(md.Dir)
+				if err != nil && !errors.Is(err, os.ErrNotExist) {
+					predErr = err
+					return false
+				}
+				if err == nil {
+					for k, v := range stats.Values() {
+						values[k] = v
+					}
+				}
+				return f.Match(values)
+			})
+			if predErr != nil {
+				return predErr
+			}
+
+			out := cmd.OutOrStdout()
+			for _, md := range runs {
+				if len(md.Tags) > 0 {
+					_, _ = fmt.Fprintf(out, "%s\t[%s]\n", md.RunID, strings.Join(md.Tags, ","))
+				} else {
+					_, _ = fmt.Fprintln(out, md.RunID)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&base, "out", "runs", "base output directory to scan")
+	cmd.Flags().StringVar(&filter, "filter", "", "filter expression over params and metrics (e.g. \"kp>0.03,overshoot_percent<5\")")
+	cmd.Flags().StringVar(&tag, "tag", "", "only list runs carrying this tag")
+
+	return cmd
+}
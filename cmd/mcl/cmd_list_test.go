@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestList_FilterReadsRenamedRunDir(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots")
+	base := filepath.Dir(dir)
+	runID := filepath.Base(dir)
+
+	// The metrics must come from the listed directory, not from one named after the run ID
+	renamed := filepath.Join(base, "renamed")
+	if err := os.Rename(dir, renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := execCmd(newListCmd(), "--out", base, "--filter", "settling_time_seconds>0")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if got := strings.TrimSpace(out); got != runID {
+		t.Errorf("list output = %q, want %q", got, runID)
+	}
+}
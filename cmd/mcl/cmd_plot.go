@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/plotting"
+)
+
+func newPlotCmd() *cobra.Command {
+	var (
+		x         string
+		ys        []string
+		out       string
+		plotTheme string
+		plotGrid  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plot <runDir>",
+		Short: "Plot columns of a run's samples.csv against each other",
+		Long: `Read <runDir>/samples.csv and plot one or more columns (--y, repeatable)
+against another (--x, default t) to a PNG, e.g. a signal column such as
+disturbance_rpm_per_s over time. Any samples.csv column can be used, including
+the signal columns of the run; saturated and integrated plot as 1 or 0, and
+samples without a value for a column (an empty signal cell) are left out.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+			if len(ys) == 0 {
+				return fmt.Errorf("at least one --y column is required")
+			}
+			theme, err := plotting.ParseTheme(plotTheme)
+			if err != nil {
+				return err
+			}
+			theme.Grid = plotGrid
+
+			path := filepath.Join(dir, "samples.csv")
+			header, err := artifacts.ReadSamplesHeader(path)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return runFileError(dir, "samples.csv", err)
+				}
+				return err
+			}
+			if err := checkPlotColumns(header, append([]string{x}, ys...)); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			samples, err := artifacts.ReadSamplesCSV(path)
+			if err != nil {
+				return err
+			}
+
+			units := artifacts.DefaultUnits()
+			if md, err := artifacts.ReadMetadata(dir); err == nil {
+				for k, u := range md.Units {
+					units[k] = u
+				}
+			}
+			if out == "" {
+				out = filepath.Join(dir, strings.Join(ys, "_")+"_vs_"+x+".png")
+			}
+			lines := plotLines(samples, x, ys)
+			points := 0
+			for _, l := range lines {
+				points += len(l.X)
+			}
+			if points == 0 {
+				return fmt.Errorf("%s: no samples have values for %s against %s", path, strings.Join(ys, ", "), x)
+			}
+			if err := plotting.WriteLinesPlot(out, strings.Join(ys, ", "), columnLabel(x, units), yAxisLabel(ys, units), lines, theme); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Plotted %d samples to %s\n", len(samples), out)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&x, "x", "t", "column for the x axis")
+	cmd.Flags().StringArrayVar(&ys, "y", nil, "column to plot against --x (repeatable)")
+	cmd.Flags().StringVar(&out, "out", "", "output PNG file (default: <runDir>/<y>_vs_<x>.png)")
+	cmd.Flags().StringVar(&plotTheme, "plot-theme", "light", "plot color theme: light or dark")
+	cmd.Flags().BoolVar(&plotGrid, "plot-grid", false, "draw grid lines")
+
+	return cmd
+}
+
+// checkPlotColumns reports the first of names that is not in header, listing
+// the available columns.
+func checkPlotColumns(header, names []string) error {
+	have := make(map[string]bool, len(header))
+	for _, h := range header {
+		have[h] = true
+	}
+	for _, n := range names {
+		if !have[n] {
+			return fmt.Errorf("unknown column %q (have %s)", n, strings.Join(header, ", "))
+		}
+	}
+	return nil
+}
+
+// plotLines returns one line of y against x per column in ys, leaving out
+// samples that lack either value or hold a non-finite one.
+func plotLines(samples []experiment.Sample, x string, ys []string) []plotting.Line {
+	lines := make([]plotting.Line, len(ys))
+	for i, y := range ys {
+		lines[i].Label = y
+		for _, s := range samples {
+			xv, okX := artifacts.ColumnValue(s, x)
+			yv, okY := artifacts.ColumnValue(s, y)
+			if !okX || !okY || !isFinite(xv) || !isFinite(yv) {
+				continue
+			}
+			lines[i].X = append(lines[i].X, xv)
+			lines[i].Y = append(lines[i].Y, yv)
+		}
+	}
+	return lines
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// columnLabel returns the axis label of a column: its name and its unit, if
+// known and not dimensionless.
+func columnLabel(name string, units map[string]string) string {
+	if u := units[name]; u != "" && u != "1" {
+		return fmt.Sprintf("%s (%s)", name, u)
+	}
+	return name
+}
+
+// yAxisLabel labels the y axis with the shared unit of ys, or no unit when
+// they differ.
+func yAxisLabel(ys []string, units map[string]string) string {
+	if len(ys) == 1 {
+		return columnLabel(ys[0], units)
+	}
+	for _, y := range ys[1:] {
+		if units[y] != units[ys[0]] {
+			return "Value"
+		}
+	}
+	if u := units[ys[0]]; u != "" && u != "1" {
+		return fmt.Sprintf("Value (%s)", u)
+	}
+	return "Value"
+}
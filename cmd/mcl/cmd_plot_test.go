@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+func TestPlot_SignalColumn(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--disturbance-enabled")
+
+	// The status message goes to stderr
+	var msg bytes.Buffer
+	cmd := newPlotCmd()
+	cmd.SetErr(&msg)
+	if _, err := execCmd(cmd, dir, "--y", "disturbance_rpm_per_s"); err != nil {
+		t.Fatalf("plot failed: %v", err)
+	}
+	path := filepath.Join(dir, "disturbance_rpm_per_s_vs_t.png")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("plot was not written: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Error("plot is empty")
+	}
+	if !strings.Contains(msg.String(), path) {
+		t.Errorf("message = %q, want the output path", msg.String())
+	}
+}
+
+func TestPlot_SeveralColumnsToOut(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots")
+	out := filepath.Join(t.TempDir(), "terms.png")
+
+	if _, err := execCmd(newPlotCmd(), dir, "--x", "error", "--y", "p", "--y", "i", "--y", "saturated", "--out", out); err != nil {
+		t.Fatalf("plot failed: %v", err)
+	}
+	if info, err := os.Stat(out); err != nil || info.Size() == 0 {
+		t.Errorf("--out was not written: %v", err)
+	}
+}
+
+func TestPlot_Errors(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots")
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no y", []string{dir}, "--y"},
+		{"unknown y", []string{dir, "--y", "speed"}, `unknown column "speed"`},
+		{"unknown x", []string{dir, "--x", "time", "--y", "u"}, `unknown column "time"`},
+		{"signal the run lacks", []string{dir, "--y", "disturbance_rpm_per_s"}, "unknown column"},
+		{"not a run", []string{t.TempDir(), "--y", "u"}, "no samples.csv"},
+		{"unknown theme", []string{dir, "--y", "u", "--plot-theme", "sepia"}, "unknown plot theme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := execCmd(newPlotCmd(), tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("plot error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Errors list the available columns
+	_, err := execCmd(newPlotCmd(), dir, "--y", "speed")
+	if err == nil || !strings.Contains(err.Error(), "out_raw") {
+		t.Errorf("plot error = %v, want the header listed", err)
+	}
+}
+
+func TestPlotLines_SkipsAbsentValues(t *testing.T) {
+	samples, err := artifacts.ReadSamples(strings.NewReader(
+		"t,dt,target,actual,error,u,p,i,d,out_raw,saturated,integrated,load\n" +
+			"0,0.1,1,0,1,2,2,0,0,2,true,false,5\n" +
+			"0.1,0.1,1,0.5,0.5,1,1,0,0,1,false,true,\n" +
+			"0.2,0.1,1,1,0,0,0,0,0,0,false,true,7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := plotLines(samples, "t", []string{"load", "saturated"})
+	if got := lines[0]; len(got.X) != 2 || got.X[1] != 0.2 || got.Y[1] != 7 {
+		t.Errorf("load line = %+v, want the two samples carrying it", got)
+	}
+	if got := lines[1]; len(got.Y) != 3 || got.Y[0] != 1 || got.Y[1] != 0 {
+		t.Errorf("saturated line = %+v, want 1, 0, 0", got)
+	}
+}
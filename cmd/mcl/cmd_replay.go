@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+func newReplayCmd() *cobra.Command {
+	var out outputOptions
+
+	cmd := &cobra.Command{
+		Use:   "replay <runDir>",
+		Short: "Re-run an experiment from its metadata.json",
+		Long: `Re-run an experiment from the params recorded in <runDir>/metadata.json,
+writing the result to a new run directory. Simulations are deterministic, so
+the replayed metrics match the original run.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			md, err := artifacts.ReadMetadata(args[0])
+			if err != nil {
+				return err
+			}
+			if md.Plant != "dc-motor" {
+				return fmt.Errorf("replay: plant %q: %w", md.Plant, errs.ErrUnknownPlant)
+			}
+			if md.Kind != "sim" || md.Experiment != "step" {
+				return fmt.Errorf("replay: unsupported run %s/%s/%s", md.Kind, md.Plant, md.Experiment)
+			}
+
+			sc, err := stepScenarioFromParams(md.Params)
+			if err != nil {
+				return fmt.Errorf("replay %s: %w", md.RunID, err)
+			}
+			if !cmd.Flags().Changed("tag") {
+				out.Tags = md.Tags
+			}
+
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "Replaying:", md.RunID)
+			_, err = executeStep(sc, out, cmd.OutOrStdout())
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&out.BaseDir, "out", "runs", "base output directory")
+	cmd.Flags().StringArrayVar(&out.Tags, "tag", nil, "tags for the new run (default: the original run's tags)")
+	cmd.Flags().BoolVar(&out.NoPlots, "no-plots", false, "skip plot rendering (CSV, metrics and logs are still written)")
+	cmd.Flags().StringVar(&out.PlotTheme, "plot-theme", "light", "plot color theme: light or dark")
+	cmd.Flags().BoolVar(&out.PlotGrid, "plot-grid", false, "draw grid lines on plots")
+	cmd.Flags().BoolVar(&out.PlotRaw, "plot-raw", false, "overlay the unclamped controller output (out_raw) on control.png")
+	cmd.Flags().BoolVar(&out.PlotPhase, "plot-phase", false, "also write phase_portrait.png (error rate against error)")
+	cmd.Flags().StringVar(&out.LogFormat, "log-format", "text", "out.log line format: text (key=value) or json")
+	cmd.Flags().BoolVar(&out.Debug, "debug", false, "write the per-step controller state to debug.csv and debug-level records to out.log")
+	cmd.Flags().BoolVar(&out.StableEnv, "stable-env", false, "record go_version under volatile_environment so metadata diffs across toolchains")
+	cmd.Flags().IntVar(&out.MaxSteps, "max-steps", experiment.DefaultMaxSteps, "fail instead of running more than this many steps (duration/dt); negative disables the cap")
+	cmd.Flags().BoolVar(&out.Deterministic, "deterministic", false, "fix the run timestamp (to $MCL_SOURCE_DATE_EPOCH, or the Unix epoch) and omit wall-clock timings, for byte-stable artifacts")
+
+	return cmd
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
+)
+
+// onlyRunDir returns the single run directory under base.
+func onlyRunDir(t *testing.T, base string) string {
+	t.Helper()
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("found %d run directories in %s, want 1", len(entries), base)
+	}
+	return filepath.Join(base, entries[0].Name())
+}
+
+func TestReplay_ReproducesRun(t *testing.T) {
+	orig := runSimStepCLI(t,
+		"--kp", "0.03", "--ki", "0.08", "--deadzone", "0.2",
+		"--disturbance-enabled", "--disturbance-start", "6", "--disturbance-magnitude", "80",
+		"--tag", "original", "--no-plots")
+
+	replayBase := t.TempDir()
+	if _, err := execCmd(newReplayCmd(), orig, "--out", replayBase, "--no-plots"); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	replayed := onlyRunDir(t, replayBase)
+
+	// Deterministic simulation: identical samples and metrics
+	for _, name := range []string{"samples.csv", "metrics.json"} {
+		a, err := os.ReadFile(filepath.Join(orig, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(replayed, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(a, b) {
+			t.Errorf("replayed %s differs from the original", name)
+		}
+	}
+
+	md, err := artifacts.ReadMetadata(replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !md.HasTag("original") {
+		t.Errorf("replayed tags = %v, want the original run's tags", md.Tags)
+	}
+}
+
+func TestReplay_IncompleteParams(t *testing.T) {
+	dir := t.TempDir()
+	md := artifacts.Metadata{
+		RunID: "incomplete", Kind: "sim", Plant: "dc-motor", Experiment: "step",
+		Params: map[string]any{"kp": 0.02},
+	}
+	if err := artifacts.WriteJSON(filepath.Join(dir, "metadata.json"), md); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execCmd(newReplayCmd(), dir, "--out", t.TempDir()); err == nil {
+		t.Error("replay should fail when params are incomplete")
+	}
+}
+
+func TestReplay_UnknownPlant(t *testing.T) {
+	dir := t.TempDir()
+	md := artifacts.Metadata{RunID: "other", Kind: "sim", Plant: "pendulum", Experiment: "step"}
+	if err := artifacts.WriteJSON(filepath.Join(dir, "metadata.json"), md); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := execCmd(newReplayCmd(), dir, "--out", t.TempDir()); !errors.Is(err, errs.ErrUnknownPlant) {
+		t.Errorf("replay error = %v, want ErrUnknownPlant", err)
+	}
+}
+
+func TestStepScenario_ParamsRoundTrip(t *testing.T) {
+	sc := stepScenario{Kp: 0.1, Ki: 0.2, Kd: 0.3, TargetRPM: 500, DurationS: 3, DTS: 0.002, DeadzoneV: 0.5, OutMinV: -6, OutMaxV: 12, WarmStart: true, SafeShutdown: true}
+	sc.AntiWindup = pid.AntiWindupBackCalc
+	sc.Kt = 2.5
+	sc.SatHysteresisV = 0.5
+	sc.Reference = referenceConfig{Type: "chirp", AmplitudeRPM: 50, FreqStartHz: 0.5, FreqEndHz: 4}
+	sc.Disturbance.Enabled = true
+	sc.Disturbance.StartS = 1
+	sc.Disturbance.DurationS = 0.5
+	sc.Disturbance.MagnitudeRPMPerS = 20
+	sc.MeasurementNoiseRPM, sc.LoadNoiseRPMPerS, sc.Seed = 2, 30, 42
+
+	got, err := stepScenarioFromParams(sc.params())
+	if err != nil {
+		t.Fatalf("stepScenarioFromParams() error = %v", err)
+	}
+	if got != sc {
+		t.Errorf("round trip = %+v, want %+v", got, sc)
+	}
+}
+
+func TestReplay_ReproducesNoisyRunAndRecordsSeeds(t *testing.T) {
+	orig := runSimStepCLI(t, "--no-plots", "--seed", "7",
+		"--measurement-noise", "3", "--load-noise", "40",
+		"--disturbance-enabled", "--disturbance-start", "4")
+
+	md, err := artifacts.ReadMetadata(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every active noise source, with the seed it actually drew from
+	want := map[string]int64{
+		"measurement_noise":      componentSeed(7, "measurement_noise"),
+		"disturbance_load_noise": componentSeed(7, "load_noise"),
+	}
+	if !reflect.DeepEqual(md.Seeds, want) {
+		t.Errorf("seeds = %v, want %v", md.Seeds, want)
+	}
+	if want["measurement_noise"] == want["disturbance_load_noise"] {
+		t.Error("noise sources share a seed, want independent streams")
+	}
+
+	replayBase := t.TempDir()
+	if _, err := execCmd(newReplayCmd(), orig, "--out", replayBase, "--no-plots"); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	replayed := onlyRunDir(t, replayBase)
+
+	a, err := os.ReadFile(filepath.Join(orig, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(replayed, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("replayed samples.csv differs from the noisy original")
+	}
+	if md2, err := artifacts.ReadMetadata(replayed); err != nil || !reflect.DeepEqual(md2.Seeds, want) {
+		t.Errorf("replayed seeds = %v (err %v), want %v", md2.Seeds, err, want)
+	}
+
+	// Another seed gives another run
+	other := runSimStepCLI(t, "--no-plots", "--seed", "8",
+		"--measurement-noise", "3", "--load-noise", "40",
+		"--disturbance-enabled", "--disturbance-start", "4")
+	c, err := os.ReadFile(filepath.Join(other, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, c) {
+		t.Error("runs with different seeds have identical samples")
+	}
+}
+
+func TestSimStep_DeterministicRunHasNoSeeds(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--disturbance-enabled")
+	md, err := artifacts.ReadMetadata(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Seeds) != 0 {
+		t.Errorf("seeds = %v, want none without noise", md.Seeds)
+	}
+}
+
+func TestStepScenario_SeedFromJSON(t *testing.T) {
+	params := stepScenario{Kp: 0.02, TargetRPM: 1000, DurationS: 1, DTS: 0.001}.params()
+
+	// Decoded JSON numbers are float64
+	params["seed"] = float64(123)
+	if sc, err := stepScenarioFromParams(params); err != nil || sc.Seed != 123 {
+		t.Errorf("seed = %v (err %v), want 123", sc.Seed, err)
+	}
+	params["seed"] = 1.5
+	if _, err := stepScenarioFromParams(params); err == nil {
+		t.Error("stepScenarioFromParams() should reject a fractional seed")
+	}
+
+	delete(params, "seed")
+	if sc, err := stepScenarioFromParams(params); err != nil || sc.Seed != defaultSeed {
+		t.Errorf("seed of an older run = %v (err %v), want the default %v", sc.Seed, err, defaultSeed)
+	}
+}
+
+func TestStepScenario_LimitsDefaultForOlderRuns(t *testing.T) {
+	params := stepScenario{Kp: 0.02, TargetRPM: 1000, DurationS: 1, DTS: 0.001}.params()
+	delete(params, "out_min_v")
+	delete(params, "out_max_v")
+
+	sc, err := stepScenarioFromParams(params)
+	if err != nil {
+		t.Fatalf("stepScenarioFromParams() error = %v", err)
+	}
+	if sc.OutMinV != -24 || sc.OutMaxV != 24 {
+		t.Errorf("limits = [%v, %v], want the PID defaults [-24, 24]", sc.OutMinV, sc.OutMaxV)
+	}
+}
+
+func TestStepScenario_DefaultsForOlderRuns(t *testing.T) {
+	params := stepScenario{Kp: 0.02, TargetRPM: 1000, DurationS: 1, DTS: 0.001}.params()
+	delete(params, "anti_windup")
+	delete(params, "kt")
+	delete(params, "sat_hysteresis_v")
+	for k := range params {
+		if strings.HasPrefix(k, "reference") {
+			delete(params, k)
+		}
+	}
+
+	sc, err := stepScenarioFromParams(params)
+	if err != nil {
+		t.Fatalf("stepScenarioFromParams() error = %v", err)
+	}
+	if sc.AntiWindup != pid.AntiWindupFreeze || sc.Kt != defaultKt || sc.SatHysteresisV != 0 {
+		t.Errorf("anti-windup = %v (kt %v, hysteresis %vV), want freeze (kt %v, no hysteresis)",
+			sc.AntiWindup, sc.Kt, sc.SatHysteresisV, defaultKt)
+	}
+	if sc.Reference != (referenceConfig{Type: "step"}) {
+		t.Errorf("reference = %+v, want a step", sc.Reference)
+	}
+
+	params["anti_windup"] = "clamp"
+	if _, err := stepScenarioFromParams(params); err == nil {
+		t.Error("stepScenarioFromParams() should reject an unknown anti-windup strategy")
+	}
+}
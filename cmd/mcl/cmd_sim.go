@@ -12,6 +12,7 @@ func newSimCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(newSimStepCmd())
+	cmd.AddCommand(newSimSoakCmd())
 
 	return cmd
 }
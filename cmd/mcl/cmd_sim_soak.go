@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// defaultSoakDurationS is the --duration default of sim soak: one simulated hour.
+const defaultSoakDurationS = 3600.0
+
+func newSimSoakCmd() *cobra.Command {
+	var (
+		sc               stepScenario
+		configPath       string
+		intervalS        float64
+		maxErrorDrift    float64
+		maxIntegralDrift float64
+		maxSteps         int
+	)
+	scenarioFlags := pflag.NewFlagSet("scenario", pflag.ContinueOnError)
+
+	cmd := &cobra.Command{
+		Use:   "soak",
+		Short: "Run a long simulation and report drift of the error and integrator",
+		Long: `Run the step scenario for a long time (default: one simulated hour) without
+keeping its samples, and print a snapshot of the mean error and the integrator
+every --snapshot-interval. The drift of each over the run, from a least-squares
+fit of the snapshots after the first (which holds the initial transient), is
+checked against --max-error-drift and --max-integral-drift; the command fails
+if either is exceeded, e.g. when float64 accumulation makes a settled loop
+wander. Nothing is written to disk.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configPath != "" {
+				if err := applyStepConfig(scenarioFlags, &sc, configPath); err != nil {
+					return err
+				}
+			}
+			if err := sc.validate(); err != nil {
+				return err
+			}
+			ctrl, sys, cfg := sc.build()
+			cfg.MaxSteps = maxSteps
+			report, wall, err := experiment.RunSoak(sys, ctrl, cfg, intervalS)
+			out := cmd.OutOrStdout()
+			writeSoakReport(out, report)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(out, "Simulated %d steps (%gs) in %s\n", report.Steps, sc.DurationS, wall)
+			return checkSoakDrift(out, report, cfg.Duration, maxErrorDrift, maxIntegralDrift)
+		},
+	}
+
+	bindStepScenarioFlags(scenarioFlags, &sc)
+	sc.DurationS = defaultSoakDurationS
+	scenarioFlags.Lookup("duration").DefValue = strconv.FormatFloat(defaultSoakDurationS, 'g', -1, 64)
+	cmd.Flags().AddFlagSet(scenarioFlags)
+	cmd.Flags().StringVar(&configPath, "config", "", "load the scenario from a YAML file (explicit flags take precedence)")
+	// Soak keeps no samples, so the memory cap of sim step does not apply
+	cmd.Flags().IntVar(&maxSteps, "max-steps", -1, "fail instead of running more than this many steps (duration/dt); negative disables the cap")
+	cmd.Flags().Float64Var(&intervalS, "snapshot-interval", 60, "simulated time between snapshots (s)")
+	cmd.Flags().Float64Var(&maxErrorDrift, "max-error-drift", 1e-3, "largest accepted change of the mean error over the run (RPM)")
+	cmd.Flags().Float64Var(&maxIntegralDrift, "max-integral-drift", 1e-3, "largest accepted change of the integrator over the run (RPM*s)")
+
+	return cmd
+}
+
+// writeSoakReport prints one line per snapshot.
+func writeSoakReport(w io.Writer, report experiment.SoakReport) {
+	_, _ = fmt.Fprintf(w, "%12s  %14s  %14s  %10s\n", "t (s)", "mean error", "integral", "u (V)")
+	for _, s := range report.Snapshots {
+		_, _ = fmt.Fprintf(w, "%12.2f  %14.6g  %14.6g  %10.4f\n", s.T, s.MeanError, s.Integral, s.U)
+	}
+}
+
+// checkSoakDrift prints the drift of the error and the integrator over a run
+// of duration seconds, and returns an error if either exceeds its limit.
+func checkSoakDrift(w io.Writer, report experiment.SoakReport, duration, maxError, maxIntegral float64) error {
+	if math.IsNaN(report.ErrorDrift) {
+		_, _ = fmt.Fprintln(w, "Drift: too few snapshots to fit (need at least 3; shorten --snapshot-interval)")
+		return nil
+	}
+	errorDrift := math.Abs(report.ErrorDrift) * duration
+	integralDrift := math.Abs(report.IntegralDrift) * duration
+	_, _ = fmt.Fprintf(w, "Error drift:    %.3g over the run (max %g)\n", errorDrift, maxError)
+	_, _ = fmt.Fprintf(w, "Integral drift: %.3g over the run (max %g)\n", integralDrift, maxIntegral)
+	switch {
+	case errorDrift > maxError:
+		return fmt.Errorf("soak: mean error drifted by %.3g over the run (max %g)", errorDrift, maxError)
+	case integralDrift > maxIntegral:
+		return fmt.Errorf("soak: integrator drifted by %.3g over the run (max %g)", integralDrift, maxIntegral)
+	}
+	return nil
+}
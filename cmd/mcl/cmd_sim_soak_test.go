@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
+)
+
+func TestSimSoak_Settled(t *testing.T) {
+	out, err := execCmd(newSimSoakCmd(), "--duration", "600", "--dt", "0.01", "--snapshot-interval", "60")
+	if err != nil {
+		t.Fatalf("sim soak failed: %v\n%s", err, out)
+	}
+	// Header plus one line per snapshot
+	if lines := strings.Count(out, "\n"); lines < 11 {
+		t.Errorf("output has %d lines, want a header and 10 snapshots:\n%s", lines, out)
+	}
+	for _, want := range []string{"Simulated 60000 steps", "Error drift:", "Integral drift:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSimSoak_DriftFails(t *testing.T) {
+	// Tracking a ramp, the integrator grows with the target
+	out, err := execCmd(newSimSoakCmd(), "--duration", "300", "--dt", "0.01", "--snapshot-interval", "30",
+		"--reference", "ramp", "--ramp-rate", "2", "--target", "2000")
+	if err == nil || !strings.Contains(err.Error(), "integrator drifted") {
+		t.Errorf("sim soak error = %v, want an integrator drift error:\n%s", err, out)
+	}
+}
+
+func TestSimSoak_Errors(t *testing.T) {
+	if _, err := execCmd(newSimSoakCmd(), "--duration", "10", "--snapshot-interval", "0"); err == nil || !strings.Contains(err.Error(), "snapshot interval") {
+		t.Errorf("zero interval: error = %v", err)
+	}
+	if _, err := execCmd(newSimSoakCmd(), "--dt", "0"); err == nil {
+		t.Error("dt 0: want an error")
+	}
+
+	out, err := execCmd(newSimSoakCmd(), "--duration", "10", "--dt", "0.01", "--snapshot-interval", "5")
+	if err != nil || !strings.Contains(out, "too few snapshots") {
+		t.Errorf("two snapshots: error = %v, output:\n%s", err, out)
+	}
+}
+
+func TestSimSoak_MaxSteps(t *testing.T) {
+	// Nothing is buffered, so there is no step cap by default
+	if def := newSimSoakCmd().Flags().Lookup("max-steps").DefValue; def != "-1" {
+		t.Errorf("--max-steps default = %s, want -1 (no cap)", def)
+	}
+
+	_, err := execCmd(newSimSoakCmd(), "--duration", "600", "--dt", "0.01", "--max-steps", "1000")
+	if !errors.Is(err, errs.ErrTooManySteps) {
+		t.Errorf("sim soak error = %v, want ErrTooManySteps with an explicit cap", err)
+	}
+}
@@ -0,0 +1,744 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/spf13/pflag"
+)
+
+// runSimStepCLI executes "sim step" with the given args and returns the run directory.
+func runSimStepCLI(t *testing.T, args ...string) string {
+	t.Helper()
+	base := t.TempDir()
+
+	args = append([]string{"--out", base, "--duration", "10", "--dt", "0.01"}, args...)
+	if _, err := execCmd(newSimStepCmd(), args...); err != nil {
+		t.Fatalf("sim step failed: %v", err)
+	}
+
+	return onlyRunDir(t, base)
+}
+
+func TestSimStep_NoPlots(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots")
+
+	for _, name := range []string{"samples.csv", "metrics.json", "metadata.json", "out.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s missing: %v", name, err)
+		}
+	}
+
+	pngs, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pngs) != 0 {
+		t.Errorf("found plots %v, want none with --no-plots", pngs)
+	}
+}
+
+func TestSimStep_PlotsByDefault(t *testing.T) {
+	dir := runSimStepCLI(t)
+
+	for _, name := range []string{"velocity.png", "control.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s missing: %v", name, err)
+		}
+	}
+}
+
+func TestSimStep_StructuredLog(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots")
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("out.log has %d lines, want 2:\n%s", len(lines), data)
+	}
+	for _, want := range []string{"level=INFO", `msg="run complete"`, "run_id=" + filepath.Base(dir), "final_actual=", "realtime_factor="} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("summary line %q missing %q", lines[0], want)
+		}
+	}
+	for _, want := range []string{"msg=metrics", "overshoot_percent=", "iae=", "settling_time_seconds="} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("metrics line %q missing %q", lines[1], want)
+		}
+	}
+}
+
+func TestSimStep_DarkPlotTheme(t *testing.T) {
+	dir := runSimStepCLI(t, "--plot-theme", "dark", "--plot-grid")
+	for _, name := range []string{"velocity.png", "control.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s missing: %v", name, err)
+		}
+	}
+}
+
+func TestSimStep_PlotRaw(t *testing.T) {
+	// A large kp saturates the output at the start
+	dir := runSimStepCLI(t, "--plot-raw", "--kp", "1")
+	if _, err := os.Stat(filepath.Join(dir, "control.png")); err != nil {
+		t.Errorf("control.png missing: %v", err)
+	}
+}
+
+func TestSimStep_PlotPhase(t *testing.T) {
+	dir := runSimStepCLI(t, "--plot-phase")
+	if _, err := os.Stat(filepath.Join(dir, "phase_portrait.png")); err != nil {
+		t.Errorf("phase_portrait.png missing: %v", err)
+	}
+
+	dir = runSimStepCLI(t)
+	if _, err := os.Stat(filepath.Join(dir, "phase_portrait.png")); !os.IsNotExist(err) {
+		t.Error("phase_portrait.png written without --plot-phase")
+	}
+}
+
+func TestSimStep_Debug(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--debug")
+
+	data, err := os.ReadFile(filepath.Join(dir, "debug.csv"))
+	if err != nil {
+		t.Fatalf("debug.csv missing: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, col := range []string{"ff", "out", "integral", "derivative_skipped"} {
+		if !strings.Contains(","+lines[0]+",", ","+col+",") {
+			t.Errorf("debug.csv header %q lacks column %q", lines[0], col)
+		}
+	}
+	if len(lines) != 1001 {
+		t.Errorf("debug.csv has %d lines, want a header and 1000 rows", len(lines))
+	}
+	logData, err := os.ReadFile(filepath.Join(dir, "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(logData), "level=DEBUG") {
+		t.Errorf("out.log has no debug records:\n%s", logData)
+	}
+
+	dir = runSimStepCLI(t, "--no-plots")
+	if _, err := os.Stat(filepath.Join(dir, "debug.csv")); !os.IsNotExist(err) {
+		t.Error("debug.csv written without --debug")
+	}
+	if logData, _ := os.ReadFile(filepath.Join(dir, "out.log")); strings.Contains(string(logData), "level=DEBUG") {
+		t.Error("out.log has debug records without --debug")
+	}
+}
+
+func TestSimStep_SafeShutdown(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--safe-shutdown")
+	samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1001 {
+		t.Fatalf("%d samples, want 1000 and a shutdown sample", len(samples))
+	}
+	if last := samples[len(samples)-1]; last.U != 0 || last.T != 10 {
+		t.Errorf("final sample: t=%v u=%v, want t=10 u=0", last.T, last.U)
+	}
+}
+
+func TestSimStep_RunStats(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots")
+
+	stats, err := artifacts.ReadRunStats(dir)
+	if err != nil {
+		t.Fatalf("ReadRunStats() error = %v", err)
+	}
+	// 10 s at 0.01 s
+	if stats.StepCount != 1000 {
+		t.Errorf("step_count = %d, want 1000", stats.StepCount)
+	}
+	if !(stats.WallTimeSeconds > 0) || !(stats.RealtimeFactor > 0) {
+		t.Errorf("wall_time_seconds = %v, realtime_factor = %v, want both positive", stats.WallTimeSeconds, stats.RealtimeFactor)
+	}
+}
+
+func TestSimStep_Deterministic(t *testing.T) {
+	files := []string{"metadata.json", "samples.csv", "metrics.json", "run_stats.json", "out.log", "summary.md", "velocity.png", "control.png"}
+	run := func(args ...string) (string, map[string]string) {
+		base := t.TempDir()
+		if _, err := execCmd(newSimStepCmd(), append([]string{"--out", base, "--duration", "2", "--dt", "0.01"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+		dir := onlyRunDir(t, base)
+		contents := map[string]string{}
+		for _, name := range files {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents[name] = string(data)
+		}
+		return filepath.Base(dir), contents
+	}
+
+	id1, a := run("--deterministic")
+	id2, b := run("--deterministic")
+	if id1 != "1970-01-01T00-00-00Z_sim_dc-motor_step" || id2 != id1 {
+		t.Errorf("run IDs %q and %q, want both at the Unix epoch", id1, id2)
+	}
+	for _, name := range files {
+		if a[name] != b[name] {
+			t.Errorf("%s differs between deterministic runs", name)
+		}
+	}
+	if strings.Contains(a["out.log"], "wall_time") {
+		t.Error("out.log has wall_time in deterministic mode")
+	}
+	if strings.Contains(a["run_stats.json"], "wall_time") || !strings.Contains(a["run_stats.json"], `"step_count": 200`) {
+		t.Errorf("run_stats.json in deterministic mode = %s, want the step count only", a["run_stats.json"])
+	}
+
+	t.Setenv(sourceDateEpochEnv, "1700000000")
+	if id, _ := run(); id != "2023-11-14T22-13-20Z_sim_dc-motor_step" {
+		t.Errorf("run ID with %s = %q", sourceDateEpochEnv, id)
+	}
+
+	t.Setenv(sourceDateEpochEnv, "yesterday")
+	if _, err := execCmd(newSimStepCmd(), "--out", t.TempDir(), "--no-plots"); err == nil || !strings.Contains(err.Error(), sourceDateEpochEnv) {
+		t.Errorf("err = %v, want an invalid %s error", err, sourceDateEpochEnv)
+	}
+}
+
+func TestSimStep_DeterministicRunDoesNotClobber(t *testing.T) {
+	base := t.TempDir()
+	run := func(args ...string) error {
+		_, err := execCmd(newSimStepCmd(), append([]string{"--out", base, "--duration", "2", "--dt", "0.01", "--deterministic"}, args...)...)
+		return err
+	}
+	if err := run("--plot-phase"); err != nil {
+		t.Fatal(err)
+	}
+	dir := onlyRunDir(t, base)
+	metrics, err := os.ReadFile(filepath.Join(dir, "metrics.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same fixed timestamp, same run ID: the second run must fail, not overwrite
+	if err := run("--kp", "0.05"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("second deterministic run error = %v, want an existing run directory error", err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, "metrics.json")); string(again) != string(metrics) {
+		t.Error("metrics.json of the first run was overwritten")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "phase_portrait.png")); err != nil {
+		t.Errorf("phase_portrait.png of the first run: %v", err)
+	}
+}
+
+func TestSimStep_UnknownPlotTheme(t *testing.T) {
+	if _, err := execCmd(newSimStepCmd(), "--out", t.TempDir(), "--plot-theme", "sepia"); err == nil || !strings.Contains(err.Error(), "plot theme") {
+		t.Errorf("err = %v, want unknown plot theme", err)
+	}
+}
+
+func TestSimStep_JSONLog(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--log-format", "json")
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		if rec["msg"] == "run complete" && rec["run_id"] != filepath.Base(dir) {
+			t.Errorf("run_id = %v, want %s", rec["run_id"], filepath.Base(dir))
+		}
+	}
+}
+
+func TestSimStep_RecordsOutputLimits(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--out-min", "-5", "--out-max", "18")
+
+	md, err := artifacts.ReadMetadata(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Params["out_min_v"] != -5.0 || md.Params["out_max_v"] != 18.0 {
+		t.Errorf("params limits = [%v, %v], want [-5, 18]", md.Params["out_min_v"], md.Params["out_max_v"])
+	}
+
+	metrics, err := artifacts.ReadMetrics(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics["out_min"] != -5.0 || metrics["out_max"] != 18.0 {
+		t.Errorf("metrics limits = [%v, %v], want [-5, 18]", metrics["out_min"], metrics["out_max"])
+	}
+}
+
+func TestSimStep_Profile(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--profile")
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `msg="step profile" steps=1000 `) {
+		t.Errorf("out.log has no step profile for 1000 steps:\n%s", data)
+	}
+}
+
+func TestSimStep_Stream(t *testing.T) {
+	base := t.TempDir()
+	out, err := execCmd(newSimStepCmd(), "--out", base, "--duration", "10", "--dt", "0.01", "--no-plots",
+		"--stream", "--stream-interval", "2.5")
+	if err != nil {
+		t.Fatalf("sim step --stream failed: %v", err)
+	}
+
+	var status []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "t=") {
+			status = append(status, line)
+		}
+	}
+	wantTimes := []string{"t=2.50s ", "t=5.00s ", "t=7.50s "}
+	if len(status) != len(wantTimes) {
+		t.Fatalf("status lines = %q, want one every 2.5s of simulated time", status)
+	}
+	for i, prefix := range wantTimes {
+		if !strings.HasPrefix(status[i], prefix) || !strings.Contains(status[i], "RPM err=") {
+			t.Errorf("status line %d = %q, want %q with actual and error", i, status[i], prefix)
+		}
+	}
+	if !strings.Contains(out, "Streamed 1000 samples") {
+		t.Errorf("output missing the sample count:\n%s", out)
+	}
+
+	// The streamed run has the same artifacts as a buffered one
+	dir := onlyRunDir(t, base)
+	samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 1000 {
+		t.Errorf("samples.csv has %d rows, want 1000", len(samples))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "metrics.json")); err != nil {
+		t.Errorf("metrics.json missing: %v", err)
+	}
+}
+
+func TestSimStep_StreamHeaderMatchesBatch(t *testing.T) {
+	// Declared system keys fix the streamed columns; the runner's own
+	// feedforward_v must be declared with them or it is dropped
+	args := []string{"--no-plots", "--disturbance-enabled", "--ff-kv", "0.01"}
+	header := func(dir string) []string {
+		h, err := artifacts.ReadSamplesHeader(filepath.Join(dir, "samples.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	batch := header(runSimStepCLI(t, args...))
+	streamed := header(runSimStepCLI(t, append(args, "--stream")...))
+	if strings.Join(streamed, ",") != strings.Join(batch, ",") {
+		t.Errorf("streamed header = %v, batch header = %v", streamed, batch)
+	}
+	if batch[len(batch)-1] != experiment.SignalFeedforward {
+		t.Errorf("batch header = %v, want it to end with %s", batch, experiment.SignalFeedforward)
+	}
+}
+
+func TestSimStep_StreamErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--stream", "--profile"}, "cannot be combined"},
+		{[]string{"--stream", "--stream-interval", "0"}, "must be > 0"},
+	}
+	for _, tt := range tests {
+		base := t.TempDir()
+		if _, err := execCmd(newSimStepCmd(), append([]string{"--out", base, "--no-plots"}, tt.args...)...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want it to mention %q", tt.args, err, tt.want)
+		}
+		if entries, _ := os.ReadDir(base); len(entries) != 0 {
+			t.Errorf("%v: run directory created despite the error", tt.args)
+		}
+	}
+}
+
+func TestSimStep_CSVComment(t *testing.T) {
+	dir := runSimStepCLI(t, "--no-plots", "--csv-comment", "--kp", "0.03")
+
+	data, err := os.ReadFile(filepath.Join(dir, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := strings.SplitN(string(data), "\n", 2)[0]
+	if first != "# kp=0.03 ki=0.05 kd=0 out_min_v=-24 out_max_v=24 dt_s=0.01" {
+		t.Errorf("first line = %q, want the controller configuration comment", first)
+	}
+	if _, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv")); err != nil {
+		t.Errorf("ReadSamplesCSV() error = %v", err)
+	}
+}
+
+func TestSimStep_CSVUnits(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantUnits string // prefix of the second line
+	}{
+		{nil, "s,s,rpm,rpm,rpm,v,"},
+		{[]string{"--observe", "position", "--target", "5", "--kp", "2", "--ki", "0"}, "s,s,rev,rev,rev,v,"},
+	}
+	for _, tt := range tests {
+		dir := runSimStepCLI(t, append([]string{"--no-plots", "--csv-units"}, tt.args...)...)
+
+		data, err := os.ReadFile(filepath.Join(dir, "samples.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.SplitN(string(data), "\n", 3)
+		if !strings.HasPrefix(lines[1], tt.wantUnits) {
+			t.Errorf("%v: units row = %q, want prefix %q", tt.args, lines[1], tt.wantUnits)
+		}
+		samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+		if err != nil || len(samples) != 1000 {
+			t.Errorf("%v: ReadSamplesCSV() = %d samples, err %v; want 1000", tt.args, len(samples), err)
+		}
+	}
+}
+
+func TestSimStep_AntiWindupFlag(t *testing.T) {
+	tests := []struct {
+		args   []string
+		want   pid.AntiWindup
+		wantKt float64
+	}{
+		{nil, pid.AntiWindupFreeze, defaultKt},
+		{[]string{"--anti-windup", "freeze"}, pid.AntiWindupFreeze, defaultKt},
+		{[]string{"--anti-windup", "back-calc", "--kt", "3"}, pid.AntiWindupBackCalc, 3},
+		{[]string{"--anti-windup", "tracking", "--kt", "20"}, pid.AntiWindupTracking, 20},
+		{[]string{"--anti-windup", "none"}, pid.AntiWindupNone, defaultKt},
+	}
+	for _, tt := range tests {
+		var sc stepScenario
+		fs := pflag.NewFlagSet("scenario", pflag.ContinueOnError)
+		bindStepScenarioFlags(fs, &sc)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		ctrl, _, _ := sc.build()
+		if ctrl.AntiWindup != tt.want || ctrl.Kt != tt.wantKt {
+			t.Errorf("%v: controller anti-windup = %v (kt %v), want %v (kt %v)",
+				tt.args, ctrl.AntiWindup, ctrl.Kt, tt.want, tt.wantKt)
+		}
+	}
+
+	fs := pflag.NewFlagSet("scenario", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bindStepScenarioFlags(fs, &stepScenario{})
+	if err := fs.Parse([]string{"--anti-windup", "clamp"}); err == nil {
+		t.Error("--anti-windup clamp should be rejected")
+	}
+}
+
+func TestSimStep_AntiWindupNoneWindsUp(t *testing.T) {
+	// The initial 1000 RPM error saturates the 12 V output during the rise
+	peakI := func(mode string) float64 {
+		dir := runSimStepCLI(t, "--no-plots", "--out-max", "12", "--anti-windup", mode)
+		md, err := artifacts.ReadMetadata(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if md.Params["anti_windup"] != mode {
+			t.Errorf("params anti_windup = %v, want %q", md.Params["anti_windup"], mode)
+		}
+		samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		peak := 0.0
+		for _, s := range samples {
+			peak = max(peak, s.I)
+		}
+		return peak
+	}
+
+	freeze, backCalc, none := peakI("freeze"), peakI("back-calc"), peakI("none")
+	t.Logf("peak I term: freeze %.2f V, back-calc %.2f V, none %.2f V", freeze, backCalc, none)
+	if none < 1.2*max(freeze, backCalc) {
+		t.Errorf("none: peak I term = %v, want it wound up well past freeze (%v) and back-calc (%v)", none, freeze, backCalc)
+	}
+}
+
+func TestSimStep_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+	}{
+		{"zero dt", []string{"--dt", "0"}, errs.ErrInvalidDT},
+		{"negative duration", []string{"--duration", "-1"}, errs.ErrInvalidDuration},
+		{"duration below dt", []string{"--duration", "0.005", "--dt", "0.01"}, errs.ErrNoSamples},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			if _, err := execCmd(newSimStepCmd(), append([]string{"--out", base, "--no-plots"}, tt.args...)...); !errors.Is(err, tt.wantErr) {
+				t.Errorf("sim step error = %v, want %v", err, tt.wantErr)
+			}
+			if entries, _ := os.ReadDir(base); len(entries) != 0 {
+				t.Errorf("failed run left %d run directories", len(entries))
+			}
+		})
+	}
+}
+
+func TestSimStep_InvalidDisturbance(t *testing.T) {
+	base := t.TempDir()
+	_, err := execCmd(newSimStepCmd(), "--out", base, "--no-plots", "--disturbance-enabled", "--disturbance-duration", "-1")
+	if err == nil || !strings.Contains(err.Error(), "duration") {
+		t.Errorf("sim step error = %v, want a disturbance duration error", err)
+	}
+}
+
+func TestSimStep_Reference(t *testing.T) {
+	tests := []struct {
+		args []string
+		want func(t float64) float64
+	}{
+		{[]string{"--reference", "step"}, func(float64) float64 { return 1000 }},
+		{[]string{"--reference", "ramp", "--ramp-rate", "250"}, func(t float64) float64 { return min(250*t, 1000) }},
+		{[]string{"--reference", "sine", "--amplitude", "100", "--freq", "0.5"}, func(t float64) float64 {
+			return 1000 + 100*math.Sin(2*math.Pi*0.5*t)
+		}},
+		// runSimStepCLI runs for 10 s
+		{[]string{"--reference", "chirp", "--amplitude", "100", "--freq-start", "0.1", "--freq-end", "1"}, func(t float64) float64 {
+			return 1000 + 100*math.Sin(2*math.Pi*(0.1*t+0.9/20*t*t))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args[1], func(t *testing.T) {
+			dir := runSimStepCLI(t, append([]string{"--no-plots"}, tt.args...)...)
+			samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range samples {
+				// samples.csv keeps 6 decimals
+				if want := tt.want(s.T); math.Abs(s.Target-want) > 1e-5 {
+					t.Fatalf("t=%v: target = %v, want %v", s.T, s.Target, want)
+				}
+			}
+
+			md, err := artifacts.ReadMetadata(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if md.Params["reference"] != tt.args[1] {
+				t.Errorf("params reference = %v, want %q", md.Params["reference"], tt.args[1])
+			}
+		})
+	}
+}
+
+func TestSimStep_ReferenceTrackingPlot(t *testing.T) {
+	dir := runSimStepCLI(t, "--reference", "ramp", "--ramp-rate", "500")
+	if _, err := os.Stat(filepath.Join(dir, "tracking.png")); err != nil {
+		t.Errorf("tracking.png missing for a ramp reference: %v", err)
+	}
+
+	step := runSimStepCLI(t)
+	if _, err := os.Stat(filepath.Join(step, "tracking.png")); !os.IsNotExist(err) {
+		t.Error("tracking.png should not be written for a step reference")
+	}
+}
+
+func TestSimStep_ReferenceRequiresFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		missing string
+	}{
+		{[]string{"--reference", "ramp"}, "--ramp-rate"},
+		{[]string{"--reference", "sine", "--amplitude", "100"}, "--freq"},
+		{[]string{"--reference", "chirp", "--amplitude", "100", "--freq-start", "1"}, "--freq-end"},
+		{[]string{"--reference", "square"}, "unknown reference"},
+	}
+	for _, tt := range tests {
+		if _, err := execCmd(newSimStepCmd(), append([]string{"--out", t.TempDir(), "--no-plots"}, tt.args...)...); err == nil || !strings.Contains(err.Error(), tt.missing) {
+			t.Errorf("%v: error = %v, want it to mention %s", tt.args, err, tt.missing)
+		}
+	}
+}
+
+func TestSimStep_ObservePosition(t *testing.T) {
+	dir := runSimStepCLI(t, "--observe", "position", "--target", "5", "--kp", "1", "--ki", "0")
+
+	metrics, err := artifacts.ReadMetrics(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sse, ok := metrics["steady_state_error"].(float64); !ok || math.Abs(sse) > 0.01 {
+		t.Errorf("steady_state_error = %v rev, want the 5 rev setpoint tracked", metrics["steady_state_error"])
+	}
+
+	md, err := artifacts.ReadMetadata(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Params["observe"] != "position" {
+		t.Errorf("params observe = %v, want position", md.Params["observe"])
+	}
+	for _, key := range []string{"target", "actual", "error", "steady_state_error"} {
+		if md.Units[key] != "rev" {
+			t.Errorf("units[%s] = %q, want rev", key, md.Units[key])
+		}
+	}
+	if md.Units["velocity_rpm"] != "rpm" {
+		t.Errorf("units[velocity_rpm] = %q, want rpm", md.Units["velocity_rpm"])
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "position.png")); err != nil {
+		t.Errorf("position.png missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "velocity.png")); !os.IsNotExist(err) {
+		t.Error("velocity.png should not be written in position mode")
+	}
+}
+
+func TestSimStep_Feedforward(t *testing.T) {
+	// P-only leaves a third of the setpoint as offset; the model feedforward removes it
+	dir := runSimStepCLI(t, "--no-plots", "--kp", "0.02", "--ki", "0", "--feedforward")
+
+	metrics, err := artifacts.ReadMetrics(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sse, ok := metrics["steady_state_error"].(float64); !ok || math.Abs(sse) > 1 {
+		t.Errorf("steady_state_error = %v, want ≈0 with feedforward", metrics["steady_state_error"])
+	}
+
+	md, err := artifacts.ReadMetadata(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Params["feedforward"] != true {
+		t.Errorf("params feedforward = %v, want true", md.Params["feedforward"])
+	}
+	samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ff := samples[len(samples)-1].Signals["feedforward_v"]; math.Abs(ff-10) > 1e-6 {
+		t.Errorf("feedforward_v = %v, want the 10 V steady-state input", ff)
+	}
+}
+
+func TestSimStep_FFKv(t *testing.T) {
+	finalI := func(dir string) float64 {
+		t.Helper()
+		samples, err := artifacts.ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return samples[len(samples)-1].I
+	}
+
+	// Without feedforward the integrator holds the whole 10 V input at 1000 RPM;
+	// Kv = 1/gain supplies it, leaving the integrator near zero
+	plain := runSimStepCLI(t, "--no-plots")
+	withFF := runSimStepCLI(t, "--no-plots", "--ff-kv", "0.01")
+	if iPlain, iFF := finalI(plain), finalI(withFF); !(math.Abs(iFF) < 0.1*math.Abs(iPlain)) {
+		t.Errorf("final integral term: %v V with --ff-kv, %v V without; want it reduced", iFF, iPlain)
+	}
+
+	md, err := artifacts.ReadMetadata(withFF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Params["ff_kv"] != 0.01 {
+		t.Errorf("params ff_kv = %v, want 0.01", md.Params["ff_kv"])
+	}
+	samples, err := artifacts.ReadSamplesCSV(filepath.Join(withFF, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ff := samples[0].Signals["feedforward_v"]; math.Abs(ff-10) > 1e-9 {
+		t.Errorf("feedforward_v = %v, want Kv*target = 10", ff)
+	}
+}
+
+func TestSimStep_FFKvZeroMatchesNoFeedforward(t *testing.T) {
+	read := func(dir string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, "samples.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if read(runSimStepCLI(t, "--no-plots", "--ff-kv", "0")) != read(runSimStepCLI(t, "--no-plots")) {
+		t.Error("samples.csv with --ff-kv 0 differs from a run without feedforward")
+	}
+}
+
+func TestSimStep_MaxSteps(t *testing.T) {
+	for _, args := range [][]string{nil, {"--stream"}} {
+		base := t.TempDir()
+		// 10 s at 0.01 s is 1000 steps
+		_, err := execCmd(newSimStepCmd(), append([]string{"--out", base, "--no-plots", "--duration", "10", "--dt", "0.01", "--max-steps", "500"}, args...)...)
+		if err == nil || !strings.Contains(err.Error(), "1000 steps, more than the maximum 500") || !strings.Contains(err.Error(), "--max-steps") {
+			t.Errorf("%v: error = %v, want a step cap error naming --max-steps", args, err)
+		}
+		if entries, _ := os.ReadDir(base); len(entries) != 0 {
+			t.Errorf("%v: %d run directories written, want none", args, len(entries))
+		}
+	}
+
+	// At or without the cap the run goes ahead
+	runSimStepCLI(t, "--no-plots", "--max-steps", "1000")
+	runSimStepCLI(t, "--no-plots", "--max-steps", "-1")
+}
+
+func TestSimStep_ObserveErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--observe", "torque"}, "unknown observation"},
+		{[]string{"--observe", "position", "--reference", "ramp", "--ramp-rate", "1"}, "supports only --reference step"},
+		{[]string{"--observe", "position", "--feedforward"}, "--feedforward is not supported"},
+		{[]string{"--observe", "position", "--ff-kv", "0.01"}, "--ff-kv is in V/RPM"},
+		{[]string{"--ff-kv", "0.01", "--feedforward"}, "use one"},
+		{[]string{"--ff-kv", "Inf"}, "must be finite"},
+		{[]string{"--observe", "position", "--measurement-noise", "1"}, "--measurement-noise is in RPM"},
+		{[]string{"--load-noise", "-1"}, "must be >= 0"},
+		{[]string{"--sat-hysteresis", "-0.1"}, "saturation hysteresis"},
+		{[]string{"--kp", "NaN"}, "gain kp is NaN"},
+		{[]string{"--out-min", "5", "--out-max", "-5"}, "output limits inverted"},
+	}
+	for _, tt := range tests {
+		if _, err := execCmd(newSimStepCmd(), append([]string{"--out", t.TempDir(), "--no-plots"}, tt.args...)...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want it to mention %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment/tuning"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+func newTuneCmd() *cobra.Command {
+	var (
+		sc             stepScenario
+		configPath     string
+		objective      string
+		opts           tuning.NelderMeadOptions
+		constraints    tuning.Constraints
+		trajectoryPath string
+	)
+	scenarioFlags := pflag.NewFlagSet("scenario", pflag.ContinueOnError)
+
+	cmd := &cobra.Command{
+		Use:   "tune",
+		Short: "Search PID gains that minimize an objective on a scenario",
+		Long: `Run a Nelder-Mead search over kp, ki and kd, starting from the scenario's
+gains, that minimizes the chosen objective of the step response. The scenario
+is given by the same flags (or --config file) as "sim step".
+
+Gains are kept non-negative, and --max-overshoot and --max-settling reject
+responses beyond those limits; violating candidates get a large cost penalty.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configPath != "" {
+				if err := applyStepConfig(scenarioFlags, &sc, configPath); err != nil {
+					return err
+				}
+			}
+			if err := sc.validate(); err != nil {
+				return err
+			}
+			cost, ok := tuning.Costs[objective]
+			if !ok {
+				return fmt.Errorf("unknown objective %q (want %s)", objective, strings.Join(costNames(), ", "))
+			}
+
+			scenario := func() (system.System, *pid.Controller, experiment.StepConfig) {
+				ctrl, sys, cfg := sc.build()
+				return sys, ctrl, cfg
+			}
+			start := [3]float64{sc.Kp, sc.Ki, sc.Kd}
+			res := tuning.Tune(scenario, start, cost, constraints, opts)
+
+			if trajectoryPath != "" {
+				if err := writeTrajectory(trajectoryPath, res.Trajectory); err != nil {
+					return err
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			status := "converged"
+			if !res.Converged {
+				status = "iteration limit reached"
+			}
+			_, _ = fmt.Fprintf(out, "Start: kp=%g ki=%g kd=%g cost=%g\n", start[0], start[1], start[2], res.Trajectory[0].F)
+			_, _ = fmt.Fprintf(out, "Best:  kp=%g ki=%g kd=%g cost=%g\n", res.X[0], res.X[1], res.X[2], res.F)
+			_, _ = fmt.Fprintf(out, "Search: %d iterations, %d evaluations (%s)\n", res.Iterations, res.Evaluations, status)
+			if res.F >= tuning.ViolationPenalty {
+				_, _ = fmt.Fprintln(out, "Warning: no candidate satisfied the constraints; the best gains violate them")
+			}
+			return nil
+		},
+	}
+
+	bindStepScenarioFlags(scenarioFlags, &sc)
+	cmd.Flags().AddFlagSet(scenarioFlags)
+	cmd.Flags().StringVar(&configPath, "config", "", "load the scenario from a YAML file (explicit flags take precedence)")
+	cmd.Flags().StringVar(&objective, "objective", "iae-overshoot", "cost to minimize: "+strings.Join(costNames(), " or "))
+	cmd.Flags().IntVar(&opts.MaxIter, "max-iter", 200, "maximum number of search iterations")
+	cmd.Flags().Float64Var(&opts.Tol, "tol", 1e-6, "stop once the costs across the search simplex differ by less than this")
+	cmd.Flags().Float64Var(&constraints.MaxOvershootPercent, "max-overshoot", 0, "reject gains whose response overshoots more than this (percent, 0 = no limit)")
+	cmd.Flags().Float64Var(&constraints.MaxSettlingS, "max-settling", 0, "reject gains whose response settles later than this (s, 0 = no limit)")
+	cmd.Flags().BoolVar(&constraints.AllowNegativeGains, "allow-negative-gains", false, "let the search use negative kp, ki and kd")
+	cmd.Flags().StringVar(&trajectoryPath, "trajectory", "", "write the best gains and cost after each iteration to this CSV file")
+
+	return cmd
+}
+
+// costNames returns the names of tuning.Costs, sorted.
+func costNames() []string {
+	names := make([]string, 0, len(tuning.Costs))
+	for name := range tuning.Costs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeTrajectory writes a search trajectory as CSV: iteration,kp,ki,kd,cost.
+func writeTrajectory(path string, trajectory []tuning.Point) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"iteration", "kp", "ki", "kd", "cost"})
+	for _, p := range trajectory {
+		row := []string{strconv.Itoa(p.Iteration)}
+		for _, v := range []float64{p.X[0], p.X[1], p.X[2], p.F} {
+			row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		_ = w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+// tuneArgs prepends a short, low-target scenario to args, so tune runs quickly.
+func tuneArgs(args ...string) []string {
+	return append([]string{"--duration", "2", "--dt", "0.01", "--target", "100"}, args...)
+}
+
+func TestTune_ImprovesOnStartAndRecordsTrajectory(t *testing.T) {
+	trajectory := filepath.Join(t.TempDir(), "trajectory.csv")
+	out, err := execCmd(newTuneCmd(), tuneArgs("--max-iter", "25", "--trajectory", trajectory)...)
+	if err != nil {
+		t.Fatalf("tune failed: %v", err)
+	}
+	for _, want := range []string{"Start: kp=0.02 ki=0.05 kd=0 cost=", "Best:  kp=", "25 iterations"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	f, err := os.Open(trajectory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(rows[0], ","); got != "iteration,kp,ki,kd,cost" {
+		t.Errorf("header = %s", got)
+	}
+	if len(rows) != 1+26 {
+		t.Fatalf("got %d trajectory rows, want 26 (start and 25 iterations)", len(rows)-1)
+	}
+	first, _ := strconv.ParseFloat(rows[1][4], 64)
+	last, _ := strconv.ParseFloat(rows[len(rows)-1][4], 64)
+	if !(last < first) {
+		t.Errorf("final cost %v, want below the start's %v", last, first)
+	}
+}
+
+func TestTune_Errors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--objective", "ise"}, "unknown objective"},
+		{[]string{"--reference", "ramp"}, "--ramp-rate"},
+	}
+	for _, tt := range tests {
+		if _, err := execCmd(newTuneCmd(), tuneArgs(tt.args...)...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want it to mention %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestTune_Constraints(t *testing.T) {
+	out, err := execCmd(newTuneCmd(), tuneArgs("--max-iter", "40", "--max-overshoot", "1", "--objective", "iae")...)
+	if err != nil {
+		t.Fatalf("tune failed: %v", err)
+	}
+	var kp, ki, kd float64
+	line := out[strings.Index(out, "Best:"):]
+	if _, err := fmt.Sscanf(line, "Best:  kp=%g ki=%g kd=%g", &kp, &ki, &kd); err != nil {
+		t.Fatalf("parsing %q: %v", line, err)
+	}
+	if kp < 0 || ki < 0 || kd < 0 {
+		t.Errorf("best gains kp=%v ki=%v kd=%v, want non-negative", kp, ki, kd)
+	}
+	if strings.Contains(out, "Warning") {
+		t.Errorf("unexpected warning:\n%s", out)
+	}
+
+	dir := runSimStepCLI(t, "--no-plots", "--target", "100", "--duration", "2",
+		"--kp", fmt.Sprint(kp), "--ki", fmt.Sprint(ki), "--kd", fmt.Sprint(kd))
+	metrics, err := artifacts.ReadMetrics(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overshoot := metrics["overshoot_percent"].(float64); overshoot > 1 {
+		t.Errorf("tuned gains overshoot %.3f%%, want ≤ 1%%", overshoot)
+	}
+
+	out, err = execCmd(newTuneCmd(), tuneArgs("--max-iter", "5", "--max-settling", "0.001")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Warning: no candidate satisfied the constraints") {
+		t.Errorf("want a warning for unsatisfiable constraints:\n%s", out)
+	}
+}
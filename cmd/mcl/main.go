@@ -14,6 +14,16 @@ func main() {
 	}
 
 	rootCmd.AddCommand(newSimCmd())
+	rootCmd.AddCommand(newListCmd())
+	rootCmd.AddCommand(newReplayCmd())
+	rootCmd.AddCommand(newInfoCmd())
+	rootCmd.AddCommand(newAnalyzeCSVCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newPlotCmd())
+	rootCmd.AddCommand(newGenScenariosCmd())
+	rootCmd.AddCommand(newTuneCmd())
+	rootCmd.AddCommand(newDoctorCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// execCmd runs cmd with args and returns what it wrote to stdout. Its stderr is
+// discarded unless the test set a writer with cmd.SetErr.
+func execCmd(cmd *cobra.Command, args ...string) (string, error) {
+	if args == nil {
+		// cobra falls back to os.Args (the test binary's flags) for nil args
+		args = []string{}
+	}
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if cmd.ErrOrStderr() == os.Stderr {
+		cmd.SetErr(io.Discard)
+	}
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
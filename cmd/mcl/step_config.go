@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// bindStepScenarioFlags registers the scenario flags on fs, bound to sc.
+// Keeping them in their own flag set lets --config tell them apart from
+// output flags.
+func bindStepScenarioFlags(fs *pflag.FlagSet, sc *stepScenario) {
+	fs.Float64Var(&sc.Kp, "kp", 0.02, "proportional gain")
+	fs.Float64Var(&sc.Ki, "ki", 0.05, "integral gain")
+	fs.Float64Var(&sc.Kd, "kd", 0.0, "derivative gain")
+	fs.StringVar(&sc.Observe, "observe", "velocity", "controlled quantity: velocity or position (then --target is in revolutions)")
+	fs.Float64Var(&sc.TargetRPM, "target", 1000.0, "target velocity (RPM), or position (rev) with --observe position")
+	fs.Float64Var(&sc.DurationS, "duration", 10.0, "simulation duration (s)")
+	fs.Float64Var(&sc.DTS, "dt", 0.001, "simulation timestep (s)")
+	fs.Float64Var(&sc.DeadzoneV, "deadzone", 0.0, "actuator deadzone threshold (V)")
+	fs.Float64Var(&sc.OutMinV, "out-min", -24.0, "controller output lower limit (V)")
+	fs.Float64Var(&sc.OutMaxV, "out-max", 24.0, "controller output upper limit (V)")
+	sc.AntiWindup = pid.AntiWindupFreeze
+	fs.Var((*antiWindupValue)(&sc.AntiWindup), "anti-windup", "integrator anti-windup strategy: freeze, back-calc, tracking or none")
+	fs.Float64Var(&sc.Kt, "kt", defaultKt, "back-calculation gain (1/s), used with --anti-windup back-calc and tracking")
+	fs.Float64Var(&sc.SatHysteresisV, "sat-hysteresis", 0, "band (V) inside the output limits before a saturated controller counts as unsaturated again (0 = off)")
+	fs.BoolVar(&sc.WarmStart, "warm-start", false, "start the motor and integrator at the setpoint's steady state (no initial transient)")
+	fs.BoolVar(&sc.SafeShutdown, "safe-shutdown", false, "bring the command to zero after the run, recorded as a final sample")
+	fs.BoolVar(&sc.Feedforward, "feedforward", false, "add the motor model's steady-state voltage for the setpoint to the controller output")
+	fs.Float64Var(&sc.FFKv, "ff-kv", 0, "velocity feedforward gain (V/RPM): add ff-kv*target to the controller output (0 = off)")
+	fs.StringVar(&sc.Reference.Type, "reference", "step", "setpoint trajectory: step, ramp (0 to --target), sine or chirp (around --target)")
+	fs.Float64Var(&sc.Reference.RampRateRPMPerS, "ramp-rate", 0, "ramp rate (RPM/s), required by --reference ramp")
+	fs.Float64Var(&sc.Reference.AmplitudeRPM, "amplitude", 0, "sine/chirp amplitude (RPM), required by --reference sine and chirp")
+	fs.Float64Var(&sc.Reference.FreqHz, "freq", 0, "sine frequency (Hz), required by --reference sine")
+	fs.Float64Var(&sc.Reference.FreqStartHz, "freq-start", 0, "chirp start frequency (Hz), required by --reference chirp")
+	fs.Float64Var(&sc.Reference.FreqEndHz, "freq-end", 0, "chirp frequency at the end of the run (Hz), required by --reference chirp")
+	fs.BoolVar(&sc.Disturbance.Enabled, "disturbance-enabled", false, "enable load disturbance injection")
+	fs.Float64Var(&sc.Disturbance.StartS, "disturbance-start", 5.0, "disturbance start time (s)")
+	fs.Float64Var(&sc.Disturbance.DurationS, "disturbance-duration", 2.0, "disturbance duration (s, 0 = infinite)")
+	fs.Float64Var(&sc.Disturbance.MagnitudeRPMPerS, "disturbance-magnitude", 50.0, "disturbance magnitude (RPM/s)")
+	fs.Float64Var(&sc.MeasurementNoiseRPM, "measurement-noise", 0, "Gaussian measurement noise standard deviation (RPM, 0 = off)")
+	fs.Float64Var(&sc.LoadNoiseRPMPerS, "load-noise", 0, "Gaussian load disturbance noise standard deviation (RPM/s, 0 = off)")
+	fs.Int64Var(&sc.Seed, "seed", defaultSeed, "seed the noise sources' seeds are derived from (recorded per source in metadata.json)")
+}
+
+// defaultKt is the --kt default, also assumed for runs recorded before it existed.
+const defaultKt = 1.0
+
+// antiWindupValue adapts pid.AntiWindup to pflag.Value.
+type antiWindupValue pid.AntiWindup
+
+func (v *antiWindupValue) String() string { return pid.AntiWindup(*v).String() }
+
+func (v *antiWindupValue) Set(s string) error {
+	a, err := pid.ParseAntiWindup(s)
+	if err != nil {
+		return err
+	}
+	*v = antiWindupValue(a)
+	return nil
+}
+
+func (v *antiWindupValue) Type() string { return "strategy" }
+
+// applyStepConfig loads the scenario from the YAML file at path into sc.
+// Flags in fs that were set explicitly on the command line take precedence
+// over the file.
+func applyStepConfig(fs *pflag.FlagSet, sc *stepScenario, path string) error {
+	loaded, err := loadStepConfig(path)
+	if err != nil {
+		return err
+	}
+
+	// sc is overwritten below; remember the explicit flag values first
+	explicit := map[string]string{}
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Changed {
+			explicit[f.Name] = f.Value.String()
+		}
+	})
+
+	*sc = loaded
+	for name, v := range explicit {
+		if err := fs.Set(name, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadStepConfig reads a scenario written by dumpStepConfig. The file holds
+// the same keys as the params in metadata.json.
+func loadStepConfig(path string) (stepScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return stepScenario{}, err
+	}
+	var params map[string]any
+	if err := yaml.Unmarshal(data, &params); err != nil {
+		return stepScenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	sc, err := stepScenarioFromParams(params)
+	if err != nil {
+		return stepScenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// dumpStepConfig writes the fully resolved scenario, defaults included, as YAML.
+func dumpStepConfig(path string, sc stepScenario) error {
+	data, err := yaml.Marshal(sc.params())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+)
+
+func TestDumpConfig_RoundTrip(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "scenario.yaml")
+	dir := runSimStepCLI(t, "--no-plots",
+		"--kp", "0.031", "--ki", "0.07", "--target", "750", "--deadzone", "0.3",
+		"--disturbance-enabled", "--disturbance-magnitude", "42.5",
+		"--dump-config", cfgPath)
+
+	dumped, err := loadStepConfig(cfgPath)
+	if err != nil {
+		t.Fatalf("loadStepConfig() error = %v", err)
+	}
+	md, err := artifacts.ReadMetadata(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran, err := stepScenarioFromParams(md.Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dumped != ran {
+		t.Errorf("dumped scenario = %+v, want the run's %+v", dumped, ran)
+	}
+
+	// Feeding the dump back reproduces the same parameters
+	again := runSimStepCLI(t, "--no-plots", "--config", cfgPath)
+	md2, err := artifacts.ReadMetadata(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc2, _ := stepScenarioFromParams(md2.Params); sc2 != ran {
+		t.Errorf("scenario from --config = %+v, want %+v", sc2, ran)
+	}
+}
+
+func TestConfig_ExplicitFlagsOverrideFile(t *testing.T) {
+	sc := stepScenario{Kp: 0.05, Ki: 0.01, TargetRPM: 600, DurationS: 10, DTS: 0.01, OutMinV: -12, OutMaxV: 12}
+	cfgPath := filepath.Join(t.TempDir(), "scenario.yaml")
+	if err := dumpStepConfig(cfgPath, sc); err != nil {
+		t.Fatal(err)
+	}
+
+	// runSimStepCLI passes --duration and --dt explicitly; they match the file
+	dir := runSimStepCLI(t, "--no-plots", "--config", cfgPath, "--kp", "0.02")
+	md, err := artifacts.ReadMetadata(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := stepScenarioFromParams(md.Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := sc
+	want.Kp = 0.02
+	if got != want {
+		t.Errorf("scenario = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadStepConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"malformed.yaml":  "kp: [",
+		"incomplete.yaml": "kp: 0.02\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadStepConfig(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,778 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment/modifier"
+	"github.com/fabriziobonavita/motor-control-lab/internal/plotting"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+// stepScenario fully describes a step-response simulation. It round-trips
+// through the params written to metadata.json, so a run can be reproduced
+// from its metadata alone.
+type stepScenario struct {
+	Kp, Ki, Kd float64
+
+	// Observe selects the controlled quantity (see validateObserve). With
+	// "position", TargetRPM is the target position in revolutions.
+	Observe string
+
+	TargetRPM float64
+	DurationS float64
+	DTS       float64
+	DeadzoneV float64
+
+	// Controller output limits (V)
+	OutMinV, OutMaxV float64
+
+	// Anti-windup strategy and back-calculation gain (1/s)
+	AntiWindup pid.AntiWindup
+	Kt         float64
+
+	// SatHysteresisV is the controller's saturation hysteresis band (V)
+	SatHysteresisV float64
+
+	// WarmStart starts plant and integrator at the setpoint's steady state
+	WarmStart bool
+
+	// SafeShutdown zeroes the command after the run, as a final sample
+	SafeShutdown bool
+
+	// Feedforward adds the nominal motor model's steady-state voltage for the
+	// setpoint to the controller output (velocity mode only)
+	Feedforward bool
+
+	// FFKv is a velocity feedforward gain (V/RPM): FFKv*target is added to
+	// the controller output (velocity mode only, 0 = off)
+	FFKv float64
+
+	Reference referenceConfig
+
+	Disturbance wrap.StepDisturbanceConfig
+
+	// Gaussian measurement noise (RPM) and load noise (RPM/s) standard
+	// deviations; zero disables them. Each noise source draws from its own
+	// seed, derived from Seed (see componentSeed).
+	MeasurementNoiseRPM float64
+	LoadNoiseRPMPerS    float64
+	Seed                int64
+}
+
+// referenceConfig selects the setpoint trajectory. TargetRPM is the constant
+// setpoint of a step, the final value of a ramp and the offset of a sine or chirp.
+// A chirp sweeps from FreqStartHz to FreqEndHz over the run duration.
+type referenceConfig struct {
+	Type string // step (or empty), ramp, sine or chirp
+
+	RampRateRPMPerS float64
+	AmplitudeRPM    float64
+	FreqHz          float64
+	FreqStartHz     float64
+	FreqEndHz       float64
+}
+
+// validate checks that the parameters required by the reference type are set.
+func (rc referenceConfig) validate() error {
+	positive := func(v float64, flag string) error {
+		if !(v > 0) {
+			return fmt.Errorf("--reference %s requires --%s > 0", rc.Type, flag)
+		}
+		return nil
+	}
+	switch rc.Type {
+	case "", "step":
+		return nil
+	case "ramp":
+		return positive(rc.RampRateRPMPerS, "ramp-rate")
+	case "sine":
+		return errors.Join(positive(rc.AmplitudeRPM, "amplitude"), positive(rc.FreqHz, "freq"))
+	case "chirp":
+		return errors.Join(positive(rc.AmplitudeRPM, "amplitude"),
+			positive(rc.FreqStartHz, "freq-start"), positive(rc.FreqEndHz, "freq-end"))
+	}
+	return fmt.Errorf("unknown reference %q (want step, ramp, sine or chirp)", rc.Type)
+}
+
+// build returns the reference for a run, or nil for a step (the constant target).
+func (rc referenceConfig) build(targetRPM, durationS float64) experiment.Reference {
+	switch rc.Type {
+	case "ramp":
+		return experiment.RampReference{RateRPMPerS: rc.RampRateRPMPerS, FinalRPM: targetRPM}
+	case "sine":
+		return experiment.SineReference{OffsetRPM: targetRPM, AmplitudeRPM: rc.AmplitudeRPM, FreqHz: rc.FreqHz}
+	case "chirp":
+		return experiment.ChirpReference{
+			OffsetRPM: targetRPM, AmplitudeRPM: rc.AmplitudeRPM,
+			StartHz: rc.FreqStartHz, EndHz: rc.FreqEndHz, DurationS: durationS,
+		}
+	}
+	return nil
+}
+
+// validate checks the parts of the scenario that build() cannot reject itself:
+// the disturbance, the hysteresis, the noise, the feedforward, the reference
+// and the observation.
+func (sc stepScenario) validate() error {
+	if err := sc.Disturbance.Validate(); err != nil {
+		return err
+	}
+	if _, err := pid.NewChecked(sc.Kp, sc.Ki, sc.Kd, sc.OutMinV, sc.OutMaxV); err != nil {
+		return err
+	}
+	if !(sc.SatHysteresisV >= 0) {
+		return fmt.Errorf("saturation hysteresis %gV must be >= 0", sc.SatHysteresisV)
+	}
+	if !(sc.MeasurementNoiseRPM >= 0) || !(sc.LoadNoiseRPMPerS >= 0) {
+		return fmt.Errorf("noise standard deviations must be >= 0 (measurement %g RPM, load %g RPM/s)",
+			sc.MeasurementNoiseRPM, sc.LoadNoiseRPMPerS)
+	}
+	if math.IsNaN(sc.FFKv) || math.IsInf(sc.FFKv, 0) {
+		return fmt.Errorf("--ff-kv %g must be finite", sc.FFKv)
+	}
+	if sc.FFKv != 0 && sc.Feedforward {
+		return fmt.Errorf("--ff-kv and --feedforward both set the feedforward; use one")
+	}
+	if err := sc.Reference.validate(); err != nil {
+		return err
+	}
+	return sc.validateObserve()
+}
+
+// validateObserve checks the observed quantity: velocity (or empty) or position.
+// Position mode supports only a step reference; the trajectories are in RPM.
+func (sc stepScenario) validateObserve() error {
+	switch sc.Observe {
+	case "", "velocity":
+		return nil
+	case "position":
+		if t := sc.Reference.Type; t != "" && t != "step" {
+			return fmt.Errorf("--observe position supports only --reference step, not %q", t)
+		}
+		if sc.Feedforward {
+			return fmt.Errorf("--feedforward is not supported with --observe position (the model maps voltage to speed)")
+		}
+		if sc.FFKv != 0 {
+			return fmt.Errorf("--ff-kv is in V/RPM and not supported with --observe position")
+		}
+		if sc.MeasurementNoiseRPM != 0 {
+			return fmt.Errorf("--measurement-noise is in RPM and not supported with --observe position")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown observation %q (want velocity or position)", sc.Observe)
+}
+
+func (sc stepScenario) positionMode() bool { return sc.Observe == "position" }
+
+// positionUnits overrides DefaultUnits for the columns and metrics that hold the
+// observation when it is a position.
+var positionUnits = map[string]string{
+	"target":             "rev",
+	"actual":             "rev",
+	"error":              "rev",
+	"integral":           "rev*s",
+	"max_actual":         "rev",
+	"min_actual":         "rev",
+	"steady_state_error": "rev",
+	"iae":                "rev*s",
+}
+
+// outputOptions control where and how run artifacts are written.
+type outputOptions struct {
+	BaseDir string
+	Tags    []string
+	NoPlots bool
+	// PlotTheme is the plot color theme ("light" or "dark").
+	PlotTheme string
+	// PlotGrid adds grid lines to every plot.
+	PlotGrid bool
+	// PlotRaw overlays the unclamped controller output on control.png.
+	PlotRaw bool
+	// PlotPhase adds phase_portrait.png (error rate against error).
+	PlotPhase bool
+	// LogFormat is the out.log line format ("text" or "json").
+	LogFormat string
+	// StableEnv separates volatile environment fields in metadata.json.
+	StableEnv bool
+	// Profile times each simulation step and logs the distribution to out.log.
+	Profile bool
+	// MaxSteps caps the number of simulation steps (see
+	// experiment.StepConfig.MaxSteps).
+	MaxSteps int
+	// CSVComment prepends the controller configuration to samples.csv as a '#' comment.
+	CSVComment bool
+	// CSVUnits writes a units row below the samples.csv header.
+	CSVUnits bool
+	// Deterministic fixes the run timestamp (see runTimestamp) and leaves
+	// wall-clock timings out of out.log, so repeated runs write identical files.
+	Deterministic bool
+	// Debug writes the per-step controller state to debug.csv and lowers the
+	// out.log level to debug.
+	Debug bool
+	// Stream writes samples.csv during the run and prints a status line every
+	// StreamIntervalS of simulated time.
+	Stream          bool
+	StreamIntervalS float64
+}
+
+// params returns the scenario as metadata.json params.
+func (sc stepScenario) params() map[string]any {
+	return map[string]any{
+		"kp":                              sc.Kp,
+		"ki":                              sc.Ki,
+		"kd":                              sc.Kd,
+		"observe":                         sc.Observe,
+		"target_rpm":                      sc.TargetRPM,
+		"duration_s":                      sc.DurationS,
+		"dt_s":                            sc.DTS,
+		"deadzone_v":                      sc.DeadzoneV,
+		"out_min_v":                       sc.OutMinV,
+		"out_max_v":                       sc.OutMaxV,
+		"anti_windup":                     sc.AntiWindup.String(),
+		"kt":                              sc.Kt,
+		"sat_hysteresis_v":                sc.SatHysteresisV,
+		"warm_start":                      sc.WarmStart,
+		"safe_shutdown":                   sc.SafeShutdown,
+		"feedforward":                     sc.Feedforward,
+		"ff_kv":                           sc.FFKv,
+		"reference":                       sc.Reference.Type,
+		"reference_ramp_rate_rpm_per_s":   sc.Reference.RampRateRPMPerS,
+		"reference_amplitude_rpm":         sc.Reference.AmplitudeRPM,
+		"reference_freq_hz":               sc.Reference.FreqHz,
+		"reference_freq_start_hz":         sc.Reference.FreqStartHz,
+		"reference_freq_end_hz":           sc.Reference.FreqEndHz,
+		"disturbance_enabled":             sc.Disturbance.Enabled,
+		"disturbance_start_s":             sc.Disturbance.StartS,
+		"disturbance_duration_s":          sc.Disturbance.DurationS,
+		"disturbance_magnitude_rpm_per_s": sc.Disturbance.MagnitudeRPMPerS,
+		"measurement_noise_rpm":           sc.MeasurementNoiseRPM,
+		"load_noise_rpm_per_s":            sc.LoadNoiseRPMPerS,
+		"seed":                            sc.Seed,
+	}
+}
+
+// stepScenarioFromParams reconstructs a scenario from metadata.json params.
+// Every parameter must be present, so an incomplete scenario is reported
+// instead of being silently filled with defaults.
+func stepScenarioFromParams(params map[string]any) (stepScenario, error) {
+	p := paramReader{params: params}
+	defaults := pid.New(0, 0, 0)
+	sc := stepScenario{
+		Kp:        p.float("kp"),
+		Ki:        p.float("ki"),
+		Kd:        p.float("kd"),
+		Observe:   p.stringOr("observe", "velocity"),
+		TargetRPM: p.float("target_rpm"),
+		DurationS: p.float("duration_s"),
+		DTS:       p.float("dt_s"),
+		DeadzoneV: p.float("deadzone_v"),
+		// Runs recorded before the limits were configurable used the PID defaults
+		OutMinV: p.floatOr("out_min_v", defaults.OutMin),
+		OutMaxV: p.floatOr("out_max_v", defaults.OutMax),
+		// ... and the freeze anti-windup
+		AntiWindup: p.antiWindupOr("anti_windup", defaults.AntiWindup),
+		Kt:         p.floatOr("kt", defaultKt),
+		// ... without saturation hysteresis
+		SatHysteresisV: p.floatOr("sat_hysteresis_v", 0),
+
+		WarmStart:    p.boolOr("warm_start", false),
+		SafeShutdown: p.boolOr("safe_shutdown", false),
+		Feedforward:  p.boolOr("feedforward", false),
+		FFKv:         p.floatOr("ff_kv", 0),
+		Reference: referenceConfig{
+			Type:            p.stringOr("reference", "step"),
+			RampRateRPMPerS: p.floatOr("reference_ramp_rate_rpm_per_s", 0),
+			AmplitudeRPM:    p.floatOr("reference_amplitude_rpm", 0),
+			FreqHz:          p.floatOr("reference_freq_hz", 0),
+			FreqStartHz:     p.floatOr("reference_freq_start_hz", 0),
+			FreqEndHz:       p.floatOr("reference_freq_end_hz", 0),
+		},
+		Disturbance: wrap.StepDisturbanceConfig{
+			Enabled:          p.bool("disturbance_enabled"),
+			StartS:           p.float("disturbance_start_s"),
+			DurationS:        p.float("disturbance_duration_s"),
+			MagnitudeRPMPerS: p.float("disturbance_magnitude_rpm_per_s"),
+		},
+		// Runs recorded before noise was configurable were deterministic
+		MeasurementNoiseRPM: p.floatOr("measurement_noise_rpm", 0),
+		LoadNoiseRPMPerS:    p.floatOr("load_noise_rpm_per_s", 0),
+		Seed:                p.int64Or("seed", defaultSeed),
+	}
+	return sc, p.err
+}
+
+// paramReader extracts typed values from decoded JSON params, keeping the first error.
+type paramReader struct {
+	params map[string]any
+	err    error
+}
+
+func (p *paramReader) value(key string) (any, bool) {
+	v, ok := p.params[key]
+	if !ok && p.err == nil {
+		p.err = fmt.Errorf("params: missing %q", key)
+	}
+	return v, ok
+}
+
+func (p *paramReader) float(key string) float64 {
+	v, ok := p.value(key)
+	if !ok {
+		return 0
+	}
+	switch x := v.(type) {
+	case float64:
+		return x
+	case int:
+		return float64(x)
+	}
+	if p.err == nil {
+		p.err = fmt.Errorf("params: %q is %T, want a number", key, v)
+	}
+	return 0
+}
+
+// floatOr is like float but returns def when key is absent.
+func (p *paramReader) floatOr(key string, def float64) float64 {
+	if _, ok := p.params[key]; !ok {
+		return def
+	}
+	return p.float(key)
+}
+
+// int64Or reads an integer, returning def when key is absent. Numbers decoded
+// from JSON are float64, so they must be integral.
+func (p *paramReader) int64Or(key string, def int64) int64 {
+	v, ok := p.params[key]
+	if !ok {
+		return def
+	}
+	switch x := v.(type) {
+	case int64:
+		return x
+	case int:
+		return int64(x)
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return int64(x)
+		}
+	}
+	if p.err == nil {
+		p.err = fmt.Errorf("params: %q is %v, want an integer", key, v)
+	}
+	return 0
+}
+
+// boolOr is like bool but returns def when key is absent.
+func (p *paramReader) boolOr(key string, def bool) bool {
+	if _, ok := p.params[key]; !ok {
+		return def
+	}
+	return p.bool(key)
+}
+
+// stringOr returns the string at key, or def when key is absent.
+func (p *paramReader) stringOr(key, def string) string {
+	v, ok := p.params[key]
+	if !ok {
+		return def
+	}
+	str, isString := v.(string)
+	if !isString && p.err == nil {
+		p.err = fmt.Errorf("params: %q is %T, want a string", key, v)
+	}
+	return str
+}
+
+// antiWindupOr reads an anti-windup strategy name, returning def when key is absent.
+func (p *paramReader) antiWindupOr(key string, def pid.AntiWindup) pid.AntiWindup {
+	name := p.stringOr(key, def.String())
+	a, err := pid.ParseAntiWindup(name)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("params: %q: %w", key, err)
+	}
+	return a
+}
+
+func (p *paramReader) bool(key string) bool {
+	v, ok := p.value(key)
+	if !ok {
+		return false
+	}
+	b, isBool := v.(bool)
+	if !isBool && p.err == nil {
+		p.err = fmt.Errorf("params: %q is %T, want a boolean", key, v)
+	}
+	return b
+}
+
+// build constructs the controller, system and experiment config for the scenario.
+func (sc stepScenario) build() (*pid.Controller, system.System, experiment.StepConfig) {
+	ctrl := pid.New(sc.Kp, sc.Ki, sc.Kd)
+	ctrl.OutMin = sc.OutMinV
+	ctrl.OutMax = sc.OutMaxV
+	ctrl.AntiWindup = sc.AntiWindup
+	ctrl.Kt = sc.Kt
+	ctrl.SatHysteresis = sc.SatHysteresisV
+	plant := sim.NewDCMotor()
+	if sc.Feedforward {
+		// The model is the nominal motor, not the (possibly disturbed) plant itself
+		ctrl.Feedforward = sim.NewDCMotor().SteadyStateVoltage
+	}
+	if kv := sc.FFKv; kv != 0 {
+		ctrl.Feedforward = func(target float64) float64 { return kv * target }
+	}
+
+	// Wrap plant with DisturbedSystem if disturbance is enabled; load noise
+	// adds to the step, so both go through one CompositeDisturbance
+	var sys system.System = plant
+	switch {
+	case sc.LoadNoiseRPMPerS != 0:
+		var components []wrap.DisturbanceComponent
+		if sc.Disturbance.Enabled {
+			components = append(components, wrap.DisturbanceComponent{Name: "step", Profile: wrap.StepProfile(sc.Disturbance)})
+		}
+		components = append(components,
+			wrap.NoiseComponent("load_noise", sc.LoadNoiseRPMPerS, componentSeed(sc.Seed, "load_noise")))
+		sys = wrap.NewCompositeDisturbance(plant, components...)
+	case sc.Disturbance.Enabled:
+		sys = wrap.NewDisturbedSystem(plant, sc.Disturbance)
+	}
+	if sc.MeasurementNoiseRPM != 0 {
+		sys = wrap.NewNoisySystem(sys, sc.MeasurementNoiseRPM, componentSeed(sc.Seed, "measurement_noise"))
+	}
+	if sc.positionMode() {
+		sys = wrap.NewPositionSystem(sys)
+	}
+
+	var mod modifier.Modifier
+	if sc.DeadzoneV > 0 {
+		mod = modifier.Chain(&modifier.DeadzoneModifier{Threshold: sc.DeadzoneV})
+	}
+
+	cfg := experiment.StepConfig{
+		TargetRPM: sc.TargetRPM,
+		DT:        sc.DTS,
+		Duration:  sc.DurationS,
+		Modifier:  mod,
+		Reference: sc.Reference.build(sc.TargetRPM, sc.DurationS),
+		WarmStart: sc.WarmStart,
+
+		SafeShutdown: sc.SafeShutdown,
+	}
+	if mod != nil {
+		// Modifiers run after the controller clamp; keep u within the actuator range
+		cfg.MaxAbsU = plant.MaxVoltage
+	}
+	return ctrl, sys, cfg
+}
+
+// defaultSeed is the --seed default, also assumed for runs recorded before it existed.
+const defaultSeed = 1
+
+// componentSeed derives the seed of one stochastic component from the
+// scenario seed, so that components draw independent streams and adding one
+// does not change the others. The derived seeds are recorded in metadata.json.
+func componentSeed(seed int64, component string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(component))
+	return seed ^ int64(h.Sum64())
+}
+
+// stepResult is the outcome of executing a scenario.
+type stepResult struct {
+	Dir      string
+	Metadata artifacts.Metadata
+	Metrics  analysis.Metrics
+	Samples  []experiment.Sample
+	Wall     time.Duration
+}
+
+// executeStep runs the scenario and writes all run artifacts. A short
+// human-oriented summary is printed to console.
+func executeStep(sc stepScenario, out outputOptions, console io.Writer) (stepResult, error) {
+	logFormat, err := artifacts.ParseLogFormat(out.LogFormat)
+	if err != nil {
+		return stepResult{}, err
+	}
+	theme, err := plotting.ParseTheme(out.PlotTheme)
+	if err != nil {
+		return stepResult{}, err
+	}
+	theme.Grid = out.PlotGrid
+	if err := sc.validate(); err != nil {
+		return stepResult{}, err
+	}
+	ctrl, sys, cfg := sc.build()
+	cfg.MaxSteps = out.MaxSteps
+	// Checked before the run directory exists, which --stream creates first
+	if err := cfg.Validate(); err != nil {
+		if errors.Is(err, errs.ErrTooManySteps) {
+			return stepResult{}, fmt.Errorf("%w (raise --max-steps, or set it negative to disable the cap)", err)
+		}
+		return stepResult{}, err
+	}
+	unit, units := "RPM", map[string]string(nil)
+	if sc.positionMode() {
+		unit, units = "rev", positionUnits
+	}
+
+	if out.Stream {
+		if out.Profile {
+			return stepResult{}, fmt.Errorf("--stream cannot be combined with --profile")
+		}
+		if !(out.StreamIntervalS > 0) {
+			return stepResult{}, fmt.Errorf("--stream-interval %gs must be > 0", out.StreamIntervalS)
+		}
+	}
+
+	createdAt, err := runTimestamp(out.Deterministic)
+	if err != nil {
+		return stepResult{}, err
+	}
+	// A fixed timestamp means reproducible artifacts; wall-clock timings are not
+	reproducible := !createdAt.IsZero()
+
+	createRun := func() (artifacts.RunDir, artifacts.Metadata, error) {
+		return artifacts.CreateWith(out.BaseDir, "sim", "dc-motor", "step", sc.params(), artifacts.CreateOptions{
+			Tags:                     out.Tags,
+			SplitVolatileEnvironment: out.StableEnv,
+			Units:                    units,
+			Seeds:                    system.ReportedSeeds(sys),
+			CreatedAt:                createdAt,
+		})
+	}
+	closeRun := func(run *artifacts.RunDir) {
+		if err := run.Close(); err != nil {
+			// Log error but don't fail - cleanup operation
+			fmt.Fprintf(os.Stderr, "warning: failed to close run directory: %v\n", err)
+		}
+	}
+
+	var csvComment string
+	if out.CSVComment {
+		csvComment = fmt.Sprintf("kp=%g ki=%g kd=%g out_min_v=%g out_max_v=%g dt_s=%g",
+			sc.Kp, sc.Ki, sc.Kd, sc.OutMinV, sc.OutMaxV, sc.DTS)
+	}
+	csvOpts := artifacts.CSVOptions{
+		SignalKeys: append(system.DeclaredSignalKeys(sys), experiment.RunnerSignalKeys(cfg, ctrl)...),
+		Comment:    csvComment,
+		UnitsRow:   out.CSVUnits,
+		Units:      units,
+	}
+
+	var debug []experiment.DebugRecord
+	if out.Debug {
+		cfg.Debug = func(rec experiment.DebugRecord) { debug = append(debug, rec) }
+	}
+
+	var (
+		run     artifacts.RunDir
+		md      artifacts.Metadata
+		samples []experiment.Sample
+		profile []time.Duration
+		wall    time.Duration
+	)
+	switch {
+	case out.Stream:
+		// samples.csv is written during the run, so the run directory comes first
+		if run, md, err = createRun(); err != nil {
+			return stepResult{}, err
+		}
+		defer closeRun(&run)
+		samples, wall, err = streamStep(&run, sys, ctrl, cfg, csvOpts, out.StreamIntervalS, unit, console)
+	case out.Profile:
+		samples, profile, wall, err = experiment.RunStepProfiled(sys, ctrl, cfg)
+	default:
+		samples, wall, err = experiment.RunStep(sys, ctrl, cfg)
+	}
+	if err != nil {
+		return stepResult{}, err
+	}
+	if len(samples) == 0 {
+		return stepResult{}, fmt.Errorf("step: duration %gs is shorter than dt %gs: %w", sc.DurationS, sc.DTS, errs.ErrNoSamples)
+	}
+
+	if !out.Stream {
+		if run, md, err = createRun(); err != nil {
+			return stepResult{}, err
+		}
+		defer closeRun(&run)
+
+		// samples.csv
+		if err := run.WriteSamplesCSVWith(samples, csvOpts); err != nil {
+			return stepResult{}, err
+		}
+	}
+
+	// debug.csv
+	if out.Debug {
+		if err := run.WriteDebugCSV(debug); err != nil {
+			return stepResult{}, err
+		}
+	}
+
+	// metrics.json
+	metrics := analysis.ComputeWithLimits(samples, 0.02, ctrl.OutMin, ctrl.OutMax)
+	if err := artifacts.WriteJSON(filepath.Join(run.Dir, "metrics.json"), metrics); err != nil {
+		return stepResult{}, err
+	}
+
+	// run_stats.json
+	stats := artifacts.RunStats{StepCount: len(samples)}
+	if !reproducible {
+		stats.WallTimeSeconds = wall.Seconds()
+		stats.RealtimeFactor = experiment.RealtimeFactor(sc.DurationS, wall)
+	}
+	if err := run.WriteRunStats(stats); err != nil {
+		return stepResult{}, err
+	}
+
+	// plots
+	if !out.NoPlots {
+		writeResponse := plotting.WriteVelocityPlot
+		if sc.positionMode() {
+			writeResponse = plotting.WritePositionPlot
+		}
+		if err := writeResponse(run.Dir, samples, theme); err != nil {
+			return stepResult{}, err
+		}
+		if err := plotting.WriteControlPlotWith(run.Dir, samples, plotting.ControlPlotOptions{
+			OutMin: metrics.OutMin, OutMax: metrics.OutMax, ShowRaw: out.PlotRaw, Theme: theme,
+		}); err != nil {
+			return stepResult{}, err
+		}
+		if cfg.Reference != nil {
+			if err := plotting.WriteTrackingPlot(run.Dir, samples, theme); err != nil {
+				return stepResult{}, err
+			}
+		}
+		if out.PlotPhase {
+			if err := plotting.WritePhasePortrait(run.Dir, samples, theme); err != nil {
+				return stepResult{}, err
+			}
+		}
+	}
+
+	// summary.md (references the plots written above)
+	if err := artifacts.WriteMarkdownSummary(run.Dir, md, metrics); err != nil {
+		return stepResult{}, err
+	}
+
+	// out.log (structured summary)
+	last := samples[len(samples)-1]
+	logLevel := slog.LevelInfo
+	if out.Debug {
+		logLevel = slog.LevelDebug
+	}
+	log := run.Logger(artifacts.LogOptions{Format: logFormat, Level: logLevel})
+	log.Debug("controller",
+		"kp", ctrl.Kp, "ki", ctrl.Ki, "kd", ctrl.Kd,
+		"out_min", ctrl.OutMin, "out_max", ctrl.OutMax,
+		"anti_windup", ctrl.AntiWindup.String(), "kt", ctrl.Kt,
+		"sat_hysteresis", ctrl.SatHysteresis,
+		"final_integral", ctrl.Integral(),
+	)
+	if out.Debug {
+		log.Debug("debug trace", "file", "debug.csv", "rows", len(debug))
+	}
+	attrs := []any{"run_id", md.RunID, "samples", len(samples)}
+	if !reproducible {
+		attrs = append(attrs, "wall_time", wall, "realtime_factor", experiment.RealtimeFactor(sc.DurationS, wall))
+	}
+	log.Info("run complete", append(attrs,
+		"final_actual", last.Actual,
+		"final_error", last.Error,
+		"final_u", last.U,
+	)...)
+	log.LogAttrs(context.Background(), slog.LevelInfo, "metrics", artifacts.MetricAttrs(metrics)...)
+	// A moving reference keeps the error oscillating by design; check steps only
+	var (
+		lcAmplitude, lcPeriod float64
+		limitCycle            bool
+	)
+	if cfg.Reference == nil {
+		lcAmplitude, lcPeriod, limitCycle = analysis.DetectLimitCycle(samples)
+	}
+	if limitCycle {
+		log.Warn("limit cycle", "amplitude", lcAmplitude, "period_s", lcPeriod)
+	}
+	if out.Profile {
+		log.Info("step profile", profileAttrs(profile)...)
+	}
+
+	// console output
+	_, _ = fmt.Fprintln(console, "Run:", md.RunID)
+	_, _ = fmt.Fprintln(console, "Artifacts:", run.Dir)
+	_, _ = fmt.Fprintf(console, "Final: actual=%.2f%s err=%.2f u=%.2fV\n", last.Actual, unit, last.Error, last.U)
+	_, _ = fmt.Fprintf(console, "Metrics: overshoot=%.2f%% settling=%v iae=%.3f\n", metrics.OvershootPercent, metrics.SettlingTimeSeconds, metrics.IAE)
+	if limitCycle {
+		_, _ = fmt.Fprintf(console, "Limit cycle: amplitude=%.2f%s period=%.3fs (the response oscillates without settling)\n", lcAmplitude, unit, lcPeriod)
+	}
+
+	return stepResult{Dir: run.Dir, Metadata: md, Metrics: metrics, Samples: samples, Wall: wall}, nil
+}
+
+// sourceDateEpochEnv names the environment variable that fixes the run
+// timestamp, in seconds since the Unix epoch (like SOURCE_DATE_EPOCH for
+// reproducible builds).
+const sourceDateEpochEnv = "MCL_SOURCE_DATE_EPOCH"
+
+// runTimestamp returns the fixed timestamp for the run's ID and metadata: the
+// time in MCL_SOURCE_DATE_EPOCH if set, else the Unix epoch if deterministic.
+// The zero time means the current time.
+func runTimestamp(deterministic bool) (time.Time, error) {
+	if s := os.Getenv(sourceDateEpochEnv); s != "" {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s=%q: want seconds since the Unix epoch", sourceDateEpochEnv, s)
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	if deterministic {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	return time.Time{}, nil
+}
+
+// profileAttrs summarizes per-step compute times as log key/value pairs.
+func profileAttrs(profile []time.Duration) []any {
+	if len(profile) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration(nil), profile...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	pct := func(p float64) time.Duration { return sorted[int(p*float64(len(sorted)-1))] }
+	return []any{
+		"steps", len(sorted),
+		"total", total,
+		"mean", total / time.Duration(len(sorted)),
+		"p50", pct(0.50),
+		"p99", pct(0.99),
+		"max", sorted[len(sorted)-1],
+	}
+}
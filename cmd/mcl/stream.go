@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/artifacts"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// streamStep runs the scenario with the streaming runner: samples.csv in run
+// grows as samples are produced, and a status line is printed to console
+// every intervalS of simulated time, so a long run can be followed live
+// (e.g., with tail -f). The samples are also returned, for the metrics and
+// plots written after the run.
+func streamStep(run *artifacts.RunDir, sys system.System, ctrl *pid.Controller, cfg experiment.StepConfig,
+	csvOpts artifacts.CSVOptions, intervalS float64, unit string, console io.Writer,
+) ([]experiment.Sample, time.Duration, error) {
+	csvSink, err := run.SamplesCSVSink(csvOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	collect := &collectSink{}
+	// The status sink runs last, so the flush includes the sample it reports
+	status := &statusSink{w: console, intervalS: intervalS, next: intervalS, unit: unit, flush: csvSink.Flush}
+	sink := experiment.MultiSink(csvSink, collect, status)
+
+	n, wall, err := experiment.RunStepStreaming(sys, ctrl, cfg, sink)
+	if cerr := sink.Close(); err == nil {
+		err = cerr
+	}
+	_, _ = fmt.Fprintf(console, "Streamed %d samples\n", n)
+	return collect.samples, wall, err
+}
+
+// collectSink keeps every sample.
+type collectSink struct {
+	samples []experiment.Sample
+}
+
+func (c *collectSink) Write(s experiment.Sample) error {
+	c.samples = append(c.samples, s)
+	return nil
+}
+
+func (c *collectSink) Close() error { return nil }
+
+// statusSink prints a status line for the first sample at or after each
+// multiple of intervalS (simulated time), after flushing the CSV.
+type statusSink struct {
+	w         io.Writer
+	intervalS float64
+	next      float64
+	unit      string
+	flush     func() error
+}
+
+func (s *statusSink) Write(smp experiment.Sample) error {
+	// Half a step of slack: T is a product of floats
+	if smp.T < s.next-smp.DT/2 {
+		return nil
+	}
+	for s.next <= smp.T+smp.DT/2 {
+		s.next += s.intervalS
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(s.w, "t=%.2fs actual=%.2f%s err=%.2f\n", smp.T, smp.Actual, s.unit, smp.Error)
+	return err
+}
+
+func (s *statusSink) Close() error { return nil }
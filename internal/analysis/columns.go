@@ -0,0 +1,63 @@
+package analysis
+
+import "github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+
+// Columns is a columnar (struct-of-arrays) view of a run, suitable for tight,
+// cache-friendly loops over very large runs. All slices must have the same length.
+//
+// Target is the final setpoint (the last sample's target), which is what the
+// step-response metrics are evaluated against. OutMin, OutMax and SettleHoldS
+// are as in Options.
+type Columns struct {
+	Target float64
+
+	OutMin float64
+	OutMax float64
+
+	SettleHoldS float64
+
+	T         []float64
+	DT        []float64
+	Actual    []float64
+	Error     []float64
+	U         []float64
+	Saturated []bool
+}
+
+// ColumnsFromSamples converts samples to columns.
+func ColumnsFromSamples(samples []experiment.Sample) Columns {
+	n := len(samples)
+	c := Columns{
+		T:         make([]float64, n),
+		DT:        make([]float64, n),
+		Actual:    make([]float64, n),
+		Error:     make([]float64, n),
+		U:         make([]float64, n),
+		Saturated: make([]bool, n),
+	}
+	if n > 0 {
+		c.Target = samples[n-1].Target
+	}
+	for i, s := range samples {
+		c.T[i] = s.T
+		c.DT[i] = s.DT
+		c.Actual[i] = s.Actual
+		c.Error[i] = s.Error
+		c.U[i] = s.U
+		c.Saturated[i] = s.Saturated
+	}
+	return c
+}
+
+// Len returns the number of samples.
+func (c Columns) Len() int { return len(c.T) }
+
+// ComputeColumns calculates the same metrics as Compute over columnar data.
+// settleBandFrac is typically 0.02 for a 2% band.
+func ComputeColumns(c Columns, settleBandFrac float64) Metrics {
+	acc := newAccumulator(c.Target, settleBandFrac, Options{OutMin: c.OutMin, OutMax: c.OutMax, SettleHoldS: c.SettleHoldS})
+	for i := range c.T {
+		acc.add(c.T[i], c.DT[i], c.Actual[i], c.Error[i], c.U[i], c.Saturated[i])
+	}
+	return acc.metrics()
+}
@@ -0,0 +1,211 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+// simulatedRun returns a realistic run with overshoot, saturation and a disturbance.
+func simulatedRun(duration float64) []experiment.Sample {
+	plant := wrap.NewDisturbedSystem(sim.NewDCMotor(), wrap.StepDisturbanceConfig{
+		Enabled: true, StartS: duration / 2, DurationS: duration / 5, MagnitudeRPMPerS: 200.0,
+	})
+	samples, _, _ := experiment.RunStep(plant, pid.New(0.5, 0.8, 0.0), experiment.StepConfig{
+		TargetRPM: 1000.0, DT: 0.001, Duration: duration,
+	})
+	return samples
+}
+
+// sameFloat treats two NaNs as equal.
+func sameFloat(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+func TestComputeColumns_MatchesCompute(t *testing.T) {
+	fixtures := map[string][]experiment.Sample{
+		"simulated":     simulatedRun(5.0),
+		"overshoot":     makeSamples(100.0, []float64{0, 50, 100, 110, 105, 100}, 0.1),
+		"never settles": makeSamples(100.0, []float64{50, 50, 50, 50}, 0.1),
+		"single sample": makeSamples(100.0, []float64{100}, 0.1),
+		"no time steps": makeSamples(100.0, []float64{0, 60, 120, 100}, 0),
+		"zero step":     makeSamples(100.0, []float64{100, 101, 99, 100}, 0.1),
+	}
+
+	for name, samples := range fixtures {
+		// A hold shorter than the simulated run's disturbance-free stretch, so
+		// both settling criteria are exercised
+		for _, hold := range []float64{0, 0.2} {
+			t.Run(fmt.Sprintf("%s/hold=%v", name, hold), func(t *testing.T) {
+				opts := Options{OutMin: -12, OutMax: 12, SettleHoldS: hold}
+				want := ComputeWithOptions(samples, 0.02, opts)
+
+				// Build columns by hand rather than through the adapter
+				c := Columns{Target: samples[len(samples)-1].Target, OutMin: -12, OutMax: 12, SettleHoldS: hold}
+				for _, s := range samples {
+					c.T = append(c.T, s.T)
+					c.DT = append(c.DT, s.DT)
+					c.Actual = append(c.Actual, s.Actual)
+					c.Error = append(c.Error, s.Error)
+					c.U = append(c.U, s.U)
+					c.Saturated = append(c.Saturated, s.Saturated)
+				}
+				got := ComputeColumns(c, 0.02)
+
+				for k, w := range want.Values() {
+					if g := got.Values()[k]; !sameFloat(g, w) {
+						t.Errorf("ComputeColumns() %s = %v, ComputeWithOptions() = %v", k, g, w)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestComputeColumns_SettlingMatchesNaiveScan checks the linear-time settling
+// detection against the definition: first sample after which the error stays in band.
+func TestComputeColumns_SettlingMatchesNaiveScan(t *testing.T) {
+	samples := simulatedRun(5.0)
+	band := 0.02 * math.Abs(samples[len(samples)-1].Target)
+
+	want := math.NaN()
+	for i := range samples {
+		ok := true
+		for j := i; j < len(samples); j++ {
+			if math.Abs(samples[j].Error) > band {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			want = samples[i].T
+			break
+		}
+	}
+
+	got := ComputeColumns(ColumnsFromSamples(samples), 0.02).SettlingTimeSeconds
+	if !sameFloat(got, want) {
+		t.Errorf("SettlingTimeSeconds = %v, want %v", got, want)
+	}
+}
+
+func TestComputeColumns_Empty(t *testing.T) {
+	m := ComputeColumns(Columns{}, 0.02)
+	if !math.IsNaN(m.SettlingTimeSeconds) {
+		t.Errorf("SettlingTimeSeconds = %v, want NaN for empty input", m.SettlingTimeSeconds)
+	}
+}
+
+func BenchmarkCompute(b *testing.B) {
+	samples := simulatedRun(100.0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Compute(samples, 0.02)
+	}
+}
+
+func BenchmarkComputeColumns(b *testing.B) {
+	c := ColumnsFromSamples(simulatedRun(100.0))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ComputeColumns(c, 0.02)
+	}
+}
+
+// TestComputeColumns_IAECompensatedSum uses a million tiny, identical IAE terms:
+// naive left-to-right accumulation drifts visibly, compensated summation does not.
+func TestComputeColumns_IAECompensatedSum(t *testing.T) {
+	const (
+		n  = 1_000_000
+		dt = 0.001
+		e  = 0.1
+	)
+	c := Columns{
+		T:         make([]float64, n),
+		DT:        make([]float64, n),
+		Actual:    make([]float64, n),
+		Error:     make([]float64, n),
+		U:         make([]float64, n),
+		Saturated: make([]bool, n),
+	}
+	var naive float64
+	for i := 0; i < n; i++ {
+		c.T[i] = float64(i) * dt
+		c.DT[i] = dt
+		c.Error[i] = e
+		naive += e * dt
+	}
+	want := 100.0
+
+	if math.Abs(naive-want) < 1e-10 {
+		t.Fatalf("fixture does not exhibit drift: naive sum = %.17g", naive)
+	}
+	if got := ComputeColumns(c, 0.02).IAE; math.Abs(got-want) > 1e-12 {
+		t.Errorf("IAE = %.17g, want %.17g (naive drift %.3g)", got, want, naive-want)
+	}
+}
+
+func TestComputeColumns_SettleHold(t *testing.T) {
+	// Settles at t=0.3, leaves the band at t=1.0 and never returns
+	actuals := []float64{0, 50, 90, 99, 100, 100, 101, 100, 99, 100, 80, 70, 60}
+	cols := ColumnsFromSamples(makeSamples(100, actuals, 0.1))
+
+	tests := []struct {
+		name  string
+		holdS float64
+		want  float64
+	}{
+		{"forever", 0, math.NaN()},
+		{"hold shorter than the stretch", 0.5, 0.3},
+		{"hold equal to the stretch", 0.6, 0.3},
+		{"hold longer than the stretch", 0.7, math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cols
+			c.SettleHoldS = tt.holdS
+			if got := ComputeColumns(c, 0.02).SettlingTimeSeconds; !sameFloat(got, tt.want) && math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("settling time = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// An in-band stretch reaching the end of the run counts as settled
+	c := ColumnsFromSamples(makeSamples(100, []float64{0, 50, 99, 100}, 0.1))
+	c.SettleHoldS = 5
+	if got := ComputeColumns(c, 0.02).SettlingTimeSeconds; math.Abs(got-0.2) > 1e-9 {
+		t.Errorf("settling time in band until the end = %v, want 0.2", got)
+	}
+}
+
+func TestComputeColumns_SettleHoldLateDisturbance(t *testing.T) {
+	// Clean step, then a sustained load disturbance pushes the speed out of band
+	plant := wrap.NewDisturbedSystem(sim.NewDCMotor(), wrap.StepDisturbanceConfig{
+		Enabled: true, StartS: 8, MagnitudeRPMPerS: 5000,
+	})
+	samples, _, err := experiment.RunStep(plant, pid.New(0.02, 0.05, 0), experiment.StepConfig{
+		TargetRPM: 1000, DT: 0.001, Duration: 8.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ColumnsFromSamples(samples)
+	if m := ComputeColumns(c, 0.02); !math.IsNaN(m.SettlingTimeSeconds) {
+		t.Fatalf("settling time without hold = %v, want NaN after the late disturbance", m.SettlingTimeSeconds)
+	}
+
+	c.SettleHoldS = 1
+	held := ComputeColumns(c, 0.02).SettlingTimeSeconds
+	undisturbed, _, _ := experiment.RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), experiment.StepConfig{
+		TargetRPM: 1000, DT: 0.001, Duration: 8,
+	})
+	want := Compute(undisturbed, 0.02).SettlingTimeSeconds
+	if math.IsNaN(held) || math.Abs(held-want) > 1e-9 {
+		t.Errorf("settling time with 1 s hold = %v, want the undisturbed %v", held, want)
+	}
+}
@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Compare returns the relative change of each metric from base to candidate,
+// (candidate - base) / |base|, keyed by json name (see Metrics.Values). A metric
+// that is zero, or NaN, in both runs has no change (0); one that is zero only in
+// base changes by ±Inf, and one that is NaN in only one run by NaN.
+func Compare(base, candidate Metrics) map[string]float64 {
+	b, c := base.Values(), candidate.Values()
+	out := make(map[string]float64, len(b))
+	for k, bv := range b {
+		out[k] = relativeChange(bv, c[k])
+	}
+	return out
+}
+
+func relativeChange(base, candidate float64) float64 {
+	switch {
+	case math.IsNaN(base) && math.IsNaN(candidate), base == candidate:
+		return 0
+	case math.IsNaN(base) || math.IsNaN(candidate):
+		return math.NaN()
+	case base == 0:
+		return math.Copysign(math.Inf(1), candidate)
+	}
+	return (candidate - base) / math.Abs(base)
+}
+
+// RegressionMetrics are the metrics checked by Regressions. Lower magnitudes
+// are better for all of them; steady_state_error is compared by magnitude.
+var RegressionMetrics = []string{
+	"overshoot_percent",
+	"settling_time_seconds",
+	"steady_state_error",
+	"iae",
+	"saturation_fraction",
+	"max_control_rate",
+}
+
+// Regression is a metric that got worse from a base run to a candidate.
+type Regression struct {
+	Metric    string
+	Base      float64
+	Candidate float64
+	// Change is the relative increase of the magnitude, |candidate|/|base| - 1;
+	// +Inf when the base was zero or the candidate is NaN (e.g., it no longer settles).
+	Change float64
+}
+
+// Regressions returns the RegressionMetrics whose magnitude grew by more than
+// tol (a fraction, e.g. 0.05 for 5%) from base to candidate, sorted by name,
+// e.g. to assert in CI that a change did not worsen control performance.
+// A candidate that is NaN while base is not, such as a settling time that is
+// no longer reached, is a regression; a base that is NaN is not comparable and
+// never regresses. A metric that was zero regresses on any increase.
+func Regressions(base, candidate Metrics, tol float64) []Regression {
+	b, c := base.Values(), candidate.Values()
+	var out []Regression
+	for _, k := range RegressionMetrics {
+		bv, cv := b[k], c[k]
+		if math.IsNaN(bv) {
+			continue
+		}
+		change := math.Inf(1)
+		if !math.IsNaN(cv) {
+			change = relativeChange(math.Abs(bv), math.Abs(cv))
+		}
+		if change > tol {
+			out = append(out, Regression{Metric: k, Base: bv, Candidate: cv, Change: change})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Metric < out[j].Metric })
+	return out
+}
@@ -0,0 +1,89 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	nan := math.NaN()
+	base := Metrics{OvershootPercent: 10, IAE: 200, SteadyStateError: -2, SettlingTimeSeconds: nan, MaxU: 24}
+	cand := Metrics{OvershootPercent: 12, IAE: 150, SteadyStateError: -1, SettlingTimeSeconds: nan, MaxU: 24, MinHeadroom: 3}
+
+	got := Compare(base, cand)
+	if len(got) != len(base.Values()) {
+		t.Errorf("Compare() has %d metrics, want all %d", len(got), len(base.Values()))
+	}
+	want := map[string]float64{
+		"overshoot_percent":     0.2,
+		"iae":                   -0.25,
+		"steady_state_error":    0.5, // -2 to -1: up by half of |base|
+		"settling_time_seconds": 0,   // NaN in both
+		"max_u":                 0,
+		"min_headroom":          math.Inf(1), // zero in base
+	}
+	for k, w := range want {
+		if g := got[k]; !sameFloat(g, w) && math.Abs(g-w) > eps {
+			t.Errorf("%s: change = %v, want %v", k, g, w)
+		}
+	}
+
+	cand.SettlingTimeSeconds = 1.5
+	if g := Compare(base, cand)["settling_time_seconds"]; !math.IsNaN(g) {
+		t.Errorf("settling_time_seconds NaN in base only: change = %v, want NaN", g)
+	}
+}
+
+func TestRegressions(t *testing.T) {
+	nan := math.NaN()
+	base := Metrics{OvershootPercent: 10, SettlingTimeSeconds: 1, SteadyStateError: -1, IAE: 200, MaxControlRate: 100}
+
+	tests := []struct {
+		name string
+		cand Metrics
+		tol  float64
+		want []string
+	}{
+		{"identical", base, 0, nil},
+		{"within tolerance",
+			Metrics{OvershootPercent: 10.4, SettlingTimeSeconds: 1.04, SteadyStateError: 1.03, IAE: 150, MaxControlRate: 104}, 0.05, nil},
+		{"beyond tolerance",
+			Metrics{OvershootPercent: 11, SettlingTimeSeconds: 1, SteadyStateError: -1, IAE: 240, MaxControlRate: 100}, 0.05,
+			[]string{"iae", "overshoot_percent"}},
+		// The sign of the steady-state error does not matter, its magnitude does
+		{"steady-state error magnitude", Metrics{OvershootPercent: 10, SettlingTimeSeconds: 1, SteadyStateError: 2, IAE: 200, MaxControlRate: 100}, 0.05,
+			[]string{"steady_state_error"}},
+		{"no longer settles", Metrics{OvershootPercent: 10, SettlingTimeSeconds: nan, SteadyStateError: -1, IAE: 200, MaxControlRate: 100}, 0.5,
+			[]string{"settling_time_seconds"}},
+		{"starts saturating", Metrics{OvershootPercent: 10, SettlingTimeSeconds: 1, SteadyStateError: -1, IAE: 200, MaxControlRate: 100, SaturationFraction: 0.01}, 0.5,
+			[]string{"saturation_fraction"}},
+		{"improvements", Metrics{OvershootPercent: 2, SettlingTimeSeconds: 0.5, IAE: 100, MaxControlRate: 50}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range Regressions(base, tt.cand, tt.tol) {
+				got = append(got, r.Metric)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Regressions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegressions_Details(t *testing.T) {
+	base := Metrics{IAE: 200, SettlingTimeSeconds: math.NaN()}
+	cand := Metrics{IAE: 300, SettlingTimeSeconds: 2}
+
+	// A base that never settled is not comparable
+	got := Regressions(base, cand, 0.1)
+	want := []Regression{{Metric: "iae", Base: 200, Candidate: 300, Change: 0.5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Regressions() = %+v, want %+v", got, want)
+	}
+	if got := Regressions(base, cand, 0.5); len(got) != 0 {
+		t.Errorf("Regressions() at tolerance 0.5 = %+v, want none (the limit is exclusive)", got)
+	}
+}
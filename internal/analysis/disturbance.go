@@ -0,0 +1,21 @@
+package analysis
+
+import (
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// DisturbanceSignal is the signal key under which systems report the applied
+// load disturbance (see wrap.DisturbedSystem).
+const DisturbanceSignal = "disturbance_rpm_per_s"
+
+// DisturbanceOnset returns the time of the first sample whose disturbance signal
+// is non-zero. ok is false if no sample carries a non-zero disturbance, including
+// runs recorded without the signal.
+func DisturbanceOnset(samples []experiment.Sample) (t float64, ok bool) {
+	for _, s := range samples {
+		if s.Signals[DisturbanceSignal] != 0 {
+			return s.T, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// disturbanceSamples returns n samples spaced by dt with the disturbance
+// signal set to mag from index onset on (no signal at all when onset < 0).
+func disturbanceSamples(n int, dt float64, onset int, mag float64) []experiment.Sample {
+	samples := make([]experiment.Sample, n)
+	for i := range samples {
+		samples[i].T = float64(i) * dt
+		samples[i].DT = dt
+		if onset >= 0 {
+			d := 0.0
+			if i >= onset {
+				d = mag
+			}
+			samples[i].Signals = map[string]float64{DisturbanceSignal: d}
+		}
+	}
+	return samples
+}
+
+func TestDisturbanceOnset(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []experiment.Sample
+		wantT   float64
+		wantOK  bool
+	}{
+		{"mid-run onset", disturbanceSamples(100, 0.1, 40, 50), 4.0, true},
+		{"negative disturbance", disturbanceSamples(100, 0.1, 10, -20), 1.0, true},
+		{"onset at first sample", disturbanceSamples(10, 0.1, 0, 5), 0, true},
+		{"signal always zero", disturbanceSamples(100, 0.1, 100, 50), 0, false},
+		{"no signal", disturbanceSamples(100, 0.1, -1, 0), 0, false},
+		{"empty", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DisturbanceOnset(tt.samples)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(got-tt.wantT) > eps {
+				t.Errorf("onset = %v, want %v", got, tt.wantT)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package analysis
+
+import "math"
+
+// FrequencyPoint is a single point of a frequency response.
+// Magnitude is expressed in dB and phase in degrees.
+type FrequencyPoint struct {
+	FreqHz      float64 `json:"freq_hz"`
+	MagnitudeDB float64 `json:"magnitude_db"`
+	PhaseDeg    float64 `json:"phase_deg"`
+}
+
+// halfPowerDB is the -3 dB (half-power) level, 20·log10(1/√2).
+var halfPowerDB = -10 * math.Log10(2)
+
+// Bandwidth returns the closed-loop bandwidth of a frequency response sorted by
+// ascending frequency: the frequency (Hz) where the magnitude first drops 3 dB
+// (half power) below the low-frequency gain, taken as the magnitude of the
+// first point. It interpolates linearly in dB over log frequency between the
+// bracketing points. It returns false if the response never drops that far
+// (e.g., the sweep stops too early) or has fewer than two points.
+func Bandwidth(fr []FrequencyPoint) (float64, bool) {
+	if len(fr) < 2 {
+		return 0, false
+	}
+	level := fr[0].MagnitudeDB + halfPowerDB
+	for i := 1; i < len(fr); i++ {
+		a, b := fr[i-1], fr[i]
+		if !(b.MagnitudeDB < level) {
+			continue
+		}
+		frac := (a.MagnitudeDB - level) / (a.MagnitudeDB - b.MagnitudeDB)
+		if a.FreqHz > 0 && b.FreqHz > 0 {
+			return math.Exp(math.Log(a.FreqHz) + frac*(math.Log(b.FreqHz)-math.Log(a.FreqHz))), true
+		}
+		return a.FreqHz + frac*(b.FreqHz-a.FreqHz), true
+	}
+	return 0, false
+}
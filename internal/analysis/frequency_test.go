@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+// closedLoopResponse samples a P-controlled first-order plant K/(tau·s+1) in
+// closed loop, L/(1+L): a first-order response with DC gain kp·K/(1+kp·K) and
+// corner frequency (1+kp·K)/(2π·tau).
+func closedLoopResponse(kp, k, tau float64, freqs []float64) []FrequencyPoint {
+	fr := make([]FrequencyPoint, len(freqs))
+	for i, f := range freqs {
+		l := complex(kp*k, 0) / complex(1, 2*math.Pi*f*tau)
+		h := l / (1 + l)
+		fr[i] = FrequencyPoint{
+			FreqHz:      f,
+			MagnitudeDB: 20 * math.Log10(math.Hypot(real(h), imag(h))),
+			PhaseDeg:    math.Atan2(imag(h), real(h)) * 180 / math.Pi,
+		}
+	}
+	return fr
+}
+
+func logFreqs(lo, hi float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = lo * math.Pow(hi/lo, float64(i)/float64(n-1))
+	}
+	return out
+}
+
+func TestBandwidth(t *testing.T) {
+	tests := []struct {
+		name    string
+		fr      []FrequencyPoint
+		want    float64 // Hz
+		wantOK  bool
+		tolFrac float64
+	}{
+		// 1+kp·K = 1 + 0.02·100 = 3, tau = 0.5 s: 3/π ≈ 0.955 Hz
+		{"log sweep", closedLoopResponse(0.02, 100, 0.5, logFreqs(0.01, 100, 60)), 3 / math.Pi, true, 0.005},
+		{"coarse log sweep", closedLoopResponse(0.02, 100, 0.5, logFreqs(0.01, 100, 9)), 3 / math.Pi, true, 0.05},
+		// High loop gain, DC gain near 1: 1+0.5·100 = 51
+		{"high gain", closedLoopResponse(0.5, 100, 0.5, logFreqs(0.1, 1000, 80)), 51 / math.Pi, true, 0.005},
+		// A linear sweep starting at DC
+		{"linear sweep from 0 Hz", closedLoopResponse(0.02, 100, 0.5, []float64{0, 0.25, 0.5, 0.75, 1, 1.25, 1.5}), 3 / math.Pi, true, 0.05},
+		{"sweep stops before the corner", closedLoopResponse(0.02, 100, 0.5, logFreqs(0.01, 0.5, 20)), 0, false, 0},
+		{"single point", closedLoopResponse(0.02, 100, 0.5, []float64{1}), 0, false, 0},
+		{"empty", nil, 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Bandwidth(tt.fr)
+			if ok != tt.wantOK {
+				t.Fatalf("Bandwidth() = %v, %v; want ok %v", got, ok, tt.wantOK)
+			}
+			if ok && math.Abs(got-tt.want) > tt.tolFrac*tt.want {
+				t.Errorf("Bandwidth() = %v Hz, want %v Hz within %v%%", got, tt.want, tt.tolFrac*100)
+			}
+		})
+	}
+}
+
+func TestBandwidth_RelativeToLowFrequencyGain(t *testing.T) {
+	// Shifting the whole response leaves the bandwidth unchanged
+	fr := closedLoopResponse(0.02, 100, 0.5, logFreqs(0.01, 100, 60))
+	want, _ := Bandwidth(fr)
+	for i := range fr {
+		fr[i].MagnitudeDB -= 20
+	}
+	if got, ok := Bandwidth(fr); !ok || math.Abs(got-want) > 1e-9 {
+		t.Errorf("Bandwidth() of the response 20 dB lower = %v, %v; want %v", got, ok, want)
+	}
+}
@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// FitFirstOrder estimates the gain K and time constant tau of a first-order plant
+// from an open-loop step response (e.g., recorded with experiment.RunImpulse using
+// a pulse that lasts until the end of the run).
+//
+// The step is located at the first sample where U departs from its initial value.
+// The gain is the change in steady-state output divided by the change in input,
+// using the final sample as steady state. Tau is the time from the step until the
+// output first covers 63.2% (1 - 1/e) of its total change, linearly interpolated
+// between samples.
+//
+// ok is false if there is no input step, the input does not change the output,
+// or the 63.2% point is never reached.
+func FitFirstOrder(samples []experiment.Sample) (gain, tau float64, ok bool) {
+	if len(samples) < 3 {
+		return 0, 0, false
+	}
+
+	u0 := samples[0].U
+	stepIdx := -1
+	for i, s := range samples {
+		if s.U != u0 {
+			stepIdx = i
+			break
+		}
+	}
+	if stepIdx < 0 {
+		return 0, 0, false
+	}
+
+	last := samples[len(samples)-1]
+	du := last.U - u0
+	y0 := samples[stepIdx].Actual
+	dy := last.Actual - y0
+	if du == 0 || dy == 0 {
+		return 0, 0, false
+	}
+
+	gain = dy / du
+
+	level := y0 + (1-math.Exp(-1))*dy
+	t0 := samples[stepIdx].T
+	for i := stepIdx + 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		if (dy > 0 && cur.Actual >= level) || (dy < 0 && cur.Actual <= level) {
+			frac := (level - prev.Actual) / (cur.Actual - prev.Actual)
+			return gain, prev.T + frac*(cur.T-prev.T) - t0, true
+		}
+	}
+
+	return 0, 0, false
+}
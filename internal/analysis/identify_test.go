@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestFitFirstOrder_RecoversPlantParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		gain    float64
+		tau     float64
+		voltage float64
+	}{
+		{name: "default-like plant", gain: 100.0, tau: 0.5, voltage: 6.0},
+		{name: "fast plant", gain: 150.0, tau: 0.1, voltage: 12.0},
+		{name: "negative step", gain: 80.0, tau: 0.3, voltage: -5.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plant := sim.NewDCMotor()
+			plant.GainRPMPerVolt = tt.gain
+			plant.TauSeconds = tt.tau
+
+			// A pulse lasting until the end of the run is an open-loop step.
+			samples, _ := experiment.RunImpulse(plant, experiment.ImpulseConfig{
+				DT:       0.001,
+				Duration: 10 * tt.tau,
+				StartS:   0.05,
+				WidthS:   20 * tt.tau,
+				VoltageV: tt.voltage,
+			})
+
+			gain, tau, ok := FitFirstOrder(samples)
+			if !ok {
+				t.Fatal("FitFirstOrder() ok = false, want true")
+			}
+			if math.Abs(gain-tt.gain)/tt.gain > 0.01 {
+				t.Errorf("gain = %v, want %v (within 1%%)", gain, tt.gain)
+			}
+			if math.Abs(tau-tt.tau)/tt.tau > 0.02 {
+				t.Errorf("tau = %v, want %v (within 2%%)", tau, tt.tau)
+			}
+		})
+	}
+}
+
+func TestFitFirstOrder_NoStep(t *testing.T) {
+	samples := makeSamples(0, []float64{0, 0, 0, 0}, 0.1)
+	if _, _, ok := FitFirstOrder(samples); ok {
+		t.Error("FitFirstOrder() ok = true for a run without an input step")
+	}
+	if _, _, ok := FitFirstOrder(nil); ok {
+		t.Error("FitFirstOrder(nil) ok = true")
+	}
+}
@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// LimitCycleMinAmplitudeFrac is the smallest oscillation amplitude, as a
+// fraction of |target|, that DetectLimitCycle reports. Smaller ripple (e.g.,
+// numerical or quantization noise around a settled response) is ignored.
+const LimitCycleMinAmplitudeFrac = 0.001
+
+// DetectLimitCycle looks for a sustained oscillation of the error in steady
+// state, taken as the second half of the run. It finds the crossings of the
+// error through its mean (linearly interpolated between samples) and reports:
+//
+//   - periodS, the mean time between crossings in the same direction,
+//   - amplitude, half the mean peak-to-peak error per period.
+//
+// present is true only for at least two full periods whose amplitude is above
+// LimitCycleMinAmplitudeFrac of the target and does not decay: the last
+// period's peak-to-peak must be at least half the first's, so a damped
+// response that is still ringing is not reported.
+func DetectLimitCycle(samples []experiment.Sample) (amplitude, periodS float64, present bool) {
+	if len(samples) < 2 {
+		return 0, 0, false
+	}
+	tMid := (samples[0].T + samples[len(samples)-1].T) / 2
+	start := 0
+	for start < len(samples) && samples[start].T < tMid {
+		start++
+	}
+	window := samples[start:]
+
+	var mean float64
+	for _, s := range window {
+		mean += s.Error
+	}
+	mean /= float64(len(window))
+
+	// Upward crossings of the mean: each starts a period
+	var ups []float64
+	var upIdx []int
+	for i := 1; i < len(window); i++ {
+		a, b := window[i-1].Error-mean, window[i].Error-mean
+		if a < 0 && b >= 0 {
+			ups = append(ups, window[i-1].T+(window[i].T-window[i-1].T)*(-a)/(b-a))
+			upIdx = append(upIdx, i)
+		}
+	}
+	periods := len(ups) - 1
+	if periods < 2 {
+		return 0, 0, false
+	}
+
+	peakToPeak := make([]float64, periods)
+	for p := 0; p < periods; p++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, s := range window[upIdx[p]:upIdx[p+1]] {
+			lo, hi = math.Min(lo, s.Error), math.Max(hi, s.Error)
+		}
+		peakToPeak[p] = hi - lo
+	}
+	var sum float64
+	for _, pp := range peakToPeak {
+		sum += pp
+	}
+	amplitude = sum / float64(periods) / 2
+	periodS = (ups[periods] - ups[0]) / float64(periods)
+
+	minAmp := LimitCycleMinAmplitudeFrac * math.Abs(window[len(window)-1].Target)
+	sustained := peakToPeak[periods-1] >= 0.5*peakToPeak[0]
+	return amplitude, periodS, amplitude > minAmp && sustained
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+// errorSamples returns samples at dt over [0, duration) with the given error
+// around a target of 1000.
+func errorSamples(dt, duration float64, e func(t float64) float64) []experiment.Sample {
+	n := int(math.Round(duration / dt))
+	samples := make([]experiment.Sample, n)
+	for i := range samples {
+		t := float64(i) * dt
+		samples[i] = experiment.Sample{T: t, DT: dt, Target: 1000, Error: e(t), Actual: 1000 - e(t)}
+	}
+	return samples
+}
+
+func TestDetectLimitCycle_Sinusoid(t *testing.T) {
+	tests := []struct {
+		name      string
+		amplitude float64
+		periodS   float64
+		offset    float64
+	}{
+		{"sustained", 20, 0.5, 0},
+		{"with offset", 5, 1.3, 3},
+		{"fast", 50, 0.07, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := errorSamples(0.001, 10, func(t float64) float64 {
+				return tt.offset + tt.amplitude*math.Sin(2*math.Pi*t/tt.periodS)
+			})
+			amp, period, present := DetectLimitCycle(samples)
+			if !present {
+				t.Fatal("present = false, want a limit cycle")
+			}
+			if math.Abs(amp-tt.amplitude) > 0.01*tt.amplitude {
+				t.Errorf("amplitude = %v, want %v", amp, tt.amplitude)
+			}
+			if math.Abs(period-tt.periodS) > 1e-3*tt.periodS {
+				t.Errorf("period = %v, want %v", period, tt.periodS)
+			}
+		})
+	}
+}
+
+func TestDetectLimitCycle_NotPresent(t *testing.T) {
+	settled, _, err := experiment.RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0),
+		experiment.StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		samples []experiment.Sample
+	}{
+		{"settled step response", settled},
+		{"damped ringing", errorSamples(0.001, 10, func(t float64) float64 {
+			return 100 * math.Exp(-0.3*t) * math.Sin(2*math.Pi*t/0.4)
+		})},
+		{"tiny ripple", errorSamples(0.001, 10, func(t float64) float64 {
+			return 0.1 * math.Sin(2*math.Pi*t/0.5)
+		})},
+		{"one period only", errorSamples(0.001, 10, func(t float64) float64 {
+			return 20 * math.Sin(2*math.Pi*t/4)
+		})},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if amp, period, present := DetectLimitCycle(tt.samples); present {
+				t.Errorf("present = true (amplitude %v, period %v), want false", amp, period)
+			}
+		})
+	}
+}
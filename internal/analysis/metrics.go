@@ -1,7 +1,11 @@
 package analysis
 
 import (
+	"bytes"
+	"encoding/json"
 	"math"
+	"reflect"
+	"strings"
 
 	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
 )
@@ -18,76 +22,384 @@ type Metrics struct {
 	IAE                 float64 `json:"iae"`
 	SettlingTimeSeconds float64 `json:"settling_time_seconds"`
 	SaturationFraction  float64 `json:"saturation_fraction"`
+
+	// SaturationHighFraction and SaturationLowFraction split SaturationFraction
+	// by the rail that was hit, e.g. to spot asymmetric limits that are too tight
+	// on one side. A saturated sample is at the high rail when its command is
+	// nearer OutMax than OutMin; when computed without limits, when it is
+	// non-negative. The two sum to SaturationFraction.
+	SaturationHighFraction float64 `json:"saturation_high_fraction"`
+	SaturationLowFraction  float64 `json:"saturation_low_fraction"`
+
+	// MaxControlRate is the largest command slew rate max(|U[i]-U[i-1]|/DT), in units of U per second.
+	// High values indicate a chattering actuator.
+	MaxControlRate float64 `json:"max_control_rate"`
+
+	// AcceleratingFraction and DeceleratingFraction are the fractions of the run
+	// time (weighted by each sample's DT) over which Actual was increasing or
+	// decreasing, from the sign of its difference to the previous sample, e.g.
+	// for mechanical wear analysis. Intervals changing slower than
+	// FlatRateFrac·|Target| per second count as flat (neither), so the two sum to
+	// 1 minus the flat fraction. Both are NaN for a run of fewer than two samples.
+	AcceleratingFraction float64 `json:"accelerating_fraction"`
+	DeceleratingFraction float64 `json:"decelerating_fraction"`
+
+	// MeanU, MaxU and MinU summarize the applied command, e.g. for sizing the
+	// actuator. MeanU is the time average (weighted by each sample's DT).
+	// All three are NaN for an empty run.
+	MeanU float64 `json:"mean_u"`
+	MaxU  float64 `json:"max_u"`
+	MinU  float64 `json:"min_u"`
+
+	// OutMin and OutMax are the controller output limits the run was recorded with,
+	// so that consumers can reference them (e.g., to shade saturation in plots).
+	// Both are zero when computed without limits (see ComputeWithLimits).
+	OutMin float64 `json:"out_min"`
+	OutMax float64 `json:"out_max"`
+
+	// MinHeadroom is how close the command came to the limits over the run,
+	// min(min(OutMax-U, U-OutMin)); for symmetric limits that is min(OutMax-|U|).
+	// Zero means the run saturated. It is NaN when computed without limits.
+	MinHeadroom float64 `json:"min_headroom"`
 }
 
+// FlatRateFrac is the rate of change of Actual, as a fraction of |Target| per
+// second, below which an interval counts as flat for AcceleratingFraction and
+// DeceleratingFraction (0.1 RPM/s at 1000 RPM). With a zero target only an
+// exactly constant interval is flat.
+const FlatRateFrac = 1e-4
+
 // Compute calculates common step-response metrics.
 // settleBandFrac is typically 0.02 for a 2% band.
 func Compute(samples []experiment.Sample, settleBandFrac float64) Metrics {
-	if len(samples) == 0 {
-		return Metrics{SettlingTimeSeconds: math.NaN()}
-	}
+	return ComputeWithOptions(samples, settleBandFrac, Options{})
+}
 
-	target := samples[len(samples)-1].Target
+// ComputeWithLimits is like Compute but also records the controller's output
+// limits in the returned Metrics.
+func ComputeWithLimits(samples []experiment.Sample, settleBandFrac, outMin, outMax float64) Metrics {
+	return ComputeWithOptions(samples, settleBandFrac, Options{OutMin: outMin, OutMax: outMax})
+}
 
-	maxA := samples[0].Actual
-	minA := samples[0].Actual
+// Options configures ComputeWithOptions. The zero value computes the same
+// metrics as Compute.
+//
+// OutMin and OutMax are the controller's output limits; they are passed
+// through to Metrics unchanged (see ComputeWithLimits).
+//
+// SettleHoldS selects the settling criterion. When zero, the response settles
+// when it enters the band and stays there until the end of the run, so a late
+// excursion (e.g., a disturbance) makes the settling time NaN. When positive, it
+// settles at the first time it stays within the band for at least SettleHoldS
+// seconds (or until the end of the run), regardless of later excursions.
+type Options struct {
+	OutMin float64
+	OutMax float64
 
-	var iae float64
-	var sat int
+	SettleHoldS float64
+}
+
+// ComputeWithOptions is like Compute with the limits and settling criterion
+// given by opts. It calculates the metrics in a single pass over samples,
+// without copying them into columns (see ComputeColumns for the columnar path).
+func ComputeWithOptions(samples []experiment.Sample, settleBandFrac float64, opts Options) Metrics {
+	n := len(samples)
+	if n == 0 {
+		return emptyMetrics(opts.OutMin, opts.OutMax)
+	}
+	acc := newAccumulator(samples[n-1].Target, settleBandFrac, opts)
 	for _, s := range samples {
-		if s.Actual > maxA {
-			maxA = s.Actual
+		acc.add(s.T, s.DT, s.Actual, s.Error, s.U, s.Saturated)
+	}
+	return acc.metrics()
+}
+
+// accumulator calculates the metrics one sample at a time. ComputeWithOptions
+// and ComputeColumns both feed it, so the row and columnar paths agree by
+// construction.
+type accumulator struct {
+	target, band, flatRate float64
+	outMin, outMax, mid    float64
+	holdS                  float64 // see Options.SettleHoldS
+
+	n                      int
+	firstActual, lastError float64
+	prevActual, prevU      float64
+
+	maxA, minA, maxU, minU     float64
+	iae, uArea, duration, uSum kahanSum
+	satHigh, satLow            int
+	maxRate                    float64
+	headroom                   float64
+
+	// Settling: without a hold, the time of the first sample after the last
+	// excursion outside the band (pending while outside); with one, the start
+	// of the current in-band stretch and of the first that lasted holdS
+	settleT, stretchT, heldT float64
+	pending, inBand, held    bool
+
+	// Motion fractions, both DT-weighted and per interval; the weighted ones
+	// are used if any interval has a time step
+	weighted           bool
+	upW, downW, totalW kahanSum
+	upN, downN, totalN int
+}
+
+// newAccumulator returns an accumulator for a run with the given final target.
+func newAccumulator(target, settleBandFrac float64, opts Options) *accumulator {
+	band := math.Abs(target) * settleBandFrac
+	if band == 0 {
+		band = 1e-9
+	}
+	a := &accumulator{
+		target:   target,
+		band:     band,
+		flatRate: math.Abs(target) * FlatRateFrac,
+		outMin:   opts.OutMin,
+		outMax:   opts.OutMax,
+		holdS:    opts.SettleHoldS,
+		headroom: math.NaN(),
+		pending:  true,
+	}
+	// Saturated samples are split by rail: the command is on the side of the
+	// midpoint of the limits (or of zero, without limits) of the rail it hit
+	if a.outMax > a.outMin {
+		a.mid = (a.outMin + a.outMax) / 2
+		a.headroom = math.Inf(1)
+	}
+	return a
+}
+
+// add records the next sample of the run.
+func (a *accumulator) add(t, dt, actual, err, u float64, saturated bool) {
+	if a.n == 0 {
+		a.firstActual = actual
+		a.maxA, a.minA = actual, actual
+		a.maxU, a.minU = u, u
+	}
+	a.maxA = math.Max(a.maxA, actual)
+	a.minA = math.Min(a.minA, actual)
+	// Compensated summation keeps IAE accurate over millions of tiny terms
+	a.iae.add(math.Abs(err) * dt)
+	a.lastError = err
+	a.addSettling(t, math.Abs(err) <= a.band)
+
+	switch {
+	case !saturated:
+	case u >= a.mid:
+		a.satHigh++
+	default:
+		a.satLow++
+	}
+
+	a.maxU = math.Max(a.maxU, u)
+	a.minU = math.Min(a.minU, u)
+	a.uArea.add(u * dt)
+	a.duration.add(dt)
+	a.uSum.add(u)
+	if a.outMax > a.outMin {
+		a.headroom = math.Min(a.headroom, math.Min(a.outMax-u, u-a.outMin))
+	}
+
+	if a.n > 0 {
+		if dt > 0 {
+			a.weighted = true
+			if r := math.Abs(u-a.prevU) / dt; r > a.maxRate {
+				a.maxRate = r
+			}
 		}
-		if s.Actual < minA {
-			minA = s.Actual
+		d, limit := actual-a.prevActual, a.flatRate*dt
+		a.totalW.add(dt)
+		a.totalN++
+		switch {
+		case d > limit:
+			a.upW.add(dt)
+		case d < -limit:
+			a.downW.add(dt)
 		}
-		iae += math.Abs(s.Error) * s.DT
-		if s.Saturated {
-			sat++
+		switch {
+		case d > 0:
+			a.upN++
+		case d < 0:
+			a.downN++
 		}
 	}
+	a.prevActual, a.prevU = actual, u
+	a.n++
+}
 
-	overshoot := 0.0
-	if target != 0 {
-		o := (maxA - target) / math.Abs(target) * 100.0
-		if o > 0 {
-			overshoot = o
+// addSettling updates the settling state with a sample at time t.
+func (a *accumulator) addSettling(t float64, inBand bool) {
+	if a.holdS <= 0 {
+		switch {
+		case !inBand:
+			a.pending = true
+		case a.pending:
+			a.settleT, a.pending = t, false
 		}
+		return
+	}
+	switch {
+	case !inBand:
+		a.inBand = false
+	case !a.inBand:
+		a.stretchT, a.inBand = t, true
+	}
+	if a.inBand && !a.held && t-a.stretchT >= a.holdS {
+		a.heldT, a.held = a.stretchT, true
 	}
+}
 
-	steadyErr := samples[len(samples)-1].Error
+// settlingTime returns the settling time of the samples added so far (see
+// Options.SettleHoldS for the criterion), or NaN if the response never settled.
+func (a *accumulator) settlingTime() float64 {
+	switch {
+	case a.holdS <= 0 && !a.pending:
+		return a.settleT
+	case a.holdS <= 0:
+		return math.NaN()
+	case a.held:
+		return a.heldT
+	case a.inBand:
+		// In band until the end of the run
+		return a.stretchT
+	}
+	return math.NaN()
+}
 
-	band := math.Abs(target) * settleBandFrac
-	if band == 0 {
-		band = 1e-9
+// metrics returns the metrics of the samples added so far.
+func (a *accumulator) metrics() Metrics {
+	if a.n == 0 {
+		return emptyMetrics(a.outMin, a.outMax)
 	}
+	n := float64(a.n)
 
-	settle := math.NaN()
-	for i := range samples {
-		if math.Abs(samples[i].Error) > band {
+	// A run whose output starts within the band of the final target is a
+	// zero-magnitude step (e.g., a warm start): there is no transient to
+	// overshoot, so excursions past the target are not reported as overshoot.
+	// They still show in max_actual, iae and, if they leave the band, the
+	// settling time. The first sample's error is not used: with a moving
+	// reference (e.g., a ramp from rest) it is measured against the reference
+	// at t=0, not against the final target.
+	overshoot := 0.0
+	if zeroStep := math.Abs(a.target-a.firstActual) <= a.band; a.target != 0 && !zeroStep {
+		overshoot = math.Max((a.maxA-a.target)/math.Abs(a.target)*100.0, 0)
+	}
+
+	// Without time steps every interval counts equally and only exact repeats
+	// are flat; a single sample has no intervals
+	accel, decel := math.NaN(), math.NaN()
+	switch {
+	case a.n < 2:
+	case a.weighted && a.totalW.sum != 0:
+		accel, decel = a.upW.sum/a.totalW.sum, a.downW.sum/a.totalW.sum
+	case a.weighted:
+		accel, decel = 0, 0
+	default:
+		accel, decel = float64(a.upN)/float64(a.totalN), float64(a.downN)/float64(a.totalN)
+	}
+
+	// Without time steps the mean command is unweighted
+	meanU := a.uSum.sum / n
+	if a.duration.sum > 0 {
+		meanU = a.uArea.sum / a.duration.sum
+	}
+
+	return Metrics{
+		Target:                 a.target,
+		MaxActual:              a.maxA,
+		MinActual:              a.minA,
+		OvershootPercent:       overshoot,
+		SteadyStateError:       a.lastError,
+		IAE:                    a.iae.sum,
+		SettlingTimeSeconds:    a.settlingTime(),
+		SaturationFraction:     float64(a.satHigh+a.satLow) / n,
+		SaturationHighFraction: float64(a.satHigh) / n,
+		SaturationLowFraction:  float64(a.satLow) / n,
+		MaxControlRate:         a.maxRate,
+		AcceleratingFraction:   accel,
+		DeceleratingFraction:   decel,
+		MeanU:                  meanU,
+		MaxU:                   a.maxU,
+		MinU:                   a.minU,
+		OutMin:                 a.outMin,
+		OutMax:                 a.outMax,
+		MinHeadroom:            math.Max(a.headroom, 0),
+	}
+}
+
+// kahanSum accumulates floats with Kahan (compensated) summation, carrying the
+// low-order bits lost by each addition into the next one. The result is
+// deterministic for a given input order.
+type kahanSum struct {
+	sum float64
+	c   float64 // running compensation
+}
+
+func (k *kahanSum) add(x float64) {
+	y := x - k.c
+	t := k.sum + y
+	k.c = (t - k.sum) - y
+	k.sum = t
+}
+
+// emptyMetrics returns the metrics of a run without samples.
+func emptyMetrics(outMin, outMax float64) Metrics {
+	nan := math.NaN()
+	return Metrics{SettlingTimeSeconds: nan, OutMin: outMin, OutMax: outMax, MinHeadroom: nan,
+		MeanU: nan, MaxU: nan, MinU: nan, AcceleratingFraction: nan, DeceleratingFraction: nan}
+}
+
+// Values returns the metrics keyed by their json names (e.g. "overshoot_percent"),
+// for code that processes all metrics generically.
+func (m Metrics) Values() map[string]float64 {
+	v := reflect.ValueOf(m)
+	t := v.Type()
+	out := make(map[string]float64, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
 			continue
 		}
-		ok := true
-		for j := i; j < len(samples); j++ {
-			if math.Abs(samples[j].Error) > band {
-				ok = false
-				break
-			}
+		out[name] = v.Field(i).Float()
+	}
+	return out
+}
+
+// MarshalJSON implements json.Marshaler. JSON has no NaN or infinity, so
+// non-finite values (e.g., the settling time of a run that never settled) are
+// written as null; the fields keep their declaration order.
+func (m Metrics) MarshalJSON() ([]byte, error) {
+	v := reflect.ValueOf(m)
+	t := v.Type()
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
 		}
-		if ok {
-			settle = samples[i].T
-			break
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
 		}
-	}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
 
-	return Metrics{
-		Target:              target,
-		MaxActual:           maxA,
-		MinActual:           minA,
-		OvershootPercent:    overshoot,
-		SteadyStateError:    steadyErr,
-		IAE:                 iae,
-		SettlingTimeSeconds: settle,
-		SaturationFraction:  float64(sat) / float64(len(samples)),
+		f := v.Field(i).Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			buf.WriteString("null")
+			continue
+		}
+		val, err := json.Marshal(f)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
 	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
 }
@@ -1,10 +1,16 @@
 package analysis
 
 import (
+	"encoding/json"
 	"math"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
 	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
 )
 
 const eps = 1e-6
@@ -296,6 +302,37 @@ func TestSaturationFraction(t *testing.T) {
 	}
 }
 
+func TestSaturationRails(t *testing.T) {
+	tests := []struct {
+		name           string
+		outMin, outMax float64
+		us             []float64 // saturated where at a limit (or ±24 without limits)
+		high, low      float64
+	}{
+		// Pinned high for 3 samples, low for 2, then free
+		{"asymmetric limits", -6, 24, []float64{24, 24, 24, 10, -6, -6, 2, 5, 5, 5}, 0.3, 0.2},
+		{"without limits", 0, 0, []float64{24, 24, 24, 10, -24, -24, 2, 5, 5, 5}, 0.3, 0.2},
+		// Both rails above zero: the low one is still told apart by the midpoint
+		{"positive limits", 4, 24, []float64{4, 4, 4, 4, 10, 24, 10, 10, 10, 10}, 0.1, 0.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := make([]experiment.Sample, len(tt.us))
+			for i, u := range tt.us {
+				sat := u == tt.outMin || u == tt.outMax || math.Abs(u) == 24
+				samples[i] = experiment.Sample{T: float64(i) * 0.1, DT: 0.1, Target: 100, Actual: 50, Error: 50, U: u, Saturated: sat}
+			}
+			m := ComputeWithLimits(samples, 0.02, tt.outMin, tt.outMax)
+			if math.Abs(m.SaturationHighFraction-tt.high) > eps || math.Abs(m.SaturationLowFraction-tt.low) > eps {
+				t.Errorf("high/low = %v/%v, want %v/%v", m.SaturationHighFraction, m.SaturationLowFraction, tt.high, tt.low)
+			}
+			if math.Abs(m.SaturationHighFraction+m.SaturationLowFraction-m.SaturationFraction) > eps {
+				t.Errorf("high %v + low %v != SaturationFraction %v", m.SaturationHighFraction, m.SaturationLowFraction, m.SaturationFraction)
+			}
+		})
+	}
+}
+
 func TestEmptySamples(t *testing.T) {
 	metrics := Compute(nil, 0.02)
 	if !math.IsNaN(metrics.SettlingTimeSeconds) {
@@ -303,6 +340,65 @@ func TestEmptySamples(t *testing.T) {
 	}
 }
 
+func TestZeroMagnitudeStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     float64
+		actuals    []float64
+		wantSettle float64
+	}{
+		{"flat at the target", 1000, []float64{1000, 1000, 1000, 1000}, 0},
+		{"flat at zero", 0, []float64{0, 0, 0, 0}, 0},
+		// Ripple within the band past the target is not overshoot
+		{"ripple within the band", 1000, []float64{1000, 1000.5, 1001, 1000.2, 1000}, 0},
+		// An excursion out of the band delays settling, but is not overshoot either
+		{"excursion out of the band", 100, []float64{100, 100, 105, 100, 100}, 0.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ComputeWithLimits(makeSamples(tt.target, tt.actuals, 0.1), 0.02, -24, 24)
+			for k, v := range m.Values() {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					t.Errorf("%s = %v, want a finite value", k, v)
+				}
+			}
+			if m.OvershootPercent != 0 {
+				t.Errorf("OvershootPercent = %v, want 0", m.OvershootPercent)
+			}
+			if math.Abs(m.SettlingTimeSeconds-tt.wantSettle) > eps {
+				t.Errorf("SettlingTimeSeconds = %v, want %v", m.SettlingTimeSeconds, tt.wantSettle)
+			}
+		})
+	}
+}
+
+func TestOvershootPercent_MovingReference(t *testing.T) {
+	// A ramp from rest to 1000 RPM that the response overshoots (to ≈1041): the
+	// first sample tracks the ramp's start exactly, but is far from the final target
+	cfg := experiment.StepConfig{
+		TargetRPM: 1000, DT: 0.01, Duration: 3,
+		Reference: experiment.RampReference{RateRPMPerS: 5000, FinalRPM: 1000},
+	}
+	samples, _, err := experiment.RunStep(sim.NewDCMotor(), pid.New(0.1, 2, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples[0].Error > 0.02*1000 {
+		t.Fatalf("fixture: first error %v, want the ramp to start within the band", samples[0].Error)
+	}
+
+	for name, m := range map[string]Metrics{
+		"Compute":        Compute(samples, 0.02),
+		"ComputeColumns": ComputeColumns(ColumnsFromSamples(samples), 0.02),
+	} {
+		want := (m.MaxActual - 1000) / 1000 * 100
+		if m.MaxActual <= 1000 || math.Abs(m.OvershootPercent-want) > eps {
+			t.Errorf("%s: OvershootPercent = %v with max_actual %v, want %v", name, m.OvershootPercent, m.MaxActual, want)
+		}
+	}
+}
+
 // makeSamples creates a slice of samples with given target and actual values
 func makeSamples(target float64, actuals []float64, dt float64) []experiment.Sample {
 	samples := make([]experiment.Sample, 0, len(actuals))
@@ -318,3 +414,263 @@ func makeSamples(target float64, actuals []float64, dt float64) []experiment.Sam
 	}
 	return samples
 }
+
+func TestMaxControlRate(t *testing.T) {
+	withU := func(us []float64, dt float64) []experiment.Sample {
+		samples := makeSamples(100.0, make([]float64, len(us)), dt)
+		for i, u := range us {
+			samples[i].U = u
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name    string
+		samples []experiment.Sample
+		want    float64
+	}{
+		{"step command", withU([]float64{0, 0, 12, 12, 12}, 0.01), 1200.0},
+		{"negative step", withU([]float64{5, 5, -5, -5}, 0.1), 100.0},
+		{"smooth ramp", withU([]float64{0, 0.1, 0.2, 0.3, 0.4}, 0.1), 1.0},
+		{"constant", withU([]float64{3, 3, 3}, 0.1), 0},
+		{"single sample", withU([]float64{7}, 0.1), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(tt.samples, 0.02).MaxControlRate
+			if math.Abs(got-tt.want) > eps {
+				t.Errorf("MaxControlRate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandStatistics(t *testing.T) {
+	withU := func(us []float64, dts []float64) []experiment.Sample {
+		samples := makeSamples(100.0, make([]float64, len(us)), 0.1)
+		for i, u := range us {
+			samples[i].U = u
+			if dts != nil {
+				samples[i].DT = dts[i]
+			}
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name           string
+		samples        []experiment.Sample
+		mean, max, min float64
+	}{
+		{"known command", withU([]float64{2, -4, 10, 6, 1}, nil), 3, 10, -4},
+		{"constant", withU([]float64{12, 12, 12}, nil), 12, 12, 12},
+		// 24 V for 0.3 s and 0 V for 0.1 s: the time average is 18 V, not 12 V
+		{"time weighted", withU([]float64{24, 0}, []float64{0.3, 0.1}), 18, 24, 0},
+		{"no time steps", withU([]float64{1, 2, 6}, []float64{0, 0, 0}), 3, 6, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Compute(tt.samples, 0.02)
+			if math.Abs(m.MeanU-tt.mean) > eps || m.MaxU != tt.max || m.MinU != tt.min {
+				t.Errorf("mean/max/min U = %v/%v/%v, want %v/%v/%v", m.MeanU, m.MaxU, m.MinU, tt.mean, tt.max, tt.min)
+			}
+		})
+	}
+
+	if m := Compute(nil, 0.02); !math.IsNaN(m.MeanU) || !math.IsNaN(m.MaxU) || !math.IsNaN(m.MinU) {
+		t.Errorf("empty run: mean/max/min U = %v/%v/%v, want NaN", m.MeanU, m.MaxU, m.MinU)
+	}
+}
+
+func TestMotionFractions(t *testing.T) {
+	tests := []struct {
+		name         string
+		actuals      []float64
+		dts          []float64 // nil: 0.1 s each
+		accel, decel float64
+	}{
+		// Rise over 4 intervals, overshoot back down over 2, then settle flat for 4
+		{"rise then settle", []float64{0, 40, 80, 100, 110, 105, 100, 100, 100, 100, 100}, nil, 0.4, 0.2},
+		{"monotonic rise", []float64{0, 10, 20, 30}, nil, 1, 0},
+		{"monotonic fall", []float64{30, 20, 10, 0}, nil, 0, 1},
+		{"constant", []float64{100, 100, 100}, nil, 0, 0},
+		// A 0.001 RPM/s creep is below FlatRateFrac*100 = 0.01 RPM/s: flat
+		{"slow creep", []float64{100, 100.0001, 100.0002}, nil, 0, 0},
+		// Rising for 0.3 s, falling for 0.1 s: weighted by DT
+		{"time weighted", []float64{0, 10, 5}, []float64{0.3, 0.3, 0.1}, 0.75, 0.25},
+		{"no time steps", []float64{0, 10, 5, 5}, []float64{0, 0, 0, 0}, 1.0 / 3, 1.0 / 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := makeSamples(100, tt.actuals, 0.1)
+			for i := range samples {
+				if tt.dts != nil {
+					samples[i].DT = tt.dts[i]
+				}
+			}
+			m := Compute(samples, 0.02)
+			if math.Abs(m.AcceleratingFraction-tt.accel) > eps || math.Abs(m.DeceleratingFraction-tt.decel) > eps {
+				t.Errorf("accelerating/decelerating = %v/%v, want %v/%v",
+					m.AcceleratingFraction, m.DeceleratingFraction, tt.accel, tt.decel)
+			}
+			if sum := m.AcceleratingFraction + m.DeceleratingFraction; sum > 1+eps {
+				t.Errorf("fractions sum to %v, more than 1", sum)
+			}
+		})
+	}
+
+	for _, samples := range [][]experiment.Sample{nil, makeSamples(100, []float64{5}, 0.1)} {
+		if m := Compute(samples, 0.02); !math.IsNaN(m.AcceleratingFraction) || !math.IsNaN(m.DeceleratingFraction) {
+			t.Errorf("%d samples: fractions = %v/%v, want NaN", len(samples), m.AcceleratingFraction, m.DeceleratingFraction)
+		}
+	}
+}
+
+func TestMotionFractions_StepResponse(t *testing.T) {
+	samples, _, err := experiment.RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), experiment.StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := Compute(samples, 0.02)
+	flat := 1 - m.AcceleratingFraction - m.DeceleratingFraction
+	// The rise accelerates; once settled, the speed is flat for most of the run
+	if !(m.AcceleratingFraction > 0.05) || !(flat > 0.3) || flat > 1 {
+		t.Errorf("accelerating %v, decelerating %v, flat %v", m.AcceleratingFraction, m.DeceleratingFraction, flat)
+	}
+}
+
+func TestComputeWithLimits(t *testing.T) {
+	samples := makeSamples(100.0, []float64{0, 50, 100}, 0.1)
+
+	m := ComputeWithLimits(samples, 0.02, -12, 24)
+	if m.OutMin != -12 || m.OutMax != 24 {
+		t.Errorf("limits = [%v, %v], want [-12, 24]", m.OutMin, m.OutMax)
+	}
+	if base := Compute(samples, 0.02); m.IAE != base.IAE || m.OvershootPercent != base.OvershootPercent {
+		t.Errorf("ComputeWithLimits() changed the other metrics: %+v vs %+v", m, base)
+	}
+
+	if empty := ComputeWithLimits(nil, 0.02, -1, 1); empty.OutMin != -1 || empty.OutMax != 1 {
+		t.Errorf("limits for empty input = [%v, %v], want [-1, 1]", empty.OutMin, empty.OutMax)
+	}
+}
+
+func TestMetricsValues(t *testing.T) {
+	m := Metrics{Target: 1000, OvershootPercent: 2.5, SettlingTimeSeconds: 0.8, MaxControlRate: 12}
+	v := m.Values()
+
+	want := map[string]float64{
+		"target":                1000,
+		"overshoot_percent":     2.5,
+		"settling_time_seconds": 0.8,
+		"max_control_rate":      12,
+		"iae":                   0,
+	}
+	for k, w := range want {
+		if got, ok := v[k]; !ok || got != w {
+			t.Errorf("Values()[%q] = %v (present=%v), want %v", k, got, ok, w)
+		}
+	}
+	if n := reflect.TypeOf(m).NumField(); len(v) != n {
+		t.Errorf("Values() has %d entries, want one per field (%d)", len(v), n)
+	}
+}
+
+func TestMetricsMarshalJSON_NonFinite(t *testing.T) {
+	m := Metrics{Target: 1000, SettlingTimeSeconds: math.NaN(), MaxControlRate: math.Inf(1), OutMax: 24}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := got["settling_time_seconds"]; !ok || v != nil {
+		t.Errorf("settling_time_seconds = %v (present=%v), want null", v, ok)
+	}
+	if got["max_control_rate"] != nil {
+		t.Errorf("max_control_rate = %v, want null", got["max_control_rate"])
+	}
+	if got["target"] != 1000.0 || got["out_max"] != 24.0 {
+		t.Errorf("finite values = %v, %v, want 1000, 24", got["target"], got["out_max"])
+	}
+	if len(got) != len(m.Values()) {
+		t.Errorf("got %d keys, want %d", len(got), len(m.Values()))
+	}
+	if !strings.HasPrefix(string(b), `{"target":1000,"max_actual"`) {
+		t.Errorf("JSON = %s, want fields in declaration order", b)
+	}
+}
+
+func TestMinHeadroom(t *testing.T) {
+	withU := func(us ...float64) []experiment.Sample {
+		samples := makeSamples(100.0, make([]float64, len(us)), 0.1)
+		for i, u := range us {
+			samples[i].U = u
+			samples[i].Saturated = math.Abs(u) >= 24
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name           string
+		samples        []experiment.Sample
+		outMin, outMax float64
+		want           float64
+	}{
+		{"barely avoids saturation", withU(5, 23.9, 12), -24, 24, 0.1},
+		{"negative side is closest", withU(5, -23.5, 12), -24, 24, 0.5},
+		{"saturated", withU(5, 24, 24, 12), -24, 24, 0},
+		{"asymmetric limits", withU(5, -1), -2, 24, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeWithLimits(tt.samples, 0.02, tt.outMin, tt.outMax).MinHeadroom
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("MinHeadroom = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Compute(withU(5, 12), 0.02).MinHeadroom; !math.IsNaN(got) {
+		t.Errorf("MinHeadroom without limits = %v, want NaN", got)
+	}
+}
+
+func TestCompute_RingRecorderWindow(t *testing.T) {
+	// A load step late in the run: the window holds only the disturbance response
+	cfg := experiment.StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 6}
+	plant := func() *wrap.DisturbedSystem {
+		return wrap.NewDisturbedSystem(sim.NewDCMotor(), wrap.StepDisturbanceConfig{
+			Enabled: true, StartS: 4, MagnitudeRPMPerS: 200,
+		})
+	}
+	full, _, err := experiment.RunStep(plant(), pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const window = 2000 // the last 2 s
+	ring := experiment.NewRingRecorder(window)
+	if _, _, err := experiment.RunStepStreaming(plant(), pid.New(0.02, 0.05, 0), cfg, ring); err != nil {
+		t.Fatal(err)
+	}
+
+	got := Compute(ring.Samples(), 0.02)
+	want := Compute(full[len(full)-window:], 0.02)
+	for k, v := range want.Values() {
+		if !sameFloat(got.Values()[k], v) {
+			t.Errorf("window %s = %v, want %v (the last %d samples)", k, got.Values()[k], v, window)
+		}
+	}
+	// The window excludes the start-up transient
+	if whole := Compute(full, 0.02); !(got.IAE < whole.IAE/2) {
+		t.Errorf("window IAE = %v, want well below the whole run's %v", got.IAE, whole.IAE)
+	}
+	if math.Abs(got.MaxActual-1000) > 1 {
+		t.Errorf("window max actual = %v, want ≈1000 (no start-up overshoot in the window)", got.MaxActual)
+	}
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// Resample linearly interpolates samples onto a uniform grid with step newDT,
+// starting at the first sample's time and ending at or before the last one's,
+// so that runs recorded with different timesteps can be overlaid or compared
+// point by point. Samples must be ordered by time.
+//
+// Every numeric field is interpolated between the two neighboring samples, and
+// so is every signal present in both; a signal present in only the earlier
+// one is held. The flags (Saturated, Integrated) are held from the earlier
+// sample, as they have no in-between value. DT of each result is newDT.
+// Grid points that coincide with a sample reproduce it exactly.
+//
+// Resample returns nil for no samples or a newDT that is not positive and finite.
+func Resample(samples []experiment.Sample, newDT float64) []experiment.Sample {
+	if len(samples) == 0 || !(newDT > 0) || math.IsInf(newDT, 0) {
+		return nil
+	}
+
+	t0 := samples[0].T
+	span := samples[len(samples)-1].T - t0
+	// Tolerate rounding so that a grid point landing on the last sample is kept
+	n := int(math.Floor(span/newDT+1e-9)) + 1
+
+	out := make([]experiment.Sample, n)
+	j := 0 // samples[j].T <= t < samples[j+1].T
+	for k := range out {
+		// Multiply, don't accumulate, to keep the grid exact over long runs
+		t := t0 + float64(k)*newDT
+		for j+1 < len(samples) && samples[j+1].T <= t {
+			j++
+		}
+		if j+1 == len(samples) {
+			out[k] = samples[j]
+		} else {
+			a, b := samples[j], samples[j+1]
+			out[k] = lerpSample(a, b, (t-a.T)/(b.T-a.T))
+		}
+		out[k].T = t
+		out[k].DT = newDT
+	}
+	return out
+}
+
+// lerpSample interpolates a towards b by frac in [0, 1) (see Resample).
+func lerpSample(a, b experiment.Sample, frac float64) experiment.Sample {
+	lerp := func(x, y float64) float64 { return x + (y-x)*frac }
+	s := experiment.Sample{
+		Target:     lerp(a.Target, b.Target),
+		Actual:     lerp(a.Actual, b.Actual),
+		Error:      lerp(a.Error, b.Error),
+		U:          lerp(a.U, b.U),
+		P:          lerp(a.P, b.P),
+		I:          lerp(a.I, b.I),
+		D:          lerp(a.D, b.D),
+		OutRaw:     lerp(a.OutRaw, b.OutRaw),
+		Saturated:  a.Saturated,
+		Integrated: a.Integrated,
+	}
+	if len(a.Signals) > 0 {
+		s.Signals = make(map[string]float64, len(a.Signals))
+		for k, v := range a.Signals {
+			if w, ok := b.Signals[k]; ok {
+				v = lerp(v, w)
+			}
+			s.Signals[k] = v
+		}
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// rampSamples returns samples at dt over [0, 1] whose values are linear in t,
+// so linear interpolation reproduces them exactly at any time.
+func rampSamples(dt float64) []experiment.Sample {
+	n := int(math.Round(1/dt)) + 1
+	samples := make([]experiment.Sample, n)
+	for i := range samples {
+		t := float64(i) * dt
+		samples[i] = experiment.Sample{
+			T: t, DT: dt,
+			Target: 100, Actual: 100 * t, Error: 100 - 100*t,
+			U: 2 + 3*t, P: t, I: -t, D: 0.5, OutRaw: 2 + 4*t,
+			Saturated: t >= 0.5,
+			Signals:   map[string]float64{"load": 10 * t},
+		}
+	}
+	return samples
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  float64
+		to    float64
+		wantN int
+	}{
+		{"finer", 0.1, 0.025, 41},
+		{"coarser", 0.1, 0.2, 6},
+		{"same", 0.1, 0.1, 11},
+		{"not a divisor", 0.1, 0.3, 4}, // 0, 0.3, 0.6, 0.9
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resample(rampSamples(tt.from), tt.to)
+			if len(got) != tt.wantN {
+				t.Fatalf("got %d samples, want %d", len(got), tt.wantN)
+			}
+			for k, s := range got {
+				ts := float64(k) * tt.to
+				if s.T != ts || s.DT != tt.to {
+					t.Fatalf("sample %d at T=%v DT=%v, want T=%v DT=%v", k, s.T, s.DT, ts, tt.to)
+				}
+				want := map[string][2]float64{
+					"actual":  {s.Actual, 100 * ts},
+					"error":   {s.Error, 100 - 100*ts},
+					"u":       {s.U, 2 + 3*ts},
+					"p":       {s.P, ts},
+					"i":       {s.I, -ts},
+					"d":       {s.D, 0.5},
+					"out_raw": {s.OutRaw, 2 + 4*ts},
+					"load":    {s.Signals["load"], 10 * ts},
+				}
+				for name, v := range want {
+					if math.Abs(v[0]-v[1]) > eps {
+						t.Errorf("sample %d (t=%v): %s = %v, want %v", k, ts, name, v[0], v[1])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestResample_CoincidentPointsAreExact(t *testing.T) {
+	// A nonlinear series: at the original sample times the values must be
+	// the recorded ones, not an approximation
+	samples := make([]experiment.Sample, 6)
+	for i := range samples {
+		ti := float64(i) * 0.2
+		samples[i] = experiment.Sample{T: ti, DT: 0.2, Actual: math.Sin(3 * ti)}
+	}
+	got := Resample(samples, 0.1)
+	for i, s := range samples {
+		if r := got[2*i]; r.Actual != s.Actual {
+			t.Errorf("t=%v: actual = %v, want the recorded %v", s.T, r.Actual, s.Actual)
+		}
+	}
+	// In between: the chord, not the sine
+	mid := (samples[1].Actual + samples[2].Actual) / 2
+	if r := got[3]; math.Abs(r.Actual-mid) > eps {
+		t.Errorf("t=0.3: actual = %v, want the midpoint %v", r.Actual, mid)
+	}
+}
+
+func TestResample_FlagsAndSignalsHeld(t *testing.T) {
+	samples := []experiment.Sample{
+		{T: 0, Saturated: true, Signals: map[string]float64{"both": 0, "early": 5}},
+		{T: 1, Integrated: true, Signals: map[string]float64{"both": 10, "late": 7}},
+	}
+	got := Resample(samples, 0.5)
+	if len(got) != 3 {
+		t.Fatalf("got %d samples, want 3", len(got))
+	}
+	mid := got[1]
+	if !mid.Saturated || mid.Integrated {
+		t.Errorf("mid flags = saturated %v integrated %v, want the earlier sample's", mid.Saturated, mid.Integrated)
+	}
+	if mid.Signals["both"] != 5 || mid.Signals["early"] != 5 {
+		t.Errorf("mid signals = %v, want both interpolated to 5 and early held at 5", mid.Signals)
+	}
+	if _, ok := mid.Signals["late"]; ok {
+		t.Errorf("mid signals = %v, want no value for a signal that starts later", mid.Signals)
+	}
+	if last := got[2]; !last.Integrated || last.Signals["late"] != 7 {
+		t.Errorf("last = %+v, want the last sample", last)
+	}
+}
+
+func TestResample_Invalid(t *testing.T) {
+	for _, dt := range []float64{0, -0.1, math.NaN(), math.Inf(1)} {
+		if got := Resample(rampSamples(0.1), dt); got != nil {
+			t.Errorf("Resample(dt=%v) = %d samples, want nil", dt, len(got))
+		}
+	}
+	if got := Resample(nil, 0.1); got != nil {
+		t.Errorf("Resample(nil) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,80 @@
+package artifacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+var bodeHeader = []string{"freq_hz", "magnitude_db", "phase_deg"}
+
+// WriteBodeCSV writes a frequency response as freq_hz,magnitude_db,phase_deg.
+func WriteBodeCSV(path string, fr []analysis.FrequencyPoint) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close() // Error on close is non-fatal for CSV writing - file is already written
+	}()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	if err := w.Write(bodeHeader); err != nil {
+		return err
+	}
+	for _, p := range fr {
+		rec := []string{
+			fmt.Sprintf("%.6f", p.FreqHz),
+			fmt.Sprintf("%.6f", p.MagnitudeDB),
+			fmt.Sprintf("%.6f", p.PhaseDeg),
+		}
+		if err := w.Write(rec); err != nil {
+			return err
+		}
+	}
+
+	return w.Error()
+}
+
+// ReadBodeCSV reads a frequency response written by WriteBodeCSV.
+func ReadBodeCSV(path string) ([]analysis.FrequencyPoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s: missing header", path)
+	}
+	for i, col := range bodeHeader {
+		if i >= len(records[0]) || records[0][i] != col {
+			return nil, fmt.Errorf("%s: unexpected header %v", path, records[0])
+		}
+	}
+
+	fr := make([]analysis.FrequencyPoint, 0, len(records)-1)
+	for row, rec := range records[1:] {
+		var vals [3]float64
+		for i := range vals {
+			v, err := strconv.ParseFloat(rec[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: row %d: %w", path, row+1, err)
+			}
+			vals[i] = v
+		}
+		fr = append(fr, analysis.FrequencyPoint{FreqHz: vals[0], MagnitudeDB: vals[1], PhaseDeg: vals[2]})
+	}
+	return fr, nil
+}
@@ -0,0 +1,64 @@
+package artifacts
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+func TestBodeCSVRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bode.csv")
+
+	fr := []analysis.FrequencyPoint{
+		{FreqHz: 0.1, MagnitudeDB: -0.017, PhaseDeg: -17.44},
+		{FreqHz: 1.0, MagnitudeDB: -3.0103, PhaseDeg: -45.0},
+		{FreqHz: 10.0, MagnitudeDB: -20.043, PhaseDeg: -84.29},
+	}
+
+	if err := WriteBodeCSV(path, fr); err != nil {
+		t.Fatalf("WriteBodeCSV() error = %v", err)
+	}
+
+	got, err := ReadBodeCSV(path)
+	if err != nil {
+		t.Fatalf("ReadBodeCSV() error = %v", err)
+	}
+	if len(got) != len(fr) {
+		t.Fatalf("read %d points, want %d", len(got), len(fr))
+	}
+	for i := range fr {
+		if math.Abs(got[i].FreqHz-fr[i].FreqHz) > 1e-6 ||
+			math.Abs(got[i].MagnitudeDB-fr[i].MagnitudeDB) > 1e-6 ||
+			math.Abs(got[i].PhaseDeg-fr[i].PhaseDeg) > 1e-6 {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], fr[i])
+		}
+	}
+}
+
+func TestBodeCSVEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bode.csv")
+
+	if err := WriteBodeCSV(path, nil); err != nil {
+		t.Fatalf("WriteBodeCSV() error = %v", err)
+	}
+	got, err := ReadBodeCSV(path)
+	if err != nil {
+		t.Fatalf("ReadBodeCSV() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("read %d points, want 0", len(got))
+	}
+}
+
+func TestReadBodeCSV_BadHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bode.csv")
+	if err := os.WriteFile(path, []byte("t,actual\n0,1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBodeCSV(path); err == nil {
+		t.Error("ReadBodeCSV() should reject an unexpected header")
+	}
+}
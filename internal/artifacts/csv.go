@@ -3,16 +3,51 @@ package artifacts
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
 )
 
+// CSVOptions controls optional samples.csv output features.
+type CSVOptions struct {
+	// SignalKeys declares signal columns that are always written, even if no
+	// sample carries them (see system.SignalDeclarer). Keys discovered in the
+	// samples are still included.
+	SignalKeys []string
+
+	// MarkAbsent writes an empty cell when a signal is absent from a sample's
+	// Signals map, instead of 0, so absence and a genuine zero stay distinguishable.
+	// ReadSamplesCSV reconstructs absence from empty cells.
+	MarkAbsent bool
+
+	// Comment, if non-empty, is written before the header as '#'-prefixed lines,
+	// one per line of Comment (e.g., the controller gains, limits and dt), making
+	// the file self-describing. It is off by default because strict CSV has no
+	// comment convention; ReadSamplesCSV skips such lines.
+	Comment string
+
+	// UnitsRow writes a second header row with the unit of each column (e.g.,
+	// "s,s,rpm,rpm,rpm,v,..."), from DefaultUnits overridden by Units; columns
+	// without a known unit get an empty cell. It is off by default because strict
+	// CSV has a single header row; ReadSamplesCSV skips the units row.
+	UnitsRow bool
+	// Units adds or overrides entries of DefaultUnits for the units row, e.g.
+	// for custom signals or position-mode columns.
+	Units map[string]string
+}
+
 // WriteSamplesCSV writes the time series to samples.csv inside the run directory.
 // Signal columns are included in deterministic lexicographic order.
 func (r *RunDir) WriteSamplesCSV(samples []experiment.Sample) error {
+	return r.WriteSamplesCSVWith(samples, CSVOptions{})
+}
+
+// WriteSamplesCSVWith is like WriteSamplesCSV with optional output features.
+func (r *RunDir) WriteSamplesCSVWith(samples []experiment.Sample, opts CSVOptions) error {
 	f, err := os.Create(filepath.Join(r.Dir, "samples.csv"))
 	if err != nil {
 		return err
@@ -21,68 +56,126 @@ func (r *RunDir) WriteSamplesCSV(samples []experiment.Sample) error {
 		_ = f.Close() // Error on close is non-fatal for CSV writing - file is already written
 	}()
 
+	if err := writeCSVComment(f, opts.Comment); err != nil {
+		return err
+	}
+
 	w := csv.NewWriter(f)
 	defer w.Flush()
 
-	// Gather all signal keys from all samples
-	signalKeysSet := make(map[string]bool)
-	for _, s := range samples {
-		if s.Signals != nil {
-			for k := range s.Signals {
-				signalKeysSet[k] = true
-			}
-		}
-	}
-
-	// Convert to sorted slice for deterministic ordering
-	var signalKeys []string
-	if len(signalKeysSet) > 0 {
-		signalKeys = make([]string, 0, len(signalKeysSet))
-		for k := range signalKeysSet {
-			signalKeys = append(signalKeys, k)
-		}
-		sort.Strings(signalKeys)
-	}
+	signalKeys := collectSignalKeys(samples, opts.SignalKeys...)
 
-	// Build header: base fields first, then signal keys
-	header := []string{"t", "dt", "target", "actual", "error", "u", "p", "i", "d", "out_raw", "saturated", "integrated"}
-	header = append(header, signalKeys...)
+	header := samplesHeader(signalKeys)
 	if err := w.Write(header); err != nil {
 		return err
 	}
+	if opts.UnitsRow {
+		if err := w.Write(unitsRecord(header, opts.Units)); err != nil {
+			return err
+		}
+	}
 
 	// Write data rows
 	for _, s := range samples {
-		rec := []string{
-			fmt.Sprintf("%.6f", s.T),
-			fmt.Sprintf("%.6f", s.DT),
-			fmt.Sprintf("%.6f", s.Target),
-			fmt.Sprintf("%.6f", s.Actual),
-			fmt.Sprintf("%.6f", s.Error),
-			fmt.Sprintf("%.6f", s.U),
-			fmt.Sprintf("%.6f", s.P),
-			fmt.Sprintf("%.6f", s.I),
-			fmt.Sprintf("%.6f", s.D),
-			fmt.Sprintf("%.6f", s.OutRaw),
-			fmt.Sprintf("%t", s.Saturated),
-			fmt.Sprintf("%t", s.Integrated),
+		if err := w.Write(sampleRecord(s, signalKeys, opts.MarkAbsent)); err != nil {
+			return err
 		}
+	}
 
-		// Append signal values in sorted key order
-		for _, key := range signalKeys {
-			val := 0.0
-			if s.Signals != nil {
-				if v, ok := s.Signals[key]; ok {
-					val = v
-				}
-			}
-			rec = append(rec, fmt.Sprintf("%.6f", val))
-		}
+	return w.Error()
+}
 
-		if err := w.Write(rec); err != nil {
+// writeCSVComment writes comment as '#'-prefixed lines; an empty comment writes nothing.
+func writeCSVComment(w io.Writer, comment string) error {
+	if comment == "" {
+		return nil
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if _, err := fmt.Fprintf(w, "# %s\n", line); err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	return w.Error()
+// baseColumns are the fixed sample columns, followed by any signal columns.
+var baseColumns = []string{"t", "dt", "target", "actual", "error", "u", "p", "i", "d", "out_raw", "saturated", "integrated"}
+
+// collectSignalKeys gathers the declared keys and all signal keys from all samples in sorted order.
+func collectSignalKeys(samples []experiment.Sample, declared ...string) []string {
+	signalKeysSet := make(map[string]bool)
+	for _, k := range declared {
+		signalKeysSet[k] = true
+	}
+	for _, s := range samples {
+		for k := range s.Signals {
+			signalKeysSet[k] = true
+		}
+	}
+	return sortedKeys(signalKeysSet)
+}
+
+// sortedKeys converts a key set to a sorted slice for deterministic ordering.
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// samplesHeader builds the header: base fields first, then signal keys.
+func samplesHeader(signalKeys []string) []string {
+	header := make([]string, 0, len(baseColumns)+len(signalKeys))
+	header = append(header, baseColumns...)
+	return append(header, signalKeys...)
+}
+
+// unitsRecord returns the unit of each header column: DefaultUnits overridden
+// by overrides, or an empty cell if the column has no known unit.
+func unitsRecord(header []string, overrides map[string]string) []string {
+	units := DefaultUnits()
+	for k, u := range overrides {
+		units[k] = u
+	}
+	rec := make([]string, len(header))
+	for i, name := range header {
+		rec[i] = units[name]
+	}
+	return rec
+}
+
+// sampleRecord formats one sample as a CSV row with signal values in signalKeys order.
+// A signal missing from the sample is written as 0, or as an empty cell if markAbsent is set.
+func sampleRecord(s experiment.Sample, signalKeys []string, markAbsent bool) []string {
+	rec := make([]string, 0, len(baseColumns)+len(signalKeys))
+	rec = append(rec,
+		fmt.Sprintf("%.6f", s.T),
+		fmt.Sprintf("%.6f", s.DT),
+		fmt.Sprintf("%.6f", s.Target),
+		fmt.Sprintf("%.6f", s.Actual),
+		fmt.Sprintf("%.6f", s.Error),
+		fmt.Sprintf("%.6f", s.U),
+		fmt.Sprintf("%.6f", s.P),
+		fmt.Sprintf("%.6f", s.I),
+		fmt.Sprintf("%.6f", s.D),
+		fmt.Sprintf("%.6f", s.OutRaw),
+		fmt.Sprintf("%t", s.Saturated),
+		fmt.Sprintf("%t", s.Integrated),
+	)
+
+	// Append signal values in sorted key order
+	for _, key := range signalKeys {
+		v, ok := s.Signals[key]
+		if !ok && markAbsent {
+			rec = append(rec, "")
+			continue
+		}
+		rec = append(rec, fmt.Sprintf("%.6f", v))
+	}
+	return rec
 }
@@ -0,0 +1,245 @@
+package artifacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// ReadSamplesCSV reads a samples.csv file written by WriteSamplesCSV.
+// See ReadSamples for the accepted format.
+func ReadSamplesCSV(path string) ([]experiment.Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	samples, err := ReadSamples(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return samples, nil
+}
+
+// ReadSamples parses samples in the samples.csv format.
+//
+// Base columns are located by header name, so their order does not matter; every
+// other column is read as a signal. An empty signal cell (see CSVOptions.MarkAbsent)
+// means the signal was absent from that sample and no key is set. Samples without
+// any signal value get a nil Signals map. Lines starting with '#' (see
+// CSVOptions.Comment) are skipped, and so is a units row (see
+// CSVOptions.UnitsRow): a first row none of whose base cells holds a value.
+func ReadSamples(r io.Reader) ([]experiment.Sample, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("missing header")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	base := make(map[string]int, len(baseColumns))
+	var signalCols []int
+	for i, name := range header {
+		if isBaseColumn(name) {
+			base[name] = i
+		} else {
+			signalCols = append(signalCols, i)
+		}
+	}
+	for _, name := range baseColumns {
+		if _, ok := base[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+
+	var samples []experiment.Sample
+	first := true
+	for row := 1; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			first = false
+			if isUnitsRow(rec, base) {
+				row-- // rows count samples
+				continue
+			}
+		}
+
+		p := recordParser{rec: rec, row: row, header: header}
+		s := experiment.Sample{
+			T:          p.float(base["t"]),
+			DT:         p.float(base["dt"]),
+			Target:     p.float(base["target"]),
+			Actual:     p.float(base["actual"]),
+			Error:      p.float(base["error"]),
+			U:          p.float(base["u"]),
+			P:          p.float(base["p"]),
+			I:          p.float(base["i"]),
+			D:          p.float(base["d"]),
+			OutRaw:     p.float(base["out_raw"]),
+			Saturated:  p.bool(base["saturated"]),
+			Integrated: p.bool(base["integrated"]),
+		}
+		for _, col := range signalCols {
+			if rec[col] == "" {
+				continue
+			}
+			if s.Signals == nil {
+				s.Signals = make(map[string]float64, len(signalCols))
+			}
+			s.Signals[header[col]] = p.float(col)
+		}
+		if p.err != nil {
+			return nil, p.err
+		}
+		samples = append(samples, s)
+	}
+
+	return samples, nil
+}
+
+// isUnitsRow reports whether rec is a units row rather than a sample: none of
+// its base cells (at the columns in base) parses as a number or a bool, so a
+// sample with a single malformed cell is still reported as an error.
+func isUnitsRow(rec []string, base map[string]int) bool {
+	for _, col := range base {
+		if _, err := strconv.ParseFloat(rec[col], 64); err == nil {
+			return false
+		}
+		if _, err := strconv.ParseBool(rec[col]); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func isBaseColumn(name string) bool {
+	for _, c := range baseColumns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recordParser parses cells of one CSV record, keeping the first error.
+type recordParser struct {
+	rec    []string
+	row    int
+	header []string
+	err    error
+}
+
+func (p *recordParser) float(col int) float64 {
+	v, err := strconv.ParseFloat(p.rec[col], 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("row %d, column %q: %w", p.row, p.header[col], err)
+	}
+	return v
+}
+
+func (p *recordParser) bool(col int) bool {
+	v, err := strconv.ParseBool(p.rec[col])
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("row %d, column %q: %w", p.row, p.header[col], err)
+	}
+	return v
+}
+
+// ReadSamplesColumn reads the named column (see ColumnValue) of a file in the
+// samples.csv format, e.g. the actual column of a hardware recording to play
+// back with a wrap.ReplaySystem. It fails if a row has no value for the column.
+func ReadSamplesColumn(path, name string) ([]float64, error) {
+	samples, err := ReadSamplesCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	series := make([]float64, len(samples))
+	for i, s := range samples {
+		v, ok := ColumnValue(s, name)
+		if !ok {
+			return nil, fmt.Errorf("%s: row %d has no %q value", path, i+1, name)
+		}
+		series[i] = v
+	}
+	return series, nil
+}
+
+// ReadSamplesHeader returns the column names of a file in the samples.csv
+// format: the base columns and any signal columns, in file order.
+func ReadSamplesHeader(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	cr := csv.NewReader(f)
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s: missing header", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return header, nil
+}
+
+// ColumnValue returns the value of s in the samples.csv column name, with
+// saturated and integrated as 1 or 0. It reports false for a signal s does
+// not carry (an empty cell in the file).
+func ColumnValue(s experiment.Sample, name string) (float64, bool) {
+	switch name {
+	case "t":
+		return s.T, true
+	case "dt":
+		return s.DT, true
+	case "target":
+		return s.Target, true
+	case "actual":
+		return s.Actual, true
+	case "error":
+		return s.Error, true
+	case "u":
+		return s.U, true
+	case "p":
+		return s.P, true
+	case "i":
+		return s.I, true
+	case "d":
+		return s.D, true
+	case "out_raw":
+		return s.OutRaw, true
+	case "saturated":
+		return boolValue(s.Saturated), true
+	case "integrated":
+		return boolValue(s.Integrated), true
+	}
+	v, ok := s.Signals[name]
+	return v, ok
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
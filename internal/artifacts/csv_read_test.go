@@ -0,0 +1,360 @@
+package artifacts
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+func TestReadSamplesCSV_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	runDir := RunDir{Dir: dir}
+
+	samples := []experiment.Sample{
+		{T: 0.0, DT: 0.001, Target: 1000.0, Actual: 0.0, Error: 1000.0, U: 24.0, P: 20.0, I: 5.0, OutRaw: 25.0, Saturated: true, Integrated: false},
+		{T: 0.001, DT: 0.001, Target: 1000.0, Actual: 4.8, Error: 995.2, U: 19.9, P: 19.9, D: -0.1, OutRaw: 19.9, Integrated: true},
+	}
+	if err := runDir.WriteSamplesCSV(samples); err != nil {
+		t.Fatalf("WriteSamplesCSV() error = %v", err)
+	}
+
+	got, err := ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+	if err != nil {
+		t.Fatalf("ReadSamplesCSV() error = %v", err)
+	}
+	if len(got) != len(samples) {
+		t.Fatalf("read %d samples, want %d", len(got), len(samples))
+	}
+	for i := range samples {
+		want := samples[i]
+		g := got[i]
+		if math.Abs(g.T-want.T) > 1e-6 || math.Abs(g.Actual-want.Actual) > 1e-6 || math.Abs(g.U-want.U) > 1e-6 ||
+			math.Abs(g.D-want.D) > 1e-6 || g.Saturated != want.Saturated || g.Integrated != want.Integrated {
+			t.Errorf("sample %d = %+v, want %+v", i, g, want)
+		}
+		if g.Signals != nil {
+			t.Errorf("sample %d: Signals = %v, want nil", i, g.Signals)
+		}
+	}
+}
+
+func TestReadSamplesCSV_AbsentVersusZero(t *testing.T) {
+	samples := []experiment.Sample{
+		{T: 0.0, DT: 0.1, Signals: map[string]float64{"a": 0.0, "b": 1.5}}, // a genuinely zero
+		{T: 0.1, DT: 0.1, Signals: map[string]float64{"b": 2.5}},           // a absent
+		{T: 0.2, DT: 0.1}, // all absent
+	}
+
+	tests := []struct {
+		name       string
+		markAbsent bool
+		wantA      []bool // whether "a" is present in each read sample
+		wantNil    []bool // whether Signals is nil
+	}{
+		{name: "mark absent", markAbsent: true, wantA: []bool{true, false, false}, wantNil: []bool{false, false, true}},
+		{name: "absent written as zero", markAbsent: false, wantA: []bool{true, true, true}, wantNil: []bool{false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			runDir := RunDir{Dir: dir}
+			if err := runDir.WriteSamplesCSVWith(samples, CSVOptions{MarkAbsent: tt.markAbsent}); err != nil {
+				t.Fatalf("WriteSamplesCSVWith() error = %v", err)
+			}
+
+			got, err := ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+			if err != nil {
+				t.Fatalf("ReadSamplesCSV() error = %v", err)
+			}
+			for i, s := range got {
+				v, ok := s.Signals["a"]
+				if ok != tt.wantA[i] {
+					t.Errorf("sample %d: a present = %v, want %v", i, ok, tt.wantA[i])
+				}
+				if ok && v != 0 {
+					t.Errorf("sample %d: a = %v, want 0", i, v)
+				}
+				if (s.Signals == nil) != tt.wantNil[i] {
+					t.Errorf("sample %d: Signals = %v, want nil=%v", i, s.Signals, tt.wantNil[i])
+				}
+			}
+			if got[1].Signals["b"] != 2.5 {
+				t.Errorf("sample 1: b = %v, want 2.5", got[1].Signals["b"])
+			}
+		})
+	}
+}
+
+func TestReadSamples_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "missing column", input: "t,dt\n0,0.1\n"},
+		{name: "bad number", input: strings.Join(baseColumns, ",") + "\nx,0,0,0,0,0,0,0,0,0,false,true\n"},
+		{name: "bad bool", input: strings.Join(baseColumns, ",") + "\n0,0,0,0,0,0,0,0,0,0,maybe,true\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadSamples(strings.NewReader(tt.input)); err == nil {
+				t.Error("ReadSamples() should fail")
+			}
+		})
+	}
+}
+
+func TestSamplesCSV_Comment(t *testing.T) {
+	samples := []experiment.Sample{
+		{T: 0.0, DT: 0.01, Target: 100, Actual: 0, U: 2},
+		{T: 0.01, DT: 0.01, Target: 100, Actual: 1.5, U: 1.9},
+	}
+	comment := "kp=0.02 ki=0.05 kd=0\nout_min_v=-24 out_max_v=24 dt_s=0.01"
+
+	tests := []struct {
+		name      string
+		comment   string
+		wantFirst string
+	}{
+		{"default off", "", "t,dt,target"},
+		{"with comment", comment, "# kp=0.02 ki=0.05 kd=0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			runDir := RunDir{Dir: dir}
+			if err := runDir.WriteSamplesCSVWith(samples, CSVOptions{Comment: tt.comment}); err != nil {
+				t.Fatalf("WriteSamplesCSVWith() error = %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "samples.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(data), "\n")
+			if !strings.HasPrefix(lines[0], tt.wantFirst) {
+				t.Errorf("first line = %q, want prefix %q", lines[0], tt.wantFirst)
+			}
+			if tt.comment != "" && lines[1] != "# out_min_v=-24 out_max_v=24 dt_s=0.01" {
+				t.Errorf("second line = %q, want the second comment line", lines[1])
+			}
+
+			got, err := ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+			if err != nil {
+				t.Fatalf("ReadSamplesCSV() error = %v", err)
+			}
+			if len(got) != len(samples) || math.Abs(got[1].Actual-1.5) > 1e-6 {
+				t.Errorf("read %+v, want the written samples", got)
+			}
+		})
+	}
+}
+
+func TestSamplesCSV_UnitsRow(t *testing.T) {
+	samples := []experiment.Sample{
+		{T: 0.0, DT: 0.01, Target: 100, Actual: 0, U: 2, Signals: map[string]float64{"velocity_rpm": 0, "custom": 1}},
+		{T: 0.01, DT: 0.01, Target: 100, Actual: 1.5, U: 1.9, Signals: map[string]float64{"velocity_rpm": 3, "custom": 2}},
+	}
+
+	tests := []struct {
+		name      string
+		opts      CSVOptions
+		wantUnits string // second line; empty for none
+	}{
+		{"default off", CSVOptions{}, ""},
+		{"units row", CSVOptions{UnitsRow: true},
+			"s,s,rpm,rpm,rpm,v,v,v,v,v,bool,bool,,rpm"},
+		{"with overrides", CSVOptions{UnitsRow: true, Units: map[string]string{"target": "rev", "custom": "n*m"}},
+			"s,s,rev,rpm,rpm,v,v,v,v,v,bool,bool,n*m,rpm"},
+		{"after a comment", CSVOptions{UnitsRow: true, Comment: "kp=0.1"},
+			"s,s,rpm,rpm,rpm,v,v,v,v,v,bool,bool,,rpm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			runDir := RunDir{Dir: dir}
+			if err := runDir.WriteSamplesCSVWith(samples, tt.opts); err != nil {
+				t.Fatalf("WriteSamplesCSVWith() error = %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "samples.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(string(data), "\n")
+			if tt.opts.Comment != "" {
+				lines = lines[1:]
+			}
+			if lines[0] != "t,dt,target,actual,error,u,p,i,d,out_raw,saturated,integrated,custom,velocity_rpm" {
+				t.Errorf("header = %q", lines[0])
+			}
+			if tt.wantUnits != "" && lines[1] != tt.wantUnits {
+				t.Errorf("units row = %q, want %q", lines[1], tt.wantUnits)
+			}
+			if tt.wantUnits == "" && !strings.HasPrefix(lines[1], "0.000000,") {
+				t.Errorf("second line = %q, want the first sample", lines[1])
+			}
+
+			got, err := ReadSamplesCSV(filepath.Join(dir, "samples.csv"))
+			if err != nil {
+				t.Fatalf("ReadSamplesCSV() error = %v", err)
+			}
+			if len(got) != len(samples) || got[0].T != 0 || math.Abs(got[1].Actual-1.5) > 1e-6 || got[1].Signals["custom"] != 2 {
+				t.Errorf("read %+v, want the written samples", got)
+			}
+		})
+	}
+}
+
+func TestReadSamples_OnlyFirstRowMayHoldUnits(t *testing.T) {
+	header := "t,dt,target,actual,error,u,p,i,d,out_raw,saturated,integrated\n"
+	units := "s,s,rpm,rpm,rpm,v,v,v,v,v,bool,bool\n"
+	row := "0.1,0.1,0,0,0,0,0,0,0,0,false,false\n"
+
+	if got, err := ReadSamples(strings.NewReader(header + units + units + row)); err == nil || !strings.Contains(err.Error(), "row 1") {
+		t.Errorf("two units rows: got %d samples, err = %v; want an error at row 1", len(got), err)
+	}
+	if _, err := ReadSamples(strings.NewReader(header + row + units)); err == nil {
+		t.Error("units row after a sample: want an error")
+	}
+}
+
+func TestCSVSink_UnitsRow(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewCSVSink(&buf, CSVOptions{UnitsRow: true})
+	if err := sink.Write(experiment.Sample{T: 0, DT: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[1] != "s,s,rpm,rpm,rpm,v,v,v,v,v,bool,bool" {
+		t.Errorf("units row = %q", lines[1])
+	}
+	got, err := ReadSamples(&buf)
+	if err != nil || len(got) != 1 {
+		t.Errorf("ReadSamples() = %d samples, err %v; want 1 sample", len(got), err)
+	}
+}
+
+func TestCSVSink_Comment(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewCSVSink(&buf, CSVOptions{Comment: "kp=0.1"})
+	if err := sink.Write(experiment.Sample{T: 0, DT: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "# kp=0.1\nt,dt,") {
+		t.Errorf("sink output starts with %q, want comment then header", buf.String()[:20])
+	}
+	got, err := ReadSamples(&buf)
+	if err != nil || len(got) != 1 {
+		t.Errorf("ReadSamples() = %d samples, err %v; want 1 sample", len(got), err)
+	}
+}
+
+func TestReadSamplesColumn_Replay(t *testing.T) {
+	// Record a run, then replay its measurements through the same loop
+	cfg := experiment.StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 2}
+	recorded, _, err := experiment.RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	runDir := RunDir{Dir: dir}
+	if err := runDir.WriteSamplesCSV(recorded); err != nil {
+		t.Fatal(err)
+	}
+
+	series, err := ReadSamplesColumn(filepath.Join(dir, "samples.csv"), "actual")
+	if err != nil {
+		t.Fatalf("ReadSamplesColumn() error = %v", err)
+	}
+	sys := wrap.NewReplaySystem(series)
+	replayed, _, err := experiment.RunStep(sys, pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != len(recorded) {
+		t.Fatalf("replayed %d samples, want %d", len(replayed), len(recorded))
+	}
+	commands := sys.Commands()
+	for i := range recorded {
+		// The CSV keeps 6 decimals, which the controller's gains barely amplify
+		if math.Abs(replayed[i].Actual-recorded[i].Actual) > 1e-6 || math.Abs(replayed[i].U-recorded[i].U) > 1e-4 {
+			t.Fatalf("sample %d: actual %v, u %v; recorded %v, %v",
+				i, replayed[i].Actual, replayed[i].U, recorded[i].Actual, recorded[i].U)
+		}
+		if commands[i] != replayed[i].U {
+			t.Fatalf("command %d = %v, want the sample's u %v", i, commands[i], replayed[i].U)
+		}
+	}
+	if !sys.Exhausted() {
+		t.Error("the run should consume the whole series")
+	}
+
+	if _, err := ReadSamplesColumn(filepath.Join(dir, "samples.csv"), "no_such_signal"); err == nil {
+		t.Error("ReadSamplesColumn() of an unknown column should fail")
+	}
+	if _, err := ReadSamplesColumn(filepath.Join(dir, "missing.csv"), "actual"); err == nil {
+		t.Error("ReadSamplesColumn() of a missing file should fail")
+	}
+}
+
+func TestReadSamplesHeader_AndColumnValue(t *testing.T) {
+	dir := t.TempDir()
+	runDir := RunDir{Dir: dir}
+	samples := []experiment.Sample{
+		{T: 0.5, DT: 0.1, Target: 1000, Actual: 900, Error: 100, U: 12, P: 2, I: 9, D: 1, OutRaw: 13, Saturated: true,
+			Signals: map[string]float64{"load": 3}},
+		{T: 0.6, DT: 0.1, Integrated: true},
+	}
+	if err := runDir.WriteSamplesCSVWith(samples, CSVOptions{Comment: "recorded", MarkAbsent: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := ReadSamplesHeader(filepath.Join(dir, "samples.csv"))
+	if err != nil {
+		t.Fatalf("ReadSamplesHeader() error = %v", err)
+	}
+	if want := append(append([]string{}, baseColumns...), "load"); strings.Join(header, ",") != strings.Join(want, ",") {
+		t.Errorf("header = %v, want %v", header, want)
+	}
+
+	wants := map[string]float64{
+		"t": 0.5, "dt": 0.1, "target": 1000, "actual": 900, "error": 100, "u": 12, "p": 2, "i": 9, "d": 1,
+		"out_raw": 13, "saturated": 1, "integrated": 0, "load": 3,
+	}
+	for _, col := range header {
+		if v, ok := ColumnValue(samples[0], col); !ok || v != wants[col] {
+			t.Errorf("ColumnValue(%q) = %v, %v, want %v", col, v, ok, wants[col])
+		}
+	}
+	if v, ok := ColumnValue(samples[1], "integrated"); !ok || v != 1 {
+		t.Errorf("ColumnValue(integrated) = %v, %v, want 1", v, ok)
+	}
+	if _, ok := ColumnValue(samples[1], "load"); ok {
+		t.Error("ColumnValue() of an absent signal should report false")
+	}
+
+	if _, err := ReadSamplesHeader(filepath.Join(dir, "missing.csv")); !os.IsNotExist(err) {
+		t.Errorf("ReadSamplesHeader() of a missing file = %v, want not exist", err)
+	}
+}
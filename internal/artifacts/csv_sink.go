@@ -0,0 +1,117 @@
+package artifacts
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// CSVSink is an experiment.SampleSink that writes samples.csv rows as samples
+// are produced, in the same format as WriteSamplesCSV.
+//
+// Because the header must be written before any row, signal columns are fixed up
+// front: either from the declared opts.SignalKeys, or, if none are declared, from
+// the keys of the first sample. Signals that only appear later are not written.
+type CSVSink struct {
+	raw    io.Writer // for the comment, written before any CSV output
+	w      *csv.Writer
+	closer io.Closer
+	opts   CSVOptions
+
+	signalKeys    []string
+	declared      bool
+	headerWritten bool
+}
+
+var _ experiment.SampleSink = (*CSVSink)(nil)
+
+// NewCSVSink returns a sink writing to w. Closing the sink flushes the CSV writer;
+// w itself is not closed.
+func NewCSVSink(w io.Writer, opts CSVOptions) *CSVSink {
+	return newCSVSink(w, nil, opts)
+}
+
+// SamplesCSVSink creates samples.csv inside the run directory and returns a sink
+// writing to it. Closing the sink closes the file.
+func (r *RunDir) SamplesCSVSink(opts CSVOptions) (*CSVSink, error) {
+	f, err := os.Create(filepath.Join(r.Dir, "samples.csv"))
+	if err != nil {
+		return nil, err
+	}
+	return newCSVSink(f, f, opts), nil
+}
+
+func newCSVSink(w io.Writer, closer io.Closer, opts CSVOptions) *CSVSink {
+	keys := append([]string(nil), opts.SignalKeys...)
+	sort.Strings(keys)
+	return &CSVSink{
+		raw:        w,
+		w:          csv.NewWriter(w),
+		closer:     closer,
+		opts:       opts,
+		signalKeys: keys,
+		declared:   opts.SignalKeys != nil,
+	}
+}
+
+// Write writes one sample, emitting the header first if needed.
+func (c *CSVSink) Write(s experiment.Sample) error {
+	if !c.headerWritten {
+		if !c.declared {
+			c.signalKeys = collectSignalKeys([]experiment.Sample{s})
+		}
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return c.w.Write(sampleRecord(s, c.signalKeys, c.opts.MarkAbsent))
+}
+
+// Flush writes the rows buffered so far to the underlying writer, so that a
+// reader following the file (e.g., tail -f) sees them while the run goes on.
+func (c *CSVSink) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close flushes buffered rows and closes the underlying file, if the sink owns one.
+// A sink that received no samples writes the header only.
+func (c *CSVSink) Close() error {
+	if !c.headerWritten {
+		if err := c.writeHeader(); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	err := c.w.Error()
+	if c.closer != nil {
+		if cerr := c.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// writeHeader writes the optional comment, the header row and the optional
+// units row. Nothing has been buffered in the CSV writer yet, so writing the
+// comment directly keeps the order.
+func (c *CSVSink) writeHeader() error {
+	if err := writeCSVComment(c.raw, c.opts.Comment); err != nil {
+		return err
+	}
+	header := samplesHeader(c.signalKeys)
+	if err := c.w.Write(header); err != nil {
+		return err
+	}
+	if c.opts.UnitsRow {
+		if err := c.w.Write(unitsRecord(header, c.opts.Units)); err != nil {
+			return err
+		}
+	}
+	c.headerWritten = true
+	return nil
+}
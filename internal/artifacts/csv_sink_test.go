@@ -0,0 +1,225 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+func disturbedPlant() *wrap.DisturbedSystem {
+	return wrap.NewDisturbedSystem(sim.NewDCMotor(), wrap.StepDisturbanceConfig{
+		Enabled: true, StartS: 0.5, DurationS: 0.2, MagnitudeRPMPerS: 50.0,
+	})
+}
+
+func TestCSVSink_MatchesBatchWriter(t *testing.T) {
+	cfg := experiment.StepConfig{TargetRPM: 1000.0, DT: 0.01, Duration: 1.0}
+
+	// Batch: run, then write samples.csv
+	batchDir := t.TempDir()
+	samples, _, _ := experiment.RunStep(disturbedPlant(), pid.New(0.02, 0.05, 0.0), cfg)
+	batchRun := RunDir{Dir: batchDir}
+	if err := batchRun.WriteSamplesCSV(samples); err != nil {
+		t.Fatalf("WriteSamplesCSV() error = %v", err)
+	}
+
+	// Streaming: write rows as they are produced
+	streamDir := t.TempDir()
+	streamRun := RunDir{Dir: streamDir}
+	sink, err := streamRun.SamplesCSVSink(CSVOptions{})
+	if err != nil {
+		t.Fatalf("SamplesCSVSink() error = %v", err)
+	}
+	n, _, err := experiment.RunStepStreaming(disturbedPlant(), pid.New(0.02, 0.05, 0.0), cfg, sink)
+	if err != nil {
+		t.Fatalf("RunStepStreaming() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if n != len(samples) {
+		t.Errorf("streamed %d samples, want %d", n, len(samples))
+	}
+
+	batch, err := os.ReadFile(filepath.Join(batchDir, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := os.ReadFile(filepath.Join(streamDir, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(batch, streamed) {
+		t.Error("streamed samples.csv differs from the batch writer output")
+	}
+}
+
+func TestCSVSink_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewCSVSink(&buf, CSVOptions{})
+	if err := sink.Write(experiment.Sample{T: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("rows reached the writer before Flush: %q", buf.String())
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Errorf("after Flush: %d records, want header and one row", len(records))
+	}
+}
+
+func TestCSVSink_DeclaredColumns(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewCSVSink(&buf, CSVOptions{SignalKeys: []string{"z_signal", "a_signal"}})
+
+	// The first sample lacks a_signal and carries an undeclared key
+	if err := sink.Write(experiment.Sample{Signals: map[string]float64{"z_signal": 1, "other": 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(experiment.Sample{Signals: map[string]float64{"a_signal": 3, "z_signal": 4}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := records[0]
+	if len(header) != len(baseColumns)+2 || header[len(baseColumns)] != "a_signal" || header[len(baseColumns)+1] != "z_signal" {
+		t.Fatalf("header = %v, want base columns then a_signal,z_signal", header)
+	}
+	if got := records[1][len(baseColumns)]; got != "0.000000" {
+		t.Errorf("row 1 a_signal = %q, want 0.000000 (absent)", got)
+	}
+	if got := records[2][len(baseColumns)]; got != "3.000000" {
+		t.Errorf("row 2 a_signal = %q, want 3.000000", got)
+	}
+}
+
+func TestCSVSink_ColumnsFromFirstSample(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewCSVSink(&buf, CSVOptions{})
+
+	if err := sink.Write(experiment.Sample{Signals: map[string]float64{"early": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	// A signal appearing late is dropped, since the header is already written
+	if err := sink.Write(experiment.Sample{Signals: map[string]float64{"early": 2, "late": 3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records[0]) != len(baseColumns)+1 || records[0][len(baseColumns)] != "early" {
+		t.Errorf("header = %v, want base columns then early", records[0])
+	}
+}
+
+func TestCSVSink_MergedSignalOrderIsDeterministic(t *testing.T) {
+	// System signals from two wrappers and the runner's own u_clamped are merged
+	// into one map per sample; the columns must not depend on its iteration order.
+	cfg := experiment.StepConfig{TargetRPM: 1000.0, DT: 0.01, Duration: 1.0, MaxAbsU: 6}
+	run := func() []byte {
+		sys := wrap.NewNoisySystem(disturbedPlant(), 1.0, 7)
+		ctrl := pid.New(0.02, 0.05, 0.0)
+		var buf bytes.Buffer
+		sink := NewCSVSink(&buf, CSVOptions{SignalKeys: append(system.DeclaredSignalKeys(sys), experiment.RunnerSignalKeys(cfg, ctrl)...)})
+		if _, _, err := experiment.RunStepStreaming(sys, ctrl, cfg, sink); err != nil {
+			t.Fatalf("RunStepStreaming() error = %v", err)
+		}
+		if err := sink.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	first := run()
+	records, err := csv.NewReader(bytes.NewReader(first)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signalCols := records[0][len(baseColumns):]
+	if len(signalCols) < 3 || !sort.StringsAreSorted(signalCols) {
+		t.Fatalf("signal columns = %v, want the merged keys in sorted order", signalCols)
+	}
+	for i := 0; i < 5; i++ {
+		if !bytes.Equal(run(), first) {
+			t.Fatalf("run %d wrote different samples.csv bytes", i+2)
+		}
+	}
+}
+
+// jumpReference holds First until At, then Second.
+type jumpReference struct{ First, Second, At float64 }
+
+func (r jumpReference) Target(t float64) float64 {
+	if t < r.At {
+		return r.First
+	}
+	return r.Second
+}
+
+func TestCSVSink_DeclaresIntegralReset(t *testing.T) {
+	// The disturbed plant declares its own keys, so the sink fixes its columns
+	// from the declared set; integral_reset must be part of it.
+	cfg := experiment.StepConfig{Reference: jumpReference{First: 500, Second: 1000, At: 0.5}, DT: 0.01, Duration: 1.0}
+	sys := disturbedPlant()
+	ctrl := pid.New(0.02, 0.05, 0.0)
+	ctrl.IntegralResetJump = 100
+	var buf bytes.Buffer
+	sink := NewCSVSink(&buf, CSVOptions{SignalKeys: append(system.DeclaredSignalKeys(sys), experiment.RunnerSignalKeys(cfg, ctrl)...)})
+	if _, _, err := experiment.RunStepStreaming(sys, ctrl, cfg, sink); err != nil {
+		t.Fatalf("RunStepStreaming() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := -1
+	for i, name := range records[0] {
+		if name == experiment.SignalIntegralReset {
+			col = i
+		}
+	}
+	if col < 0 {
+		t.Fatalf("header = %v, want an %s column", records[0], experiment.SignalIntegralReset)
+	}
+	fired := 0
+	for _, rec := range records[1:] {
+		if v, err := strconv.ParseFloat(rec[col], 64); err == nil && v == 1 {
+			fired++
+		}
+	}
+	if fired != 1 {
+		t.Errorf("integral_reset set on %d rows, want 1", fired)
+	}
+}
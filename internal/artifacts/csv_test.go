@@ -1,6 +1,7 @@
 package artifacts
 
 import (
+	"bytes"
 	"encoding/csv"
 	"os"
 	"path/filepath"
@@ -8,6 +9,9 @@ import (
 	"testing"
 
 	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
 )
 
 func TestWriteSamplesCSV(t *testing.T) {
@@ -304,3 +308,65 @@ func TestWriteSamplesCSV_WithSignals(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteSamplesCSV_DeclaredSignalKeys(t *testing.T) {
+	dir := t.TempDir()
+	runDir := RunDir{Dir: dir}
+
+	// No sample carries a signal yet (e.g., a run that ended before the disturbance)
+	samples := []experiment.Sample{
+		{T: 0.0, DT: 0.001, Target: 1000.0},
+		{T: 0.001, DT: 0.001, Target: 1000.0, Signals: map[string]float64{"extra": 1.0}},
+	}
+
+	plant := wrap.NewDisturbedSystem(sim.NewDCMotor(), wrap.StepDisturbanceConfig{Enabled: true, StartS: 5.0, MagnitudeRPMPerS: 50.0})
+	opts := CSVOptions{SignalKeys: system.DeclaredSignalKeys(plant)}
+	if err := runDir.WriteSamplesCSVWith(samples, opts); err != nil {
+		t.Fatalf("WriteSamplesCSVWith() error = %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "samples.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Declared and discovered keys, in sorted order
+	header := records[0]
+	want := append(append([]string{}, baseColumns...), "disturbance_rpm_per_s", "extra")
+	if len(header) != len(want) {
+		t.Fatalf("header = %v, want %v", header, want)
+	}
+	for i := range want {
+		if header[i] != want[i] {
+			t.Errorf("header[%d] = %q, want %q", i, header[i], want[i])
+		}
+	}
+}
+
+func TestCSVSink_DeclaredKeysBeforeFirstSignal(t *testing.T) {
+	var buf bytes.Buffer
+	plant := wrap.NewDisturbedSystem(sim.NewDCMotor(), wrap.StepDisturbanceConfig{})
+	sink := NewCSVSink(&buf, CSVOptions{SignalKeys: system.DeclaredSignalKeys(plant)})
+
+	if err := sink.Write(experiment.Sample{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := records[0][len(records[0])-1]; got != "disturbance_rpm_per_s" {
+		t.Errorf("last header column = %q, want disturbance_rpm_per_s", got)
+	}
+}
@@ -0,0 +1,65 @@
+package artifacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// debugColumns are the debug.csv columns: every controller trace field, the
+// applied command and the integrator state.
+var debugColumns = []string{
+	"t", "dt", "target", "actual", "error",
+	"p", "i", "d", "ff", "out_raw", "out", "u", "integral",
+	"saturated", "integrated", "derivative_skipped",
+}
+
+// WriteDebugCSV writes the per-step controller state to debug.csv inside the
+// run directory, one row per record (see experiment.StepConfig.Debug). It is
+// heavier than samples.csv and meant for diagnosing tuning problems.
+func (r *RunDir) WriteDebugCSV(records []experiment.DebugRecord) error {
+	f, err := os.Create(filepath.Join(r.Dir, "debug.csv"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close() // Error on close is non-fatal for CSV writing - file is already written
+	}()
+
+	w := csv.NewWriter(f)
+	if err := w.Write(debugColumns); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if err := w.Write(debugRecord(rec)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func debugRecord(rec experiment.DebugRecord) []string {
+	tr := rec.Trace
+	return []string{
+		fmt.Sprintf("%.6f", rec.T),
+		fmt.Sprintf("%.6f", rec.DT),
+		fmt.Sprintf("%.6f", tr.Target),
+		fmt.Sprintf("%.6f", tr.Actual),
+		fmt.Sprintf("%.6f", tr.Error),
+		fmt.Sprintf("%.6f", tr.P),
+		fmt.Sprintf("%.6f", tr.I),
+		fmt.Sprintf("%.6f", tr.D),
+		fmt.Sprintf("%.6f", tr.FF),
+		fmt.Sprintf("%.6f", tr.OutRaw),
+		fmt.Sprintf("%.6f", tr.Out),
+		fmt.Sprintf("%.6f", rec.U),
+		fmt.Sprintf("%.6f", rec.Integral),
+		fmt.Sprintf("%t", tr.Saturated),
+		fmt.Sprintf("%t", tr.Integrated),
+		fmt.Sprintf("%t", tr.DerivativeSkipped),
+	}
+}
@@ -0,0 +1,46 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+func TestWriteDebugCSV(t *testing.T) {
+	records := []experiment.DebugRecord{
+		{T: 0, DT: 0.01, U: 2, Integral: 10, Trace: pid.Trace{
+			Target: 100, Actual: 0, Error: 100, P: 2, I: 0.5, D: 0.1, FF: 1, OutRaw: 3.6, Out: 2,
+			Saturated: true, Integrated: false, DerivativeSkipped: true,
+		}},
+		{T: 0.01, DT: 0.01, U: 1.5, Integral: 10.5, Trace: pid.Trace{Target: 100, Actual: 5, Error: 95, Out: 1.5, Integrated: true}},
+	}
+
+	dir := t.TempDir()
+	runDir := RunDir{Dir: dir}
+	if err := runDir.WriteDebugCSV(records); err != nil {
+		t.Fatalf("WriteDebugCSV() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "debug.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{
+		"t,dt,target,actual,error,p,i,d,ff,out_raw,out,u,integral,saturated,integrated,derivative_skipped",
+		"0.000000,0.010000,100.000000,0.000000,100.000000,2.000000,0.500000,0.100000,1.000000,3.600000,2.000000,2.000000,10.000000,true,false,true",
+		"0.010000,0.010000,100.000000,5.000000,95.000000,0.000000,0.000000,0.000000,0.000000,0.000000,1.500000,1.500000,10.500000,false,true,false",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("debug.csv has %d lines, want %d:\n%s", len(lines), len(want), data)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
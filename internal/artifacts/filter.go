@@ -0,0 +1,152 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FilterIndex returns the runs for which predicate returns true, preserving order.
+func FilterIndex(runs []Metadata, predicate func(Metadata) bool) []Metadata {
+	var out []Metadata
+	for _, md := range runs {
+		if predicate(md) {
+			out = append(out, md)
+		}
+	}
+	return out
+}
+
+// ReadMetrics reads metrics.json from a run directory as a generic map,
+// so runs written by older or newer versions can still be inspected.
+func ReadMetrics(runDir string) (map[string]any, error) {
+	b, err := os.ReadFile(filepath.Join(runDir, "metrics.json"))
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RunValues flattens the numeric params and metrics of a run into a single
+// namespace for filtering. Booleans are mapped to 0/1. Params take precedence
+// when a key exists in both.
+func RunValues(md Metadata, metrics map[string]any) map[string]float64 {
+	values := make(map[string]float64, len(md.Params)+len(metrics))
+	for _, src := range []map[string]any{metrics, md.Params} {
+		for k, v := range src {
+			if f, ok := toFloat(v); ok {
+				values[k] = f
+			}
+		}
+	}
+	return values
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int:
+		return float64(x), true
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Filter is a parsed filter expression: a conjunction of comparisons such as
+// "kp>0.03,overshoot_percent<5". Clauses are separated by "," or "&&".
+type Filter struct {
+	clauses []clause
+}
+
+type clause struct {
+	key   string
+	op    string
+	value float64
+}
+
+// filterOps is ordered so that two-character operators match before their prefixes.
+var filterOps = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// ParseFilter parses a filter expression. An empty expression matches every run.
+func ParseFilter(expr string) (Filter, error) {
+	var f Filter
+	expr = strings.ReplaceAll(expr, "&&", ",")
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		c, err := parseClause(part)
+		if err != nil {
+			return Filter{}, err
+		}
+		f.clauses = append(f.clauses, c)
+	}
+	return f, nil
+}
+
+func parseClause(s string) (clause, error) {
+	for _, op := range filterOps {
+		idx := strings.Index(s, op)
+		if idx < 0 {
+			continue
+		}
+		key := strings.TrimSpace(s[:idx])
+		raw := strings.TrimSpace(s[idx+len(op):])
+		if key == "" {
+			return clause{}, fmt.Errorf("filter %q: missing field name", s)
+		}
+		raw = strings.TrimSuffix(raw, "%")
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return clause{}, fmt.Errorf("filter %q: invalid number %q", s, raw)
+		}
+		if op == "=" {
+			op = "=="
+		}
+		return clause{key: key, op: op, value: v}, nil
+	}
+	return clause{}, fmt.Errorf("filter %q: expected a comparison like key>value", s)
+}
+
+// Match reports whether values satisfy every clause. A clause referring to a
+// missing key does not match.
+func (f Filter) Match(values map[string]float64) bool {
+	for _, c := range f.clauses {
+		v, ok := values[c.key]
+		if !ok {
+			return false
+		}
+		var pass bool
+		switch c.op {
+		case ">":
+			pass = v > c.value
+		case ">=":
+			pass = v >= c.value
+		case "<":
+			pass = v < c.value
+		case "<=":
+			pass = v <= c.value
+		case "==":
+			pass = v == c.value
+		case "!=":
+			pass = v != c.value
+		}
+		if !pass {
+			return false
+		}
+	}
+	return true
+}
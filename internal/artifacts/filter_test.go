@@ -0,0 +1,83 @@
+package artifacts
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// fabricatedRuns returns run metadata with metrics keyed by run ID.
+func fabricatedRuns() ([]Metadata, map[string]map[string]any) {
+	runs := []Metadata{
+		{RunID: "r1", Params: map[string]any{"kp": 0.02, "ki": 0.05, "disturbance_enabled": false}},
+		{RunID: "r2", Params: map[string]any{"kp": 0.04, "ki": 0.05, "disturbance_enabled": true}},
+		{RunID: "r3", Params: map[string]any{"kp": 0.08, "ki": 0.10, "disturbance_enabled": false}},
+	}
+	metrics := map[string]map[string]any{
+		"r1": {"overshoot_percent": 0.0, "iae": 120.0},
+		"r2": {"overshoot_percent": 3.5, "iae": 80.0},
+		"r3": {"overshoot_percent": 12.0, "iae": 60.0},
+	}
+	return runs, metrics
+}
+
+func TestFilterIndex(t *testing.T) {
+	runs, metrics := fabricatedRuns()
+
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		{name: "empty expression matches all", expr: "", want: []string{"r1", "r2", "r3"}},
+		{name: "param comparison", expr: "kp>0.03", want: []string{"r2", "r3"}},
+		{name: "param and metric", expr: "kp > 0.03, overshoot_percent < 5", want: []string{"r2"}},
+		{name: "ampersand conjunction", expr: "ki==0.05 && iae<=100", want: []string{"r2"}},
+		{name: "percent suffix", expr: "overshoot_percent<5%", want: []string{"r1", "r2"}},
+		{name: "boolean param", expr: "disturbance_enabled=1", want: []string{"r2"}},
+		{name: "not equal", expr: "ki!=0.05", want: []string{"r3"}},
+		{name: "unknown key matches nothing", expr: "missing>0", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q) error = %v", tt.expr, err)
+			}
+			got := FilterIndex(runs, func(md Metadata) bool {
+				return f.Match(RunValues(md, metrics[md.RunID]))
+			})
+			if len(got) != len(tt.want) {
+				t.Fatalf("FilterIndex() returned %d runs, want %d", len(got), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if got[i].RunID != id {
+					t.Errorf("got[%d].RunID = %q, want %q", i, got[i].RunID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, expr := range []string{"kp", ">0.1", "kp>abc"} {
+		if _, err := ParseFilter(expr); err == nil {
+			t.Errorf("ParseFilter(%q) should fail", expr)
+		}
+	}
+}
+
+func TestReadMetrics(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteJSON(filepath.Join(dir, "metrics.json"), map[string]any{"iae": 1.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ReadMetrics(dir)
+	if err != nil {
+		t.Fatalf("ReadMetrics() error = %v", err)
+	}
+	if m["iae"] != 1.5 {
+		t.Errorf("iae = %v, want 1.5", m["iae"])
+	}
+}
@@ -0,0 +1,68 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BuildIndex scans baseDir for run directories and returns their metadata,
+// sorted by run ID (which sorts chronologically).
+//
+// Subdirectories without a metadata.json are skipped; a metadata.json that
+// cannot be parsed is reported as an error.
+func BuildIndex(baseDir string) ([]Metadata, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Metadata
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		md, err := ReadMetadata(filepath.Join(baseDir, e.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, md)
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].RunID < runs[j].RunID })
+	return runs, nil
+}
+
+// ReadMetadata reads metadata.json from a run directory and sets Dir to runDir.
+func ReadMetadata(runDir string) (Metadata, error) {
+	b, err := os.ReadFile(filepath.Join(runDir, "metadata.json"))
+	if err != nil {
+		return Metadata{}, err
+	}
+	var md Metadata
+	if err := json.Unmarshal(b, &md); err != nil {
+		return Metadata{}, err
+	}
+	md.Dir = runDir
+	return md, nil
+}
+
+// FilterByTag returns the runs carrying the given tag, preserving order.
+func FilterByTag(runs []Metadata, tag string) []Metadata {
+	return FilterIndex(runs, func(md Metadata) bool { return md.HasTag(tag) })
+}
+
+// HasTag reports whether the run carries the given tag.
+func (md Metadata) HasTag(tag string) bool {
+	for _, t := range md.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
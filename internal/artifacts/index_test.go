@@ -0,0 +1,95 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeRun fabricates a run directory containing only metadata.json.
+func writeRun(t *testing.T, base string, md Metadata) {
+	t.Helper()
+	dir := filepath.Join(base, md.RunID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create run dir: %v", err)
+	}
+	if err := WriteJSON(filepath.Join(dir, "metadata.json"), md); err != nil {
+		t.Fatalf("failed to write metadata.json: %v", err)
+	}
+}
+
+func TestBuildIndex(t *testing.T) {
+	base := t.TempDir()
+
+	writeRun(t, base, Metadata{RunID: "2026-01-02T00-00-00Z_sim_dc-motor_step", Tags: []string{"b"}})
+	writeRun(t, base, Metadata{RunID: "2026-01-01T00-00-00Z_sim_dc-motor_step", Tags: []string{"a"}})
+
+	// Directories without metadata.json and stray files are ignored
+	if err := os.MkdirAll(filepath.Join(base, "not-a-run"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "README"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := BuildIndex(base)
+	if err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("BuildIndex() returned %d runs, want 2", len(runs))
+	}
+
+	// Sorted by run ID
+	if runs[0].RunID != "2026-01-01T00-00-00Z_sim_dc-motor_step" {
+		t.Errorf("runs[0].RunID = %q, want the earliest run first", runs[0].RunID)
+	}
+}
+
+func TestBuildIndex_InvalidMetadata(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "broken")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "metadata.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := BuildIndex(base); err == nil {
+		t.Error("BuildIndex() should fail on unparsable metadata.json")
+	}
+}
+
+func TestFilterByTag(t *testing.T) {
+	runs := []Metadata{
+		{RunID: "r1", Tags: []string{"baseline"}},
+		{RunID: "r2", Tags: []string{"baseline", "disturbance"}},
+		{RunID: "r3"},
+		{RunID: "r4", Tags: []string{"disturbance"}},
+	}
+
+	tests := []struct {
+		name string
+		tag  string
+		want []string
+	}{
+		{name: "shared tag", tag: "baseline", want: []string{"r1", "r2"}},
+		{name: "other tag", tag: "disturbance", want: []string{"r2", "r4"}},
+		{name: "unknown tag", tag: "missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterByTag(runs, tt.tag)
+			if len(got) != len(tt.want) {
+				t.Fatalf("FilterByTag(%q) returned %d runs, want %d", tt.tag, len(got), len(tt.want))
+			}
+			for i, id := range tt.want {
+				if got[i].RunID != id {
+					t.Errorf("got[%d].RunID = %q, want %q", i, got[i].RunID, id)
+				}
+			}
+		})
+	}
+}
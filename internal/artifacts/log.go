@@ -0,0 +1,79 @@
+package artifacts
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+// LogFormat selects the line format of run logs.
+type LogFormat string
+
+const (
+	// LogText writes logfmt-style lines: level=INFO msg="run complete" run_id=...
+	LogText LogFormat = "text"
+	// LogJSON writes one JSON object per line.
+	LogJSON LogFormat = "json"
+)
+
+// ParseLogFormat validates a --log-format value. An empty string selects LogText.
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch LogFormat(s) {
+	case "", LogText:
+		return LogText, nil
+	case LogJSON:
+		return LogJSON, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q (want %q or %q)", s, LogText, LogJSON)
+	}
+}
+
+// LogOptions configures NewLogger.
+type LogOptions struct {
+	Format LogFormat
+	// Level is the minimum level written; the zero value is slog.LevelInfo.
+	Level slog.Level
+}
+
+// NewLogger returns a structured logger writing key=value (or JSON) lines to w.
+//
+// Records carry no timestamp; the run's creation time is already in metadata.json.
+func NewLogger(w io.Writer, opts LogOptions) *slog.Logger {
+	ho := &slog.HandlerOptions{
+		Level: opts.Level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}
+	if opts.Format == LogJSON {
+		return slog.New(slog.NewJSONHandler(w, ho))
+	}
+	return slog.New(slog.NewTextHandler(w, ho))
+}
+
+// Logger returns a structured logger writing to the run's out.log.
+func (r *RunDir) Logger(opts LogOptions) *slog.Logger {
+	return NewLogger(r.out, opts)
+}
+
+// MetricAttrs returns one attribute per metric, keyed by its json name.
+// Non-finite values (e.g. an unsettled SettlingTimeSeconds) are logged as strings
+// such as "NaN", since JSON has no representation for them.
+func MetricAttrs(metrics analysis.Metrics) []slog.Attr {
+	fields := metricFields(metrics)
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		if v, ok := f.value.(float64); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			attrs = append(attrs, slog.String(f.name, fmt.Sprint(v)))
+			continue
+		}
+		attrs = append(attrs, slog.Any(f.name, f.value))
+	}
+	return attrs
+}
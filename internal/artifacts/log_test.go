@@ -0,0 +1,82 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+func TestNewLogger_Text(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(&buf, LogOptions{})
+	log.Info("run complete", "run_id", "r1", "final_actual", 999.5)
+	log.Debug("hidden")
+
+	got := buf.String()
+	want := "level=INFO msg=\"run complete\" run_id=r1 final_actual=999.5\n"
+	if got != want {
+		t.Errorf("log = %q, want %q", got, want)
+	}
+}
+
+func TestNewLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(&buf, LogOptions{Level: slog.LevelWarn})
+	log.Info("dropped")
+	log.Warn("kept")
+
+	if got := buf.String(); got != "level=WARN msg=kept\n" {
+		t.Errorf("log = %q, want only the warning", got)
+	}
+}
+
+func TestNewLogger_JSONMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewLogger(&buf, LogOptions{Format: LogJSON})
+	metrics := analysis.Metrics{Target: 1000, OvershootPercent: 4.5, SettlingTimeSeconds: math.NaN()}
+	log.LogAttrs(context.Background(), slog.LevelInfo, "metrics", MetricAttrs(metrics)...)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, buf.String())
+	}
+	if _, ok := rec["time"]; ok {
+		t.Error("log record should not carry a timestamp")
+	}
+	checks := map[string]any{
+		"level":                 "INFO",
+		"msg":                   "metrics",
+		"target":                1000.0,
+		"overshoot_percent":     4.5,
+		"settling_time_seconds": "NaN",
+	}
+	for k, want := range checks {
+		if rec[k] != want {
+			t.Errorf("%s = %v, want %v", k, rec[k], want)
+		}
+	}
+}
+
+func TestParseLogFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LogFormat
+		wantErr bool
+	}{
+		{"", LogText, false},
+		{"text", LogText, false},
+		{"json", LogJSON, false},
+		{"xml", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLogFormat(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLogFormat(%q) = %q, %v; want %q, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package artifacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// LongRun is one run's samples for WriteLongCSV.
+type LongRun struct {
+	RunID   string
+	Samples []experiment.Sample
+}
+
+// WriteLongCSV writes the samples of several runs as one long-format table
+// (one row per sample, e.g. for a pandas groupby): a leading run_id column,
+// then the samples.csv columns. The signal columns are the union over all runs;
+// a signal a sample lacks is written as an empty cell, as with
+// CSVOptions.MarkAbsent, so runs of different plants can share a file. Run IDs
+// must be unique, or the runs could not be told apart.
+func WriteLongCSV(w io.Writer, runs []LongRun) error {
+	seen := make(map[string]bool, len(runs))
+	var all []experiment.Sample
+	for _, run := range runs {
+		if seen[run.RunID] {
+			return fmt.Errorf("duplicate run id %q", run.RunID)
+		}
+		seen[run.RunID] = true
+		all = append(all, run.Samples...)
+	}
+	signalKeys := collectSignalKeys(all)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"run_id"}, samplesHeader(signalKeys)...)); err != nil {
+		return err
+	}
+	for _, run := range runs {
+		for _, s := range run.Samples {
+			if err := cw.Write(append([]string{run.RunID}, sampleRecord(s, signalKeys, true)...)); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,64 @@
+package artifacts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+func TestWriteLongCSV(t *testing.T) {
+	runs := []LongRun{
+		{RunID: "run-a", Samples: []experiment.Sample{
+			{T: 0, DT: 0.1, Target: 100, Signals: map[string]float64{"velocity_rpm": 1}},
+			{T: 0.1, DT: 0.1, Target: 100, Actual: 5, Signals: map[string]float64{"velocity_rpm": 2}},
+		}},
+		{RunID: "run-b", Samples: []experiment.Sample{
+			{T: 0, DT: 0.01, Target: 50, Signals: map[string]float64{"load_nm": 0.5}},
+			{T: 0.01, DT: 0.01, Target: 50},
+			{T: 0.02, DT: 0.01, Target: 50},
+		}},
+		{RunID: "run-empty"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLongCSV(&buf, runs); err != nil {
+		t.Fatalf("WriteLongCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantHeader := "run_id," + strings.Join(baseColumns, ",") + ",load_nm,velocity_rpm"
+	if got := strings.Join(records[0], ","); got != wantHeader {
+		t.Errorf("header = %q, want %q", got, wantHeader)
+	}
+	rows := records[1:]
+	if len(rows) != 5 {
+		t.Fatalf("%d rows, want 5 (2 + 3 + 0)", len(rows))
+	}
+	wantIDs := []string{"run-a", "run-a", "run-b", "run-b", "run-b"}
+	for i, row := range rows {
+		if row[0] != wantIDs[i] {
+			t.Errorf("row %d: run_id = %q, want %q", i, row[0], wantIDs[i])
+		}
+	}
+	// Signals a run lacks are empty, not zero
+	load, vel := len(records[0])-2, len(records[0])-1
+	if rows[0][load] != "" || rows[0][vel] != "1.000000" {
+		t.Errorf("run-a row 0 signals = %q, %q; want empty, 1", rows[0][load], rows[0][vel])
+	}
+	if rows[2][load] != "0.500000" || rows[2][vel] != "" || rows[3][load] != "" {
+		t.Errorf("run-b signals = %q, %q, %q; want 0.5, empty, empty", rows[2][load], rows[2][vel], rows[3][load])
+	}
+}
+
+func TestWriteLongCSV_DuplicateRunID(t *testing.T) {
+	runs := []LongRun{{RunID: "x"}, {RunID: "x"}}
+	if err := WriteLongCSV(&bytes.Buffer{}, runs); err == nil {
+		t.Error("WriteLongCSV() with a duplicate run id should fail")
+	}
+}
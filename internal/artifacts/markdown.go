@@ -0,0 +1,90 @@
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+// WriteMarkdownSummary writes summary.md inside runDir with a parameters table,
+// a metrics table, and image references to the PNG plots present in runDir.
+// It is meant for pasting into notebooks and pull requests.
+func WriteMarkdownSummary(runDir string, md Metadata, metrics analysis.Metrics) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Run %s\n\n", md.RunID)
+	fmt.Fprintf(&b, "- Kind: %s\n", md.Kind)
+	fmt.Fprintf(&b, "- Plant: %s\n", md.Plant)
+	fmt.Fprintf(&b, "- Experiment: %s\n", md.Experiment)
+	fmt.Fprintf(&b, "- Created (UTC): %s\n", md.CreatedAtUTC)
+	if len(md.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(md.Tags, ", "))
+	}
+
+	b.WriteString("\n## Parameters\n\n")
+	b.WriteString("| Parameter | Value |\n|---|---|\n")
+	keys := make([]string, 0, len(md.Params))
+	for k := range md.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "| %s | %v |\n", k, md.Params[k])
+	}
+
+	b.WriteString("\n## Metrics\n\n")
+	b.WriteString("| Metric | Value |\n|---|---|\n")
+	for _, kv := range metricRows(metrics) {
+		fmt.Fprintf(&b, "| %s | %s |\n", kv[0], kv[1])
+	}
+
+	plots, err := filepath.Glob(filepath.Join(runDir, "*.png"))
+	if err != nil {
+		return err
+	}
+	if len(plots) > 0 {
+		sort.Strings(plots)
+		b.WriteString("\n## Plots\n\n")
+		for _, p := range plots {
+			name := filepath.Base(p)
+			fmt.Fprintf(&b, "![%s](%s)\n\n", strings.TrimSuffix(name, ".png"), name)
+		}
+	}
+
+	return os.WriteFile(filepath.Join(runDir, "summary.md"), []byte(b.String()), 0o644)
+}
+
+// metricRows returns (json name, formatted value) pairs in field order.
+func metricRows(metrics analysis.Metrics) [][2]string {
+	fields := metricFields(metrics)
+	rows := make([][2]string, 0, len(fields))
+	for _, f := range fields {
+		rows = append(rows, [2]string{f.name, fmt.Sprintf("%.6g", f.value)})
+	}
+	return rows
+}
+
+type metricField struct {
+	name  string
+	value any
+}
+
+// metricFields returns the metrics keyed by their json names, in field order.
+func metricFields(metrics analysis.Metrics) []metricField {
+	v := reflect.ValueOf(metrics)
+	t := v.Type()
+	fields := make([]metricField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = t.Field(i).Name
+		}
+		fields = append(fields, metricField{name: name, value: v.Field(i).Interface()})
+	}
+	return fields
+}
@@ -0,0 +1,65 @@
+package artifacts
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+func TestWriteMarkdownSummary(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "velocity.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	md := Metadata{
+		RunID:        "2026-01-16T09-05-29Z_sim_dc-motor_step",
+		CreatedAtUTC: "2026-01-16T09-05-29Z",
+		Kind:         "sim",
+		Plant:        "dc-motor",
+		Experiment:   "step",
+		Tags:         []string{"baseline"},
+		Params:       map[string]any{"kp": 0.02, "ki": 0.05, "target_rpm": 1000.0},
+	}
+	metrics := analysis.Metrics{
+		Target:              1000.0,
+		OvershootPercent:    4.25,
+		IAE:                 123.5,
+		SettlingTimeSeconds: math.NaN(),
+	}
+
+	if err := WriteMarkdownSummary(dir, md, metrics); err != nil {
+		t.Fatalf("WriteMarkdownSummary() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "summary.md"))
+	if err != nil {
+		t.Fatalf("summary.md not written: %v", err)
+	}
+	got := string(content)
+
+	for _, want := range []string{
+		md.RunID,
+		"| kp | 0.02 |",
+		"| ki | 0.05 |",
+		"| target_rpm | 1000 |",
+		"| overshoot_percent | 4.25 |",
+		"| iae | 123.5 |",
+		"| settling_time_seconds | NaN |",
+		"![velocity](velocity.png)",
+		"baseline",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary.md missing %q\n%s", want, got)
+		}
+	}
+
+	// Only plots that exist are referenced
+	if strings.Contains(got, "control.png") {
+		t.Error("summary.md references control.png, which was not generated")
+	}
+}
@@ -1,7 +1,9 @@
 package artifacts
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -18,29 +20,95 @@ type RunDir struct {
 
 // Metadata is written to metadata.json to make runs self-describing.
 // Params are experiment parameters (gains, dt, duration, target, etc.).
+// Tags are free-form user labels used to organize and filter runs.
+// Units maps samples.csv columns, signals and metrics to their units (see DefaultUnits).
+// Seeds records the effective seed of each stochastic component of the system
+// (see system.SeedReporter); deterministic runs have none.
+// VolatileEnvironment is only set when CreateOptions.SplitVolatileEnvironment is;
+// it then holds the environment fields that vary between otherwise identical setups.
 type Metadata struct {
 	RunID        string            `json:"run_id"`
 	CreatedAtUTC string            `json:"created_at_utc"`
 	Kind         string            `json:"kind"` // e.g. "sim" or "hw"
 	Plant        string            `json:"plant"`
 	Experiment   string            `json:"experiment"`
+	Tags         []string          `json:"tags,omitempty"`
 	Params       map[string]any    `json:"params"`
+	Units        map[string]string `json:"units,omitempty"`
+	Seeds        map[string]int64  `json:"seeds,omitempty"`
 	Environment  map[string]string `json:"environment"`
+
+	VolatileEnvironment map[string]string `json:"volatile_environment,omitempty"`
+
+	// Dir is the run directory the metadata was read from (see ReadMetadata),
+	// which need not be named after RunID if the run was renamed or copied.
+	// It is not stored in metadata.json.
+	Dir string `json:"-"`
+}
+
+// CreateOptions holds optional settings for CreateWith.
+type CreateOptions struct {
+	Tags []string
+
+	// SplitVolatileEnvironment moves toolchain-dependent fields (go_version) out of
+	// Environment into VolatileEnvironment, so that Environment can be diffed
+	// across machines when verifying reproducibility.
+	SplitVolatileEnvironment bool
+
+	// Units adds or overrides entries of DefaultUnits, e.g. for custom signals.
+	Units map[string]string
+
+	// Seeds is recorded as Metadata.Seeds.
+	Seeds map[string]int64
+
+	// CreatedAt, if non-zero, replaces the current time in the run ID and
+	// CreatedAtUTC, so that repeated runs produce identical artifacts (e.g.,
+	// for golden-file tests). Runs with the same timestamp, kind, plant and
+	// experiment share a run ID, so they need separate base directories:
+	// CreateWith fails with an error wrapping fs.ErrExist rather than reuse an
+	// existing run directory.
+	CreatedAt time.Time
 }
 
+// goVersion is a variable so tests can simulate a different toolchain.
+var goVersion = runtime.Version
+
 const (
 	// timestampFormat is used for run directory names and timestamps.
 	// Uses dashes instead of colons for filesystem compatibility.
 	timestampFormat = "2006-01-02T15-04-05Z"
 )
 
+// Create creates a new run directory under baseDir and writes metadata.json and out.log.
 func Create(baseDir, kind, plant, experiment string, params map[string]any) (RunDir, Metadata, error) {
-	ts := time.Now().UTC().Format(timestampFormat)
+	return CreateWith(baseDir, kind, plant, experiment, params, CreateOptions{})
+}
+
+// CreateWith is like Create but accepts optional settings such as tags.
+func CreateWith(baseDir, kind, plant, experiment string, params map[string]any, opts CreateOptions) (RunDir, Metadata, error) {
+	created := opts.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	ts := created.UTC().Format(timestampFormat)
 	runID := fmt.Sprintf("%s_%s_%s_%s", ts, kind, plant, experiment)
 	dir := filepath.Join(baseDir, runID)
 
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return RunDir{}, Metadata{}, err
+	if opts.CreatedAt.IsZero() {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return RunDir{}, Metadata{}, err
+		}
+	} else {
+		// A fixed timestamp repeats the run ID: never overwrite an earlier run
+		if err := os.MkdirAll(baseDir, 0o755); err != nil {
+			return RunDir{}, Metadata{}, err
+		}
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			if errors.Is(err, fs.ErrExist) {
+				return RunDir{}, Metadata{}, fmt.Errorf("run directory %s already exists (runs with a fixed creation time share a run ID; use a separate base directory): %w", dir, fs.ErrExist)
+			}
+			return RunDir{}, Metadata{}, err
+		}
 	}
 
 	md := Metadata{
@@ -49,13 +117,23 @@ func Create(baseDir, kind, plant, experiment string, params map[string]any) (Run
 		Kind:         kind,
 		Plant:        plant,
 		Experiment:   experiment,
+		Tags:         opts.Tags,
 		Params:       params,
+		Units:        DefaultUnits(),
+		Seeds:        opts.Seeds,
 		Environment: map[string]string{
-			"go_version": runtime.Version(),
-			"os":         runtime.GOOS,
-			"arch":       runtime.GOARCH,
+			"os":   runtime.GOOS,
+			"arch": runtime.GOARCH,
 		},
 	}
+	for k, u := range opts.Units {
+		md.Units[k] = u
+	}
+	if opts.SplitVolatileEnvironment {
+		md.VolatileEnvironment = map[string]string{"go_version": goVersion()}
+	} else {
+		md.Environment["go_version"] = goVersion()
+	}
 
 	if err := WriteJSON(filepath.Join(dir, "metadata.json"), md); err != nil {
 		return RunDir{}, Metadata{}, err
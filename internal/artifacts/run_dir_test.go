@@ -0,0 +1,279 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+func TestCreateWith_TagsRoundTrip(t *testing.T) {
+	base := t.TempDir()
+	tags := []string{"baseline", "disturbance"}
+
+	run, md, err := CreateWith(base, "sim", "dc-motor", "step", map[string]any{"kp": 0.02}, CreateOptions{Tags: tags})
+	if err != nil {
+		t.Fatalf("CreateWith() error = %v", err)
+	}
+	defer func() {
+		_ = run.Close()
+	}()
+
+	content, err := os.ReadFile(filepath.Join(run.Dir, "metadata.json"))
+	if err != nil {
+		t.Fatalf("failed to read metadata.json: %v", err)
+	}
+
+	var decoded Metadata
+	if err := json.Unmarshal(content, &decoded); err != nil {
+		t.Fatalf("failed to parse metadata.json: %v", err)
+	}
+
+	if decoded.RunID != md.RunID {
+		t.Errorf("RunID = %q, want %q", decoded.RunID, md.RunID)
+	}
+	if len(decoded.Tags) != len(tags) {
+		t.Fatalf("Tags = %v, want %v", decoded.Tags, tags)
+	}
+	for i, tag := range tags {
+		if decoded.Tags[i] != tag {
+			t.Errorf("Tags[%d] = %q, want %q", i, decoded.Tags[i], tag)
+		}
+	}
+}
+
+func TestCreate_NoTagsOmitted(t *testing.T) {
+	base := t.TempDir()
+
+	run, _, err := Create(base, "sim", "dc-motor", "step", map[string]any{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer func() {
+		_ = run.Close()
+	}()
+
+	content, err := os.ReadFile(filepath.Join(run.Dir, "metadata.json"))
+	if err != nil {
+		t.Fatalf("failed to read metadata.json: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(content, &raw); err != nil {
+		t.Fatalf("failed to parse metadata.json: %v", err)
+	}
+	if _, ok := raw["tags"]; ok {
+		t.Error("metadata.json should omit tags when none are set")
+	}
+}
+
+func TestCreateWith_SplitVolatileEnvironment(t *testing.T) {
+	defer func(orig func() string) { goVersion = orig }(goVersion)
+
+	// Generate the same scenario on two "machines" with different toolchains
+	readWith := func(version string) map[string]any {
+		goVersion = func() string { return version }
+		run, _, err := CreateWith(t.TempDir(), "sim", "dc-motor", "step", map[string]any{"kp": 0.02},
+			CreateOptions{SplitVolatileEnvironment: true})
+		if err != nil {
+			t.Fatalf("CreateWith() error = %v", err)
+		}
+		defer func() {
+			_ = run.Close()
+		}()
+		content, err := os.ReadFile(filepath.Join(run.Dir, "metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw map[string]any
+		if err := json.Unmarshal(content, &raw); err != nil {
+			t.Fatal(err)
+		}
+		// Timestamps legitimately differ between runs
+		delete(raw, "run_id")
+		delete(raw, "created_at_utc")
+		return raw
+	}
+	a := readWith("go1.22.0")
+	b := readWith("go1.23.4")
+
+	va, _ := json.Marshal(a["volatile_environment"])
+	vb, _ := json.Marshal(b["volatile_environment"])
+	if string(va) == string(vb) {
+		t.Errorf("volatile sections should differ, both are %s", va)
+	}
+
+	delete(a, "volatile_environment")
+	delete(b, "volatile_environment")
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	if string(ja) != string(jb) {
+		t.Errorf("metadata outside the volatile section differs:\n%s\n%s", ja, jb)
+	}
+	env, _ := a["environment"].(map[string]any)
+	if _, ok := env["go_version"]; ok {
+		t.Error("environment should not contain go_version when split")
+	}
+}
+
+func TestCreate_EnvironmentIncludesGoVersionByDefault(t *testing.T) {
+	run, md, err := Create(t.TempDir(), "sim", "dc-motor", "step", map[string]any{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer func() {
+		_ = run.Close()
+	}()
+
+	if md.Environment["go_version"] == "" {
+		t.Error("Environment should include go_version by default")
+	}
+	if md.VolatileEnvironment != nil {
+		t.Errorf("VolatileEnvironment = %v, want nil", md.VolatileEnvironment)
+	}
+}
+
+func TestCreate_Units(t *testing.T) {
+	run, _, err := CreateWith(t.TempDir(), "sim", "dc-motor", "step", map[string]any{},
+		CreateOptions{Units: map[string]string{"custom_signal": "nm", "u": "a"}})
+	if err != nil {
+		t.Fatalf("CreateWith() error = %v", err)
+	}
+	defer func() {
+		_ = run.Close()
+	}()
+
+	decoded, err := ReadMetadata(run.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"t":                     "s",
+		"target":                "rpm",
+		"actual":                "rpm",
+		"settling_time_seconds": "s",
+		"overshoot_percent":     "%",
+		"custom_signal":         "nm",
+		"u":                     "a", // overridden
+	}
+	for k, unit := range want {
+		if got := decoded.Units[k]; got != unit {
+			t.Errorf("Units[%q] = %q, want %q", k, got, unit)
+		}
+	}
+	if DefaultUnits()["u"] != "v" {
+		t.Error("overriding a unit must not modify the defaults")
+	}
+}
+
+func TestCreateWith_Seeds(t *testing.T) {
+	seeds := map[string]int64{"measurement_noise": -4611686018427387904, "disturbance_load_noise": 7}
+	run, _, err := CreateWith(t.TempDir(), "sim", "dc-motor", "step", map[string]any{}, CreateOptions{Seeds: seeds})
+	if err != nil {
+		t.Fatalf("CreateWith() error = %v", err)
+	}
+	defer func() {
+		_ = run.Close()
+	}()
+
+	decoded, err := ReadMetadata(run.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Seeds are 64-bit: they must survive JSON exactly
+	if !reflect.DeepEqual(decoded.Seeds, seeds) {
+		t.Errorf("Seeds = %v, want %v", decoded.Seeds, seeds)
+	}
+
+	// A run without stochastic components has no seeds entry
+	plain, _, err := Create(t.TempDir(), "sim", "dc-motor", "step", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = plain.Close()
+	}()
+	raw, err := os.ReadFile(filepath.Join(plain.Dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["seeds"]; ok {
+		t.Error("metadata.json has seeds for a deterministic run")
+	}
+}
+
+func TestCreateWith_CreatedAt(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 5, 0, time.FixedZone("CET", 3600))
+	var files [][]byte
+	for i := 0; i < 2; i++ {
+		run, md, err := CreateWith(t.TempDir(), "sim", "dc-motor", "step", map[string]any{"kp": 0.02}, CreateOptions{CreatedAt: at})
+		if err != nil {
+			t.Fatalf("CreateWith() error = %v", err)
+		}
+		_ = run.Close()
+
+		// The timestamp is formatted in UTC
+		if md.RunID != "2024-03-01T11-30-05Z_sim_dc-motor_step" || md.CreatedAtUTC != "2024-03-01T11-30-05Z" {
+			t.Errorf("run %d: RunID %q, CreatedAtUTC %q", i, md.RunID, md.CreatedAtUTC)
+		}
+		if filepath.Base(run.Dir) != md.RunID {
+			t.Errorf("run %d: directory %q, want %q", i, filepath.Base(run.Dir), md.RunID)
+		}
+		data, err := os.ReadFile(filepath.Join(run.Dir, "metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, data)
+	}
+	if string(files[0]) != string(files[1]) {
+		t.Errorf("metadata.json differs between runs with the same CreatedAt:\n%s\n%s", files[0], files[1])
+	}
+}
+
+func TestCreateWith_CreatedAtExistingRunDir(t *testing.T) {
+	base := t.TempDir()
+	opts := CreateOptions{CreatedAt: time.Unix(0, 0)}
+	run, _, err := CreateWith(base, "sim", "dc-motor", "step", map[string]any{"kp": 0.02}, opts)
+	if err != nil {
+		t.Fatalf("CreateWith() error = %v", err)
+	}
+	_ = run.Close()
+	first, err := os.ReadFile(filepath.Join(run.Dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same timestamp, same run ID: the earlier run must not be overwritten
+	_, _, err = CreateWith(base, "sim", "dc-motor", "step", map[string]any{"kp": 0.5}, opts)
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("second CreateWith() error = %v, want fs.ErrExist", err)
+	}
+	if again, _ := os.ReadFile(filepath.Join(run.Dir, "metadata.json")); string(again) != string(first) {
+		t.Error("metadata.json of the earlier run was overwritten")
+	}
+}
+
+func TestDefaultUnits_CoverCSVColumnsAndMetrics(t *testing.T) {
+	units := DefaultUnits()
+	for _, col := range append(baseColumns, debugColumns...) {
+		if units[col] == "" {
+			t.Errorf("missing unit for CSV column %q", col)
+		}
+	}
+	for _, f := range metricFields(analysis.Metrics{}) {
+		if units[f.name] == "" {
+			t.Errorf("missing unit for metric %q", f.name)
+		}
+	}
+}
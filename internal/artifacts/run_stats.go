@@ -0,0 +1,50 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// RunStats is the simulation cost of a run, written to run_stats.json next to
+// metrics.json so sweeps can compare it across runs. Unlike the metrics, it is
+// not derived from the samples.
+type RunStats struct {
+	// StepCount is the number of simulated steps, one per sample.
+	StepCount int `json:"step_count"`
+	// WallTimeSeconds is the wall-clock time of the simulation loop, and
+	// RealtimeFactor the simulated time per wall-clock second. Both are left
+	// out (zero) for reproducible runs, whose artifacts must not vary.
+	WallTimeSeconds float64 `json:"wall_time_seconds,omitempty"`
+	RealtimeFactor  float64 `json:"realtime_factor,omitempty"`
+}
+
+// WriteRunStats writes run_stats.json inside the run directory.
+func (r *RunDir) WriteRunStats(stats RunStats) error {
+	return WriteJSON(filepath.Join(r.Dir, "run_stats.json"), stats)
+}
+
+// ReadRunStats reads run_stats.json from a run directory. Runs written before
+// it existed have none; the error then matches os.ErrNotExist.
+func ReadRunStats(runDir string) (RunStats, error) {
+	var stats RunStats
+	b, err := os.ReadFile(filepath.Join(runDir, "run_stats.json"))
+	if err != nil {
+		return stats, err
+	}
+	err = json.Unmarshal(b, &stats)
+	return stats, err
+}
+
+// Values returns the stats keyed by json name, for filtering alongside params
+// and metrics (see RunValues). Stats left out of the file are left out here.
+func (s RunStats) Values() map[string]float64 {
+	values := map[string]float64{"step_count": float64(s.StepCount)}
+	if s.WallTimeSeconds != 0 {
+		values["wall_time_seconds"] = s.WallTimeSeconds
+	}
+	if s.RealtimeFactor != 0 {
+		values["realtime_factor"] = s.RealtimeFactor
+	}
+	return values
+}
@@ -0,0 +1,67 @@
+package artifacts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStats_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      RunStats
+		wantKeys   []string
+		absentKeys []string
+	}{
+		{"timed", RunStats{StepCount: 1000, WallTimeSeconds: 0.25, RealtimeFactor: 40},
+			[]string{"step_count", "wall_time_seconds", "realtime_factor"}, nil},
+		{"reproducible", RunStats{StepCount: 200},
+			[]string{"step_count"}, []string{"wall_time_seconds", "realtime_factor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			run := RunDir{Dir: dir}
+			if err := run.WriteRunStats(tt.stats); err != nil {
+				t.Fatalf("WriteRunStats() error = %v", err)
+			}
+			got, err := ReadRunStats(dir)
+			if err != nil {
+				t.Fatalf("ReadRunStats() error = %v", err)
+			}
+			if got != tt.stats {
+				t.Errorf("ReadRunStats() = %+v, want %+v", got, tt.stats)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "run_stats.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			values := got.Values()
+			for _, k := range tt.wantKeys {
+				if !strings.Contains(string(data), `"`+k+`"`) {
+					t.Errorf("run_stats.json misses %s:\n%s", k, data)
+				}
+				if _, ok := values[k]; !ok {
+					t.Errorf("Values() misses %s", k)
+				}
+			}
+			for _, k := range tt.absentKeys {
+				if strings.Contains(string(data), k) {
+					t.Errorf("run_stats.json has %s:\n%s", k, data)
+				}
+				if _, ok := values[k]; ok {
+					t.Errorf("Values() has %s", k)
+				}
+			}
+		})
+	}
+}
+
+func TestReadRunStats_Missing(t *testing.T) {
+	if _, err := ReadRunStats(t.TempDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadRunStats() of a run without stats = %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,68 @@
+package artifacts
+
+// DefaultUnits returns the unit of each samples.csv and debug.csv column, known
+// signal, metrics.json and run_stats.json field, keyed by column or json name. Dimensionless values use
+// "1", percentages "%", and boolean flags "bool".
+//
+// A fresh map is returned on every call, so callers may extend it.
+func DefaultUnits() map[string]string {
+	return map[string]string{
+		// samples.csv columns
+		"t":          "s",
+		"dt":         "s",
+		"target":     "rpm",
+		"actual":     "rpm",
+		"error":      "rpm",
+		"u":          "v",
+		"p":          "v",
+		"i":          "v",
+		"d":          "v",
+		"out_raw":    "v",
+		"saturated":  "bool",
+		"integrated": "bool",
+
+		// debug.csv columns (beyond the samples.csv ones)
+		"ff":                 "v",
+		"out":                "v",
+		"integral":           "rpm*s",
+		"derivative_skipped": "bool",
+
+		// signals
+		"disturbance_rpm_per_s":  "rpm/s",
+		"current_cmd_a":          "a",
+		"current_limit_active":   "bool",
+		"velocity_unlimited_rpm": "rpm",
+		"velocity_limit_active":  "bool",
+		"u_clamped":              "bool",
+		"feedforward_v":          "v",
+		"integral_reset":         "bool",
+		"gain_rpm_per_volt":      "rpm/v",
+		"measurement_noise_rpm":  "rpm",
+		"velocity_rpm":           "rpm",
+
+		// metrics.json (target is shared with the CSV column)
+		"max_actual":               "rpm",
+		"min_actual":               "rpm",
+		"overshoot_percent":        "%",
+		"steady_state_error":       "rpm",
+		"iae":                      "rpm*s",
+		"settling_time_seconds":    "s",
+		"saturation_fraction":      "1",
+		"saturation_high_fraction": "1",
+		"saturation_low_fraction":  "1",
+		"max_control_rate":         "v/s",
+		"accelerating_fraction":    "1",
+		"decelerating_fraction":    "1",
+		"mean_u":                   "v",
+		"max_u":                    "v",
+		"min_u":                    "v",
+		"out_min":                  "v",
+		"out_max":                  "v",
+		"min_headroom":             "v",
+
+		// run_stats.json
+		"step_count":        "1",
+		"wall_time_seconds": "s",
+		"realtime_factor":   "1",
+	}
+}
@@ -0,0 +1,59 @@
+package bangbang
+
+// Controller is an on/off (bang-bang) controller: its output is either OutMax
+// ("on") or OutMin ("off"), depending on the sign of the error target - actual.
+//
+// Pure switching (Lower = Upper = 0) flips the output whenever the error changes
+// sign, which chatters at the setpoint, in a discrete-time loop often every
+// step. Two mechanisms reduce that:
+//
+//   - Hysteresis: the output switches on only once the error exceeds Upper and
+//     off only once it drops below Lower, so inside the band [Lower, Upper]
+//     (Lower <= 0 <= Upper) the previous output is kept.
+//   - MinDwell: after a switch the output is held for at least MinDwell seconds,
+//     bounding the switching frequency at 1/MinDwell.
+//
+// The controller starts off; the first switch is not delayed by MinDwell.
+type Controller struct {
+	OutMin, OutMax float64
+
+	Lower, Upper float64 // hysteresis thresholds on the error
+	MinDwell     float64 // minimum time (s) between switches; zero disables it
+
+	on          bool
+	switched    bool    // whether the output has switched yet
+	sinceSwitch float64 // time since the last switch (s)
+}
+
+// New returns a pure-switching controller between outMin and outMax.
+func New(outMin, outMax float64) *Controller {
+	return &Controller{OutMin: outMin, OutMax: outMax}
+}
+
+// On reports whether the output is currently OutMax.
+func (c *Controller) On() bool {
+	return c.on
+}
+
+// Step updates the switching state for the current error and returns the output.
+func (c *Controller) Step(target, actual, dt float64) float64 {
+	err := target - actual
+	c.sinceSwitch += dt
+
+	if c.switched && c.sinceSwitch < c.MinDwell {
+		return c.output()
+	}
+	if (!c.on && err > c.Upper) || (c.on && err < c.Lower) {
+		c.on = !c.on
+		c.switched = true
+		c.sinceSwitch = 0
+	}
+	return c.output()
+}
+
+func (c *Controller) output() float64 {
+	if c.on {
+		return c.OutMax
+	}
+	return c.OutMin
+}
@@ -0,0 +1,95 @@
+package bangbang
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestHysteresisBand(t *testing.T) {
+	c := New(-1, 1)
+	c.Lower, c.Upper = -5, 5
+
+	tests := []struct {
+		err  float64
+		want float64
+	}{
+		{3, -1},  // inside the band: stays off
+		{6, 1},   // above Upper: on
+		{0, 1},   // back inside the band: stays on
+		{-4, 1},  // still inside
+		{-6, -1}, // below Lower: off
+		{4, -1},  // inside again: stays off
+	}
+	for i, tt := range tests {
+		if got := c.Step(tt.err, 0, 0.01); got != tt.want {
+			t.Errorf("step %d (err=%v): output = %v, want %v", i, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMinDwell(t *testing.T) {
+	c := New(0, 1)
+	c.MinDwell = 0.05
+
+	if c.Step(1, 0, 0.01) != 1 {
+		t.Fatal("first switch should not wait for MinDwell")
+	}
+	// The error flips immediately, but the output is held for 0.05 s
+	for i := 1; i < 5; i++ {
+		if got := c.Step(-1, 0, 0.01); got != 1 {
+			t.Fatalf("%.2f s after switching: output = %v, want held at 1", float64(i)*0.01, got)
+		}
+	}
+	if got := c.Step(-1, 0, 0.011); got != 0 {
+		t.Errorf("after MinDwell: output = %v, want 0", got)
+	}
+}
+
+// switchingFrequency runs c on a DC motor around a 1000 RPM setpoint and
+// returns the output switches per second over the last 4 s (after the rise).
+func switchingFrequency(c *Controller) float64 {
+	const (
+		dt       = 0.001
+		duration = 5.0
+		measureS = 4.0
+	)
+	m := sim.NewDCMotor()
+	n := int(duration / dt)
+	switches := 0
+	prev := math.NaN()
+	for i := 0; i < n; i++ {
+		u := c.Step(1000, m.Observe(), dt)
+		if float64(i)*dt >= duration-measureS && u != prev && !math.IsNaN(prev) {
+			switches++
+		}
+		prev = u
+		m.Actuate(u)
+		m.Step(dt)
+	}
+	return float64(switches) / measureS
+}
+
+func TestHysteresisReducesChatter(t *testing.T) {
+	pure := switchingFrequency(New(0, 24))
+
+	band := New(0, 24)
+	band.Lower, band.Upper = -20, 20
+	withBand := switchingFrequency(band)
+
+	dwell := New(0, 24)
+	dwell.MinDwell = 0.05
+	withDwell := switchingFrequency(dwell)
+
+	t.Logf("switching frequency: pure %.0f Hz, hysteresis %.0f Hz, dwell %.0f Hz", pure, withBand, withDwell)
+	if pure < 100 {
+		t.Errorf("pure switching at %.0f Hz, expected chatter", pure)
+	}
+	if withBand >= pure/10 {
+		t.Errorf("hysteresis: %.0f Hz, want well below pure switching (%.0f Hz)", withBand, pure)
+	}
+	if withDwell > 1/dwell.MinDwell {
+		t.Errorf("dwell: %.0f Hz, want at most %.0f Hz", withDwell, 1/dwell.MinDwell)
+	}
+}
@@ -1,43 +1,169 @@
 package pid
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 // Trace captures the internal terms of the PID controller for logging and
 // debugging. If you don't need tracing, pass nil to Controller.Step().
 //
 // The terms are expressed in the same units as the output (e.g., volts).
-// OutRaw is the sum before clamping; Out is the clamped output.
+// OutRaw is the sum before clamping (including FF); Out is the clamped output.
 type Trace struct {
 	Target float64
 	Actual float64
 	Error  float64
 
-	P float64
-	I float64
-	D float64
+	P  float64
+	I  float64
+	D  float64
+	FF float64 // feedforward term (zero without Controller.Feedforward)
 
 	OutRaw     float64
 	Out        float64
 	Saturated  bool
 	Integrated bool // whether the integrator was updated this step
+
+	DerivativeSkipped bool // whether the derivative was suppressed due to a dt glitch
+	IntegralReset     bool // whether a setpoint jump reset the integrator (see Controller.IntegralResetJump)
+
+	// Tracking is the change of the integral term (output units) made by the
+	// AntiWindupTracking correction this step; zero with other strategies.
+	Tracking float64
+}
+
+// AntiWindup selects how the integrator is kept from winding up while the output
+// is clamped.
+type AntiWindup int
+
+const (
+	// AntiWindupFreeze stops integrating when the predicted output is saturated
+	// in the same direction as the error (conditional integration). It is the default.
+	AntiWindupFreeze AntiWindup = iota
+	// AntiWindupBackCalc feeds the clamping excess back into the integrator with
+	// gain Kt (back-calculation), unwinding it while saturated.
+	AntiWindupBackCalc
+	// AntiWindupNone always integrates, regardless of saturation. The windup it
+	// shows is the baseline for before/after comparisons of the other strategies.
+	AntiWindupNone
+	// AntiWindupTracking is conditional integration with tracking: it integrates
+	// like AntiWindupFreeze, and while the output is still saturated it also
+	// relaxes the integrator towards the value that would just unsaturate it,
+	// with time constant 1/Kt. Unlike a hard freeze it does not hold a stale
+	// integral through a long saturation; unlike AntiWindupBackCalc it never
+	// integrates further into the limit.
+	AntiWindupTracking
+)
+
+var antiWindupNames = map[AntiWindup]string{
+	AntiWindupFreeze:   "freeze",
+	AntiWindupBackCalc: "back-calc",
+	AntiWindupNone:     "none",
+	AntiWindupTracking: "tracking",
 }
 
-// Controller is a classic PID controller with output clamping and basic anti-windup.
+func (a AntiWindup) String() string {
+	if name, ok := antiWindupNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("AntiWindup(%d)", int(a))
+}
+
+// ParseAntiWindup parses "freeze", "back-calc", "none" or "tracking".
+func ParseAntiWindup(s string) (AntiWindup, error) {
+	for a, name := range antiWindupNames {
+		if s == name {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown anti-windup strategy %q (want freeze, back-calc, none or tracking)", s)
+}
+
+// IntegralMethod selects how the error integral is discretized over a step of
+// length dt, with e_k the current and e_{k-1} the previous step's error.
+type IntegralMethod int
+
+const (
+	// IntegralBackward is backward Euler (rectangular, current error):
+	// integral += e_k*dt. It is the default and the original behavior.
+	IntegralBackward IntegralMethod = iota
+	// IntegralForward is forward Euler (rectangular, previous error):
+	// integral += e_{k-1}*dt.
+	IntegralForward
+	// IntegralTrapezoidal is the trapezoidal (Tustin) rule:
+	// integral += (e_k + e_{k-1})/2*dt.
+	IntegralTrapezoidal
+)
+
+// Controller is a classic PID controller with output clamping and selectable
+// anti-windup (see AntiWindup).
 //
-// Anti-windup strategy: freeze the integrator when the predicted output is saturated
-// in the same direction as the error.
+// The default strategy freezes the integrator when the predicted output is
+// saturated in the same direction as the error. With AntiWindupBackCalc the
+// integral term I instead follows dI/dt = Ki*e + Kt*(out - outRaw), where
+// out - outRaw is the clamping excess of the predicted output.
 //
 // This preserves the behavior of the original implementation, but uses clearer
 // names and an optional trace output.
+//
+// Every dt-dependent term (integral accumulation and the derivative difference
+// quotient) uses the dt passed to the current Step call, so a varying dt (e.g.,
+// in a realtime runner) is handled per step. MaxDTRatio additionally guards
+// against timing glitches: when dt changes by more than that factor relative to
+// the previous step, the derivative term is skipped for that step instead of
+// spiking. Zero disables the guard.
+//
+// IntegralMethod selects the integral discretization. On the first step there
+// is no previous error, so every method uses the current one.
+//
+// Feedforward, when set, maps the target to an output added to the PID terms
+// before clamping, typically a plant model's steady-state input (e.g.,
+// sim.DCMotor.SteadyStateVoltage). With a matched model the feedback terms only
+// correct the transient and model error, so the integrator has little to do.
+// Anti-windup accounts for the feedforward term like for the others.
+//
+// SatHysteresis, when > 0, is a band (output units) inside the limits for the
+// saturation decision: once saturated high, the controller counts as saturated
+// until the output drops below OutMax - SatHysteresis (likewise above OutMin +
+// SatHysteresis when saturated low). An output hovering at a limit then no
+// longer toggles Trace.Saturated and the freeze anti-windup every step. The
+// output itself is still clamped to the limits only.
+//
+// IntegralResetJump, when > 0, resets the integrator when the target changes
+// by more than that amount (target units) from one step to the next: the
+// integral accumulated for the old setpoint would otherwise push the response
+// past the new one. IntegralResetKeep is the fraction of the integrator kept
+// (0, the default, clears it; e.g. 0.5 halves it). The first Step has no
+// previous target and never resets.
 type Controller struct {
 	Kp, Ki, Kd float64
 
 	OutMin float64
 	OutMax float64
 
+	MaxDTRatio float64
+
+	AntiWindup AntiWindup
+	Kt         float64 // back-calculation gain (1/s), used by AntiWindupBackCalc and AntiWindupTracking
+
+	IntegralMethod IntegralMethod
+
+	Feedforward func(target float64) float64
+
+	SatHysteresis float64
+
+	IntegralResetJump float64
+	IntegralResetKeep float64
+
 	integral  float64
 	prevError float64
+	prevDT    float64
 	hasPrev   bool
+	satState  int // +1 saturated high, -1 low, 0 not; tracked when SatHysteresis > 0
+
+	prevTarget    float64
+	hasPrevTarget bool
 }
 
 func New(kp, ki, kd float64) *Controller {
@@ -50,6 +176,104 @@ func New(kp, ki, kd float64) *Controller {
 	}
 }
 
+// NewChecked is like New with the given output limits, but returns an error
+// instead of a controller that would silently produce a garbage run (see
+// Validate).
+func NewChecked(kp, ki, kd, outMin, outMax float64) (*Controller, error) {
+	c := New(kp, ki, kd)
+	c.OutMin, c.OutMax = outMin, outMax
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the gains and output limits: the gains must be finite, and
+// the limits must not be NaN or inverted (OutMin > OutMax). Infinite limits
+// are allowed and leave that side unclamped. IntegralResetKeep must be in
+// [0, 1].
+func (c *Controller) Validate() error {
+	for _, g := range []struct {
+		name string
+		v    float64
+	}{{"kp", c.Kp}, {"ki", c.Ki}, {"kd", c.Kd}} {
+		if math.IsNaN(g.v) || math.IsInf(g.v, 0) {
+			return fmt.Errorf("gain %s is %v, want a finite value", g.name, g.v)
+		}
+	}
+	if math.IsNaN(c.OutMin) || math.IsNaN(c.OutMax) {
+		return fmt.Errorf("output limits [%v, %v] must not be NaN", c.OutMin, c.OutMax)
+	}
+	if c.OutMin > c.OutMax {
+		return fmt.Errorf("output limits inverted: min %g > max %g", c.OutMin, c.OutMax)
+	}
+	if !(c.IntegralResetKeep >= 0 && c.IntegralResetKeep <= 1) {
+		return fmt.Errorf("integral reset keep fraction %g must be in [0, 1]", c.IntegralResetKeep)
+	}
+	return nil
+}
+
+// Clone returns an independent copy of the controller, including gains, limits,
+// and internal state (integrator and derivative memory). The clone continues
+// exactly where the original is, so sweeps can branch from a common warmed-up
+// controller.
+func (c *Controller) Clone() *Controller {
+	cp := *c
+	return &cp
+}
+
+// Integral returns the integrator state: the accumulated error integral
+// (error·seconds), so that the integral term is Ki*Integral().
+func (c *Controller) Integral() float64 {
+	return c.integral
+}
+
+// SetIntegral sets the integrator state (see Integral), e.g. to warm-start the
+// controller at the value that holds the plant at the setpoint.
+func (c *Controller) SetIntegral(v float64) {
+	c.integral = v
+}
+
+// PrevError returns the derivative memory: the previous step's error, and whether
+// there is one (false before the first Step, unless set with SetPrevError).
+func (c *Controller) PrevError() (float64, bool) {
+	return c.prevError, c.hasPrev
+}
+
+// SetPrevError sets the derivative memory: the error of the previous step. The
+// next Step then computes its derivative term from e, as if a step with error e
+// had just been taken.
+func (c *Controller) SetPrevError(e float64) {
+	c.prevError = e
+	c.hasPrev = true
+}
+
+// SaturationState returns the saturation state tracked for SatHysteresis: +1
+// when saturated at OutMax, -1 at OutMin and 0 when not saturated.
+func (c *Controller) SaturationState() int {
+	return c.satState
+}
+
+// SetSaturationState sets the saturation state (see SaturationState), so that
+// the next Step applies the SatHysteresis band as if the controller had been
+// saturated on that side.
+func (c *Controller) SetSaturationState(s int) {
+	c.satState = s
+}
+
+// PrevTarget returns the previous step's target, which IntegralResetJump
+// compares against, and whether there is one (false before the first Step,
+// unless set with SetPrevTarget).
+func (c *Controller) PrevTarget() (float64, bool) {
+	return c.prevTarget, c.hasPrevTarget
+}
+
+// SetPrevTarget sets the previous step's target (see PrevTarget).
+func (c *Controller) SetPrevTarget(target float64) {
+	c.prevTarget = target
+	c.hasPrevTarget = true
+}
+
 // Step computes the control output for the given target and measurement.
 //
 // If tr != nil, it is populated with the term breakdown and clamping info.
@@ -65,29 +289,69 @@ func (c *Controller) Step(target, actual, dt float64, tr *Trace) float64 {
 	pTerm := c.Kp * err
 
 	dTerm := 0.0
+	dSkipped := false
 	if c.hasPrev {
-		dTerm = c.Kd * (err - c.prevError) / dt
+		if c.dtGlitch(dt) {
+			dSkipped = true
+		} else {
+			dTerm = c.Kd * (err - c.prevError) / dt
+		}
+	}
+
+	ffTerm := 0.0
+	if c.Feedforward != nil {
+		ffTerm = c.Feedforward(target)
+	}
+
+	reset := c.IntegralResetJump > 0 && c.hasPrevTarget && math.Abs(target-c.prevTarget) > c.IntegralResetJump
+	if reset {
+		c.integral *= c.IntegralResetKeep
 	}
 
 	// Predict saturation using the current integrator state.
-	outNoI := pTerm + dTerm
+	outNoI := pTerm + dTerm + ffTerm
 	outPred := outNoI + c.Ki*c.integral
 
-	satHigh := outPred >= c.OutMax
-	satLow := outPred <= c.OutMin
+	// With hysteresis, the saturated side extends SatHysteresis into the range
+	satHigh := outPred >= c.OutMax || (c.satState > 0 && outPred > c.OutMax-c.SatHysteresis)
+	satLow := outPred <= c.OutMin || (c.satState < 0 && outPred < c.OutMin+c.SatHysteresis)
 
 	integrated := true
-	if (satHigh && err > 0) || (satLow && err < 0) {
-		// Would wind up further into saturation.
-		integrated = false
-	} else {
-		c.integral += err * dt
+	tracking := 0.0
+	inc := c.integralIncrement(err, dt)
+	switch c.AntiWindup {
+	case AntiWindupBackCalc:
+		excess := clamp(outPred, c.OutMin, c.OutMax) - outPred
+		if c.Ki != 0 {
+			// integral stores ∫e, so the Kt feedback is scaled back by 1/Ki
+			c.integral += inc + c.Kt*excess/c.Ki*dt
+		}
+	case AntiWindupNone:
+		c.integral += inc
+	case AntiWindupTracking:
+		if (satHigh && err > 0) || (satLow && err < 0) {
+			integrated = false
+		} else {
+			c.integral += inc
+		}
+		tracking = c.track(outNoI, dt)
+	default:
+		if (satHigh && err > 0) || (satLow && err < 0) {
+			// Would wind up further into saturation.
+			integrated = false
+		} else {
+			c.integral += inc
+		}
 	}
 
 	iTerm := c.Ki * c.integral
 
-	outRaw := pTerm + iTerm + dTerm
+	outRaw := pTerm + iTerm + dTerm + ffTerm
 	out := clamp(outRaw, c.OutMin, c.OutMax)
+	saturated := out != outRaw
+	if c.SatHysteresis > 0 {
+		saturated = c.updateSaturation(outRaw)
+	}
 
 	if tr != nil {
 		*tr = Trace{
@@ -97,18 +361,83 @@ func (c *Controller) Step(target, actual, dt float64, tr *Trace) float64 {
 			P:          pTerm,
 			I:          iTerm,
 			D:          dTerm,
+			FF:         ffTerm,
 			OutRaw:     outRaw,
 			Out:        out,
-			Saturated:  out != outRaw,
+			Saturated:  saturated,
 			Integrated: integrated,
+
+			DerivativeSkipped: dSkipped,
+			IntegralReset:     reset,
+			Tracking:          tracking,
 		}
 	}
 
+	c.prevTarget = target
+	c.hasPrevTarget = true
 	c.prevError = err
+	c.prevDT = dt
 	c.hasPrev = true
 	return out
 }
 
+// track relaxes the integrator of AntiWindupTracking towards the value that
+// puts outNoI + Ki*integral exactly at the exceeded limit, by the fraction
+// 1 - exp(-Kt*dt), and returns the change of the integral term. It does
+// nothing while the output is within the limits.
+func (c *Controller) track(outNoI, dt float64) float64 {
+	out := outNoI + c.Ki*c.integral
+	if c.Ki == 0 || (out <= c.OutMax && out >= c.OutMin) {
+		return 0
+	}
+	target := (clamp(out, c.OutMin, c.OutMax) - outNoI) / c.Ki
+	before := c.integral
+	c.integral += (1 - math.Exp(-c.Kt*dt)) * (target - c.integral)
+	return c.Ki * (c.integral - before)
+}
+
+// updateSaturation updates the saturation state for outRaw with the
+// SatHysteresis band and reports whether the controller is saturated.
+func (c *Controller) updateSaturation(outRaw float64) bool {
+	switch {
+	case outRaw > c.OutMax || (c.satState > 0 && outRaw > c.OutMax-c.SatHysteresis):
+		c.satState = 1
+	case outRaw < c.OutMin || (c.satState < 0 && outRaw < c.OutMin+c.SatHysteresis):
+		c.satState = -1
+	default:
+		c.satState = 0
+	}
+	return c.satState != 0
+}
+
+// integralIncrement returns the error integral over this step for the configured IntegralMethod.
+func (c *Controller) integralIncrement(err, dt float64) float64 {
+	prev := err
+	if c.hasPrev {
+		prev = c.prevError
+	}
+	switch c.IntegralMethod {
+	case IntegralForward:
+		return prev * dt
+	case IntegralTrapezoidal:
+		return 0.5 * (err + prev) * dt
+	default:
+		return err * dt
+	}
+}
+
+// dtGlitch reports whether dt differs from the previous step's dt by more than MaxDTRatio.
+func (c *Controller) dtGlitch(dt float64) bool {
+	if c.MaxDTRatio <= 0 || c.prevDT <= 0 {
+		return false
+	}
+	ratio := dt / c.prevDT
+	if ratio < 1 {
+		ratio = 1 / ratio
+	}
+	return ratio > c.MaxDTRatio
+}
+
 func clamp(x, lo, hi float64) float64 {
 	return math.Min(math.Max(x, lo), hi)
 }
@@ -2,6 +2,7 @@ package pid
 
 import (
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -206,3 +207,536 @@ func TestTraceFieldsAreConsistent(t *testing.T) {
 		t.Errorf("Actual = %v, want %v", tr.Actual, actual)
 	}
 }
+
+func TestCloneProducesIdenticalOutputs(t *testing.T) {
+	ctrl := New(0.05, 0.5, 0.01)
+	dt := 0.01
+
+	// Warm up so integral and derivative state are non-trivial
+	for i := 0; i < 20; i++ {
+		ctrl.Step(100.0, float64(i)*2.0, dt, nil)
+	}
+
+	clone := ctrl.Clone()
+
+	for i := 0; i < 20; i++ {
+		actual := 40.0 + float64(i)
+		var trOrig, trClone Trace
+		outOrig := ctrl.Step(100.0, actual, dt, &trOrig)
+		outClone := clone.Step(100.0, actual, dt, &trClone)
+
+		if outOrig != outClone {
+			t.Fatalf("step %d: clone output = %v, original = %v", i, outClone, outOrig)
+		}
+		if trOrig != trClone {
+			t.Fatalf("step %d: clone trace = %+v, original = %+v", i, trClone, trOrig)
+		}
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	ctrl := New(0.05, 0.5, 0.01)
+	ctrl.Step(100.0, 0.0, 0.01, nil)
+
+	integral := ctrl.integral
+	prevError := ctrl.prevError
+
+	clone := ctrl.Clone()
+	clone.Kp = 10.0
+	clone.OutMax = 1.0
+	for i := 0; i < 10; i++ {
+		clone.Step(100.0, 50.0, 0.01, nil)
+	}
+
+	if ctrl.Kp != 0.05 {
+		t.Errorf("original Kp = %v, want 0.05 (mutated through clone)", ctrl.Kp)
+	}
+	if ctrl.OutMax != 24.0 {
+		t.Errorf("original OutMax = %v, want 24.0 (mutated through clone)", ctrl.OutMax)
+	}
+	if ctrl.integral != integral {
+		t.Errorf("original integral = %v, want %v (mutated through clone)", ctrl.integral, integral)
+	}
+	if ctrl.prevError != prevError {
+		t.Errorf("original prevError = %v, want %v (mutated through clone)", ctrl.prevError, prevError)
+	}
+}
+
+func TestDerivativeSuppressedOnDTSpike(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxDTRatio  float64
+		wantSkipped bool
+	}{
+		{name: "guard enabled", maxDTRatio: 5.0, wantSkipped: true},
+		{name: "guard disabled", maxDTRatio: 0.0, wantSkipped: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := New(0, 0, 1.0)
+			ctrl.MaxDTRatio = tt.maxDTRatio
+
+			// Steady cadence: error decreases by 1 per 10ms step, D = -100
+			var tr Trace
+			ctrl.Step(100.0, 0.0, 0.01, &tr)
+			ctrl.Step(100.0, 1.0, 0.01, &tr)
+			if math.Abs(tr.D-(-100.0)) > eps {
+				t.Fatalf("steady D = %v, want -100", tr.D)
+			}
+
+			// Timing glitch: dt collapses 100x while the error changes by the same amount
+			ctrl.Step(100.0, 2.0, 0.0001, &tr)
+
+			if tr.DerivativeSkipped != tt.wantSkipped {
+				t.Errorf("DerivativeSkipped = %v, want %v", tr.DerivativeSkipped, tt.wantSkipped)
+			}
+			if tt.wantSkipped {
+				if tr.D != 0 {
+					t.Errorf("D during spike = %v, want 0 (suppressed)", tr.D)
+				}
+			} else if math.Abs(tr.D-(-10000.0)) > 1e-6 {
+				t.Errorf("D during spike = %v, want -10000 (unguarded)", tr.D)
+			}
+
+			// Back to the nominal dt: the ratio to the glitch step is large again,
+			// so the guard skips once more, then recovers on the following step.
+			ctrl.Step(100.0, 3.0, 0.01, &tr)
+			ctrl.Step(100.0, 4.0, 0.01, &tr)
+			if tr.DerivativeSkipped {
+				t.Error("derivative still skipped after dt recovered")
+			}
+			if math.Abs(tr.D-(-100.0)) > eps {
+				t.Errorf("D after recovery = %v, want -100", tr.D)
+			}
+		})
+	}
+}
+
+func TestDerivativeUsesCurrentDT(t *testing.T) {
+	ctrl := New(0, 0, 1.0)
+	ctrl.MaxDTRatio = 5.0
+
+	var tr Trace
+	ctrl.Step(100.0, 0.0, 0.01, &tr)
+	// dt doubles (within the ratio): derivative uses the new dt
+	ctrl.Step(100.0, 1.0, 0.02, &tr)
+
+	if tr.DerivativeSkipped {
+		t.Fatal("derivative skipped for a dt change within MaxDTRatio")
+	}
+	if math.Abs(tr.D-(-50.0)) > eps {
+		t.Errorf("D = %v, want -50 (error change / current dt)", tr.D)
+	}
+}
+
+func TestController_SetIntegral(t *testing.T) {
+	c := New(0.5, 2.0, 0)
+	c.SetIntegral(3.0)
+	if got := c.Integral(); got != 3.0 {
+		t.Fatalf("Integral() = %v, want 3", got)
+	}
+
+	// At zero error the output is the integral term alone
+	var tr Trace
+	out := c.Step(100, 100, 0.01, &tr)
+	if math.Abs(out-6.0) > 1e-12 || math.Abs(tr.I-6.0) > 1e-12 {
+		t.Errorf("output = %v (I = %v), want Ki*integral = 6", out, tr.I)
+	}
+}
+
+func TestAntiWindupBackCalcUnwindsIntegral(t *testing.T) {
+	c := New(0, 1.0, 0)
+	c.OutMax = 2.0
+	c.AntiWindup = AntiWindupBackCalc
+	c.Kt = 10
+	c.SetIntegral(5) // I term 5 V, 3 V above the limit
+
+	// Zero error: only the back-calculation feedback moves the integral
+	var tr Trace
+	c.Step(100, 100, 0.01, &tr)
+	want := 5 + 10*(2.0-5.0)/1.0*0.01
+	if math.Abs(c.Integral()-want) > 1e-12 || !tr.Integrated {
+		t.Errorf("integral = %v (integrated %v), want %v", c.Integral(), tr.Integrated, want)
+	}
+
+	// Saturated with persistent error, the I term settles where Ki*e balances Kt*excess
+	for i := 0; i < 5000; i++ {
+		c.Step(1, 0, 0.01, &tr)
+	}
+	if settled := 2.0 + 1.0/10; math.Abs(tr.I-settled) > 1e-6 {
+		t.Errorf("settled I = %v, want %v", tr.I, settled)
+	}
+}
+
+func TestAntiWindupTrackingRelaxesIntegral(t *testing.T) {
+	c := New(0, 1.0, 0)
+	c.OutMax = 2.0
+	c.AntiWindup = AntiWindupTracking
+	c.Kt = 10
+	c.SetIntegral(5) // I term 5 V, 3 V above the limit
+
+	// Zero error: the integral relaxes towards 2 by 1 - exp(-Kt*dt)
+	var tr Trace
+	c.Step(100, 100, 0.01, &tr)
+	want := 5 + (1-math.Exp(-0.1))*(2.0-5.0)
+	if math.Abs(c.Integral()-want) > 1e-12 || math.Abs(tr.Tracking-(want-5)) > 1e-12 {
+		t.Errorf("integral = %v (tracking %v), want %v (%v)", c.Integral(), tr.Tracking, want, want-5)
+	}
+
+	// Saturated with persistent error it neither integrates nor overshoots the
+	// unsaturating value, unlike back-calculation
+	for i := 0; i < 5000; i++ {
+		c.Step(1, 0, 0.01, &tr)
+		if tr.Integrated {
+			t.Fatalf("step %d integrated while saturated in the error direction", i)
+		}
+	}
+	if math.Abs(tr.I-2.0) > 1e-6 || math.Abs(tr.Tracking) > 1e-6 {
+		t.Errorf("settled I = %v (tracking %v), want the limit 2 and no correction", tr.I, tr.Tracking)
+	}
+
+	// Within the limits the tracking is inactive
+	c.SetIntegral(1)
+	c.Step(100, 100, 0.01, &tr)
+	if tr.Tracking != 0 || c.Integral() != 1 {
+		t.Errorf("unsaturated: integral = %v (tracking %v), want 1 unchanged", c.Integral(), tr.Tracking)
+	}
+}
+
+func TestAntiWindupTrackingRecovery(t *testing.T) {
+	// A 2000 RPM step on a first-order plant (100 RPM/V, tau 0.5 s) needs
+	// 20 V at steady state; P alone saturates the 24 V output early in the rise.
+	run := func(mode AntiWindup, kt float64) (overshoot, iae float64) {
+		c := New(0.02, 0.5, 0)
+		c.OutMin, c.OutMax = -24, 24
+		c.AntiWindup = mode
+		c.Kt = kt
+		y, dt := 0.0, 0.001
+		for i := 0; i < 10000; i++ {
+			u := c.Step(2000, y, dt, nil)
+			y += dt / 0.5 * (100*u - y)
+			overshoot = math.Max(overshoot, y-2000)
+			iae += math.Abs(2000-y) * dt
+		}
+		return overshoot, iae
+	}
+
+	freezeOS, freezeIAE := run(AntiWindupFreeze, 0)
+	backOS, backIAE := run(AntiWindupBackCalc, 1)
+	trackOS, trackIAE := run(AntiWindupTracking, 1)
+	t.Logf("overshoot/IAE: freeze %.2f/%.1f, back-calc %.2f/%.1f, tracking %.2f/%.1f",
+		freezeOS, freezeIAE, backOS, backIAE, trackOS, trackIAE)
+
+	// With the same Kt, tracking recovers like a freeze rather than carrying the
+	// windup back-calculation accumulates before its feedback catches up
+	if trackOS >= backOS || trackIAE >= backIAE {
+		t.Errorf("tracking overshoot/IAE %v/%v, want below back-calc's %v/%v", trackOS, trackIAE, backOS, backIAE)
+	}
+	if trackOS > freezeOS*1.01+1e-9 || trackIAE > freezeIAE*1.01 {
+		t.Errorf("tracking overshoot/IAE %v/%v, want no worse than freeze's %v/%v", trackOS, trackIAE, freezeOS, freezeIAE)
+	}
+}
+
+func TestParseAntiWindup(t *testing.T) {
+	for _, a := range []AntiWindup{AntiWindupFreeze, AntiWindupBackCalc, AntiWindupNone, AntiWindupTracking} {
+		got, err := ParseAntiWindup(a.String())
+		if err != nil || got != a {
+			t.Errorf("ParseAntiWindup(%q) = %v, %v, want %v", a.String(), got, err, a)
+		}
+	}
+	if _, err := ParseAntiWindup("clamp"); err == nil {
+		t.Error("ParseAntiWindup(\"clamp\") should fail")
+	}
+}
+
+func TestNewChecked(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	tests := []struct {
+		name           string
+		kp, ki, kd     float64
+		outMin, outMax float64
+		wantErr        string // empty: valid
+	}{
+		{"valid", 0.02, 0.05, 0.001, -24, 24, ""},
+		{"zero gains", 0, 0, 0, -24, 24, ""},
+		{"asymmetric limits", 0.02, 0.05, 0, 0, 12, ""},
+		{"equal limits", 0.02, 0.05, 0, 5, 5, ""},
+		{"unlimited", 0.02, 0.05, 0, -inf, inf, ""},
+		{"NaN kp", nan, 0.05, 0, -24, 24, "gain kp is NaN"},
+		{"infinite ki", 0.02, inf, 0, -24, 24, "gain ki is +Inf"},
+		{"negative infinite kd", 0.02, 0.05, -inf, -24, 24, "gain kd is -Inf"},
+		{"inverted limits", 0.02, 0.05, 0, 24, -24, "inverted"},
+		{"NaN limit", 0.02, 0.05, 0, nan, 24, "must not be NaN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewChecked(tt.kp, tt.ki, tt.kd, tt.outMin, tt.outMax)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("NewChecked() error = %v", err)
+				}
+				if c.Kp != tt.kp || c.Ki != tt.ki || c.Kd != tt.kd || c.OutMin != tt.outMin || c.OutMax != tt.outMax {
+					t.Errorf("NewChecked() = %+v, want the given gains and limits", c)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewChecked() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+			if c != nil {
+				t.Error("NewChecked() returned a controller with an error")
+			}
+		})
+	}
+}
+
+func TestValidate_AfterConstruction(t *testing.T) {
+	c := New(0.02, 0.05, 0)
+	if err := c.Validate(); err != nil {
+		t.Errorf("New() defaults: Validate() = %v", err)
+	}
+	c.OutMin, c.OutMax = 10, -10
+	if err := c.Validate(); err == nil {
+		t.Error("Validate() with limits changed after New should fail")
+	}
+
+	c = New(0.02, 0.05, 0)
+	c.IntegralResetKeep = 1.5
+	if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "keep fraction") {
+		t.Errorf("Validate() with IntegralResetKeep 1.5 = %v, want an error", err)
+	}
+}
+
+func TestAntiWindupNoneIntegratesThroughSaturation(t *testing.T) {
+	run := func(mode AntiWindup) (integral float64, allIntegrated bool) {
+		c := New(0.1, 1.0, 0)
+		c.OutMax = 2.0
+		c.AntiWindup = mode
+		allIntegrated = true
+		// Sustained saturation: the measurement never moves towards the target
+		for i := 0; i < 1000; i++ {
+			var tr Trace
+			c.Step(100, 0, 0.01, &tr)
+			allIntegrated = allIntegrated && tr.Integrated
+		}
+		return c.Integral(), allIntegrated
+	}
+
+	// 10 s of error 100: unmitigated, the integral is the full ∫e dt = 1000
+	none, integrated := run(AntiWindupNone)
+	if math.Abs(none-1000) > 1e-6 || !integrated {
+		t.Errorf("none: integral = %v (integrated every step: %v), want 1000", none, integrated)
+	}
+
+	// Freeze stops as soon as the output saturates (P alone is already 10 V)
+	if freeze, _ := run(AntiWindupFreeze); freeze > 2.0 {
+		t.Errorf("freeze: integral = %v, want it bounded by the output limit", freeze)
+	}
+}
+
+func TestIntegralMethodsConvergeToSameSteadyState(t *testing.T) {
+	for _, m := range []IntegralMethod{IntegralBackward, IntegralForward, IntegralTrapezoidal} {
+		c := New(0.02, 0.05, 0)
+		c.IntegralMethod = m
+
+		// First-order plant: 100 RPM/V, tau 0.5 s
+		y, dt := 0.0, 0.001
+		for i := 0; i < 20000; i++ {
+			u := c.Step(1000, y, dt, nil)
+			y += dt / 0.5 * (100*u - y)
+		}
+		if math.Abs(y-1000) > 1e-3 {
+			t.Errorf("method %d: final output = %v, want 1000", m, y)
+		}
+	}
+}
+
+func TestIntegralMethodsOnRamp(t *testing.T) {
+	// Open loop with a ramp error e(t) = t: the exact integral is t²/2
+	const dt, steps = 0.01, 100
+	exact := 0.5 * (dt * steps) * (dt * steps)
+
+	integrate := func(m IntegralMethod) float64 {
+		c := New(0, 1, 0)
+		c.IntegralMethod = m
+		c.OutMin, c.OutMax = math.Inf(-1), math.Inf(1)
+		for k := 0; k <= steps; k++ {
+			c.Step(float64(k)*dt, 0, dt, nil)
+		}
+		return c.Integral()
+	}
+
+	backward := math.Abs(integrate(IntegralBackward) - exact)
+	forward := math.Abs(integrate(IntegralForward) - exact)
+	trapezoidal := math.Abs(integrate(IntegralTrapezoidal) - exact)
+	if trapezoidal > 1e-12 {
+		t.Errorf("trapezoidal error = %v, want exact on a ramp", trapezoidal)
+	}
+	if trapezoidal >= backward || trapezoidal >= forward {
+		t.Errorf("trapezoidal error %v, want below backward (%v) and forward (%v)", trapezoidal, backward, forward)
+	}
+}
+
+func TestController_PrevError(t *testing.T) {
+	c := New(0, 0, 0.5)
+	if _, ok := c.PrevError(); ok {
+		t.Fatal("PrevError() reports a previous error before the first step")
+	}
+
+	// Without derivative memory the first step has no derivative kick
+	var tr Trace
+	c.Clone().Step(100, 90, 0.01, &tr)
+	if tr.D != 0 {
+		t.Fatalf("first-step D = %v, want 0", tr.D)
+	}
+
+	c.SetPrevError(4)
+	if e, ok := c.PrevError(); !ok || e != 4 {
+		t.Fatalf("PrevError() = %v, %v, want 4, true", e, ok)
+	}
+	c.Step(100, 90, 0.01, &tr)
+	if want := 0.5 * (10.0 - 4.0) / 0.01; math.Abs(tr.D-want) > 1e-9 {
+		t.Errorf("D = %v, want Kd*(e - prevError)/dt = %v", tr.D, want)
+	}
+	if e, ok := c.PrevError(); !ok || e != 10 {
+		t.Errorf("PrevError() after Step = %v, %v, want 10, true", e, ok)
+	}
+}
+
+func TestFeedforward(t *testing.T) {
+	c := New(0.1, 0, 0)
+	c.Feedforward = func(target float64) float64 { return target / 100 }
+
+	var tr Trace
+	out := c.Step(1000, 950, 0.01, &tr)
+	if tr.FF != 10 || tr.P != 5 {
+		t.Fatalf("FF = %v, P = %v, want 10 and 5", tr.FF, tr.P)
+	}
+	if out != 15 || tr.OutRaw != 15 {
+		t.Errorf("out = %v, OutRaw = %v, want the sum 15", out, tr.OutRaw)
+	}
+
+	// The feedforward term counts towards saturation and anti-windup
+	c = New(0.1, 1, 0)
+	c.Feedforward = func(float64) float64 { return 30 }
+	out = c.Step(1000, 950, 0.01, &tr)
+	if out != c.OutMax || !tr.Saturated || tr.Integrated || c.Integral() != 0 {
+		t.Errorf("out = %v, saturated = %v, integrated = %v, integral = %v; want clamped at %v with the integrator frozen",
+			out, tr.Saturated, tr.Integrated, c.Integral(), c.OutMax)
+	}
+
+	if c := New(1, 0, 0); c.Step(10, 0, 0.01, &tr) != 10 || tr.FF != 0 {
+		t.Errorf("without Feedforward: FF = %v, want 0", tr.FF)
+	}
+}
+
+func TestSatHysteresis(t *testing.T) {
+	// The output dithers just around OutMax = 10, then drops well inside
+	dither := []float64{10.2, 9.9, 10.1, 9.95, 10.3, 9.8, 10.05, 9.9}
+	errors := append(append([]float64{}, dither...), 9, 8)
+
+	tests := []struct {
+		name          string
+		hysteresis    float64
+		wantSaturated []bool
+	}{
+		{"off", 0, []bool{true, false, true, false, true, false, true, false, false, false}},
+		{"0.5 band", 0.5, []bool{true, true, true, true, true, true, true, true, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A tiny Ki keeps the integral term negligible but exercises the freeze
+			c := New(1, 1e-6, 0)
+			c.OutMin, c.OutMax = -10, 10
+			c.SatHysteresis = tt.hysteresis
+
+			for i, e := range errors {
+				var tr Trace
+				out := c.Step(e, 0, 0.01, &tr)
+				if tr.Saturated != tt.wantSaturated[i] {
+					t.Errorf("step %d (out_raw=%.4f): Saturated = %v, want %v", i, tr.OutRaw, tr.Saturated, tt.wantSaturated[i])
+				}
+				// The freeze follows the same decision: no integration while saturated
+				if tr.Integrated == tr.Saturated {
+					t.Errorf("step %d: Integrated = %v with Saturated = %v", i, tr.Integrated, tr.Saturated)
+				}
+				if out > c.OutMax {
+					t.Errorf("step %d: output %v exceeds OutMax", i, out)
+				}
+			}
+		})
+	}
+}
+
+func TestSatHysteresis_LowSide(t *testing.T) {
+	c := New(1, 0, 0)
+	c.OutMin, c.OutMax = -10, 10
+	c.SatHysteresis = 1
+
+	var tr Trace
+	for i, tc := range []struct {
+		err  float64
+		want bool
+	}{
+		{-11, true}, {-9.5, true}, {-10.5, true}, {-8.5, false}, {-9.5, false},
+	} {
+		c.Step(tc.err, 0, 0.01, &tr)
+		if tr.Saturated != tc.want {
+			t.Errorf("step %d (out_raw=%v): Saturated = %v, want %v", i, tr.OutRaw, tr.Saturated, tc.want)
+		}
+	}
+}
+
+func TestIntegralResetOnTargetJump(t *testing.T) {
+	tests := []struct {
+		name      string
+		jump      float64
+		keep      float64
+		newTarget float64
+		wantReset bool
+	}{
+		{"jump clears", 50, 0, 200, true},
+		{"jump keeps half", 50, 0.5, 200, true},
+		{"small change", 50, 0, 140, false},
+		{"downward jump", 50, 0, 0, true},
+		{"disabled", 0, 0, 200, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(0, 1, 0)
+			c.OutMin, c.OutMax = -1e9, 1e9
+			c.IntegralResetJump, c.IntegralResetKeep = tt.jump, tt.keep
+			var tr Trace
+			for i := 0; i < 10; i++ {
+				c.Step(100, 90, 0.1, &tr) // integral = 10 * 10 * 0.1 = 10
+				if tr.IntegralReset {
+					t.Fatalf("step %d: reset without a target change", i)
+				}
+			}
+			before := c.Integral()
+
+			c.Step(tt.newTarget, 90, 0.1, &tr)
+			inc := (tt.newTarget - 90) * 0.1
+			want := before + inc
+			if tt.wantReset {
+				want = before*tt.keep + inc
+			}
+			if tr.IntegralReset != tt.wantReset || math.Abs(c.Integral()-want) > eps {
+				t.Errorf("IntegralReset = %v, integral = %v; want %v, %v", tr.IntegralReset, c.Integral(), tt.wantReset, want)
+			}
+		})
+	}
+}
+
+func TestIntegralReset_FirstStepAndWarmStart(t *testing.T) {
+	c := New(0, 1, 0)
+	c.IntegralResetJump = 1
+	c.SetIntegral(5) // warm start: there is no previous target to jump from
+	var tr Trace
+	c.Step(1000, 0, 0.1, &tr)
+	if tr.IntegralReset || c.Integral() < 5 {
+		t.Errorf("first step: IntegralReset = %v, integral = %v; want no reset", tr.IntegralReset, c.Integral())
+	}
+}
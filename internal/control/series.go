@@ -0,0 +1,63 @@
+// Package control composes the single-loop controllers of its subpackages
+// (e.g., pid) into larger structures.
+package control
+
+import "github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+
+// Controller is a single-loop feedback controller: it maps a target and a
+// measurement to an output, filling tr with its internal terms when tr != nil.
+// *pid.Controller implements it.
+type Controller interface {
+	Step(target, actual, dt float64, tr *pid.Trace) float64
+}
+
+// SeriesTrace captures both layers of a SeriesController step.
+type SeriesTrace struct {
+	Outer pid.Trace
+	Inner pid.Trace
+	// InnerTarget is the outer output mapped to the inner setpoint.
+	InnerTarget float64
+}
+
+// SeriesController chains two controllers in cascade: the outer controller's
+// output, passed through Map, is the inner controller's setpoint, and the
+// inner output drives the plant. Each layer closes its own loop, so Step takes
+// one measurement per layer (e.g., position for the outer and velocity for
+// the inner loop of a position servo).
+//
+// Map converts between the layers' units, and can bound the inner setpoint;
+// a nil Map passes the outer output through unchanged.
+type SeriesController struct {
+	Outer, Inner Controller
+	Map          func(outerOut float64) (innerTarget float64)
+}
+
+// Series returns a SeriesController running outer around inner.
+func Series(outer, inner Controller, m func(outerOut float64) (innerTarget float64)) *SeriesController {
+	return &SeriesController{Outer: outer, Inner: inner, Map: m}
+}
+
+// Step runs the outer controller on (target, outerActual), maps its output to
+// the inner setpoint, then runs the inner controller on innerActual and
+// returns the inner output.
+//
+// If tr != nil, it is populated with both layers' traces.
+func (s *SeriesController) Step(target, outerActual, innerActual, dt float64, tr *SeriesTrace) float64 {
+	var outerTr, innerTr *pid.Trace
+	if tr != nil {
+		outerTr, innerTr = &tr.Outer, &tr.Inner
+	}
+
+	innerTarget := s.Outer.Step(target, outerActual, dt, outerTr)
+	if s.Map != nil {
+		innerTarget = s.Map(innerTarget)
+	}
+	out := s.Inner.Step(innerTarget, innerActual, dt, innerTr)
+
+	if tr != nil {
+		tr.InnerTarget = innerTarget
+	}
+	return out
+}
+
+var _ Controller = (*pid.Controller)(nil)
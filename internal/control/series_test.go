@@ -0,0 +1,80 @@
+package control
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+func TestSeries_PositionServo(t *testing.T) {
+	// Outer P on position (rev) -> velocity setpoint (RPM, bounded by Map);
+	// inner PI on velocity -> voltage.
+	outer := pid.New(600, 0, 0)
+	outer.OutMin, outer.OutMax = -1e9, 1e9
+	inner := pid.New(0.02, 0.2, 0)
+	inner.OutMin, inner.OutMax = -24, 24
+	limit := func(v float64) float64 { return math.Max(-1500, math.Min(1500, v)) }
+	s := Series(outer, inner, limit)
+
+	plant := wrap.NewPositionSystem(sim.NewDCMotor())
+	const (
+		dt     = 0.001
+		target = 10.0 // revolutions
+	)
+	var tr SeriesTrace
+	maxInnerTarget := 0.0
+	for i := 0; i < 5000; i++ {
+		velocity := plant.Signals()["velocity_rpm"]
+		plant.Actuate(s.Step(target, plant.Observe(), velocity, dt, &tr))
+		plant.Step(dt)
+		maxInnerTarget = math.Max(maxInnerTarget, tr.InnerTarget)
+	}
+
+	if got := plant.Observe(); math.Abs(got-target) > 0.05 {
+		t.Errorf("position = %.3f rev, want %.1f", got, target)
+	}
+	if maxInnerTarget != 1500 {
+		t.Errorf("max inner target = %v, want bounded at 1500 by Map", maxInnerTarget)
+	}
+}
+
+func TestSeries_Trace(t *testing.T) {
+	outer := pid.New(2, 0, 0)
+	outer.OutMin, outer.OutMax = -100, 100
+	inner := pid.New(0.5, 0, 0)
+	inner.OutMin, inner.OutMax = -10, 10
+	s := Series(outer, inner, func(v float64) float64 { return 10 * v })
+
+	var tr SeriesTrace
+	out := s.Step(5, 1, 30, 0.01, &tr)
+
+	// outer: 2*(5-1) = 8 -> inner target 80; inner: 0.5*(80-30) = 25 -> clamped to 10
+	if tr.Outer.Target != 5 || tr.Outer.Actual != 1 || tr.Outer.Out != 8 {
+		t.Errorf("outer trace = %+v, want target 5, actual 1, out 8", tr.Outer)
+	}
+	if tr.InnerTarget != 80 {
+		t.Errorf("InnerTarget = %v, want 80", tr.InnerTarget)
+	}
+	if tr.Inner.Target != 80 || tr.Inner.Actual != 30 || tr.Inner.OutRaw != 25 || !tr.Inner.Saturated {
+		t.Errorf("inner trace = %+v, want target 80, actual 30, out_raw 25, saturated", tr.Inner)
+	}
+	if out != 10 || tr.Inner.Out != out {
+		t.Errorf("output = %v (inner trace %v), want 10", out, tr.Inner.Out)
+	}
+}
+
+func TestSeries_NilMapAndTrace(t *testing.T) {
+	outer := pid.New(1, 0, 0)
+	outer.OutMin, outer.OutMax = -100, 100
+	inner := pid.New(1, 0, 0)
+	inner.OutMin, inner.OutMax = -100, 100
+	s := Series(outer, inner, nil)
+
+	// outer: 3-1 = 2 passed through unchanged; inner: 2-0.5 = 1.5
+	if got := s.Step(3, 1, 0.5, 0.01, nil); got != 1.5 {
+		t.Errorf("output = %v, want 1.5", got)
+	}
+}
@@ -0,0 +1,23 @@
+// Package errs defines the sentinel errors shared across the project.
+//
+// Functions wrap them with context (fmt.Errorf("...: %w", errs.ErrX)), so callers
+// should match with errors.Is rather than comparing error values.
+package errs
+
+import "errors"
+
+var (
+	// ErrInvalidDT reports a non-positive or non-finite timestep.
+	ErrInvalidDT = errors.New("invalid timestep")
+	// ErrInvalidDuration reports a non-positive or non-finite experiment duration.
+	ErrInvalidDuration = errors.New("invalid duration")
+	// ErrDiverged reports a simulation whose output became NaN or infinite.
+	ErrDiverged = errors.New("simulation diverged")
+	// ErrNoSamples reports an experiment that produced no samples.
+	ErrNoSamples = errors.New("no samples produced")
+	// ErrTooManySteps reports an experiment whose duration needs more steps
+	// than its cap (see experiment.StepConfig.MaxSteps).
+	ErrTooManySteps = errors.New("too many steps")
+	// ErrUnknownPlant reports a plant name with no known model.
+	ErrUnknownPlant = errors.New("unknown plant")
+)
@@ -0,0 +1,69 @@
+package experiment
+
+import (
+	"math"
+	"time"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// ImpulseConfig defines an open-loop impulse experiment: a rectangular voltage
+// pulse of VoltageV applied for WidthS seconds starting at StartS.
+type ImpulseConfig struct {
+	DT       float64
+	Duration float64
+
+	StartS   float64
+	WidthS   float64
+	VoltageV float64
+}
+
+// RunImpulse applies the configured pulse open-loop (no controller) and records
+// the plant response. It is intended for system identification.
+//
+// Pulse edges are snapped to the nearest step, so the applied pulse area is
+// exactly VoltageV * round(WidthS/DT) * DT.
+//
+// Samples have Target = 0, so Error = -Actual; U holds the applied pulse and the
+// controller term fields (P, I, D, OutRaw) are zero.
+func RunImpulse(sys system.System, cfg ImpulseConfig) ([]Sample, time.Duration) {
+	start := time.Now()
+
+	if cfg.DT <= 0 || cfg.Duration <= 0 {
+		return nil, time.Since(start)
+	}
+
+	steps := int(cfg.Duration / cfg.DT)
+	out := make([]Sample, 0, steps)
+
+	pulseStart := int(math.Round(cfg.StartS / cfg.DT))
+	pulseEnd := pulseStart + int(math.Round(cfg.WidthS/cfg.DT))
+
+	signals := newSignalSnapshotter(sys)
+
+	for i := 0; i < steps; i++ {
+		t := float64(i) * cfg.DT
+
+		actual := sys.Observe()
+
+		u := 0.0
+		if i >= pulseStart && i < pulseEnd {
+			u = cfg.VoltageV
+		}
+
+		sys.Actuate(u)
+		sys.Step(cfg.DT)
+
+		out = append(out, Sample{
+			T:       t,
+			DT:      cfg.DT,
+			Actual:  actual,
+			Error:   -actual,
+			U:       u,
+			OutRaw:  u,
+			Signals: signals.snapshot(nil),
+		})
+	}
+
+	return out, time.Since(start)
+}
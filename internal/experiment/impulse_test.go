@@ -0,0 +1,81 @@
+package experiment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestRunImpulse_PulseTiming(t *testing.T) {
+	plant := sim.NewDCMotor()
+	cfg := ImpulseConfig{DT: 0.01, Duration: 1.0, StartS: 0.2, WidthS: 0.1, VoltageV: 12.0}
+
+	samples, _ := RunImpulse(plant, cfg)
+	if len(samples) != 100 {
+		t.Fatalf("got %d samples, want 100", len(samples))
+	}
+
+	for _, s := range samples {
+		inPulse := s.T >= cfg.StartS-eps && s.T < cfg.StartS+cfg.WidthS-eps
+		if inPulse && s.U != cfg.VoltageV {
+			t.Errorf("t=%v: U = %v, want %v during pulse", s.T, s.U, cfg.VoltageV)
+		}
+		if !inPulse && s.U != 0 {
+			t.Errorf("t=%v: U = %v, want 0 outside pulse", s.T, s.U)
+		}
+		if s.Error != -s.Actual {
+			t.Errorf("t=%v: Error = %v, want -Actual", s.T, s.Error)
+		}
+	}
+}
+
+// For a first-order plant dv/dt = (K*V - v)/tau starting and ending at rest,
+// integrating both sides gives: integral(v dt) = K * integral(V dt).
+// So the area under the velocity response equals gain * pulse area.
+func TestRunImpulse_AreaMatchesFirstOrderGain(t *testing.T) {
+	plant := sim.NewDCMotor()
+	plant.GainRPMPerVolt = 100.0
+	plant.TauSeconds = 0.2
+
+	cfg := ImpulseConfig{
+		DT:       0.001,
+		Duration: 3.0, // 15 time constants: the response has fully decayed
+		StartS:   0.1,
+		WidthS:   0.05,
+		VoltageV: 10.0,
+	}
+
+	samples, _ := RunImpulse(plant, cfg)
+
+	var area, pulseArea float64
+	for _, s := range samples {
+		area += s.Actual * s.DT
+		pulseArea += s.U * s.DT
+	}
+
+	want := plant.GainRPMPerVolt * cfg.VoltageV * cfg.WidthS
+	if math.Abs(pulseArea-cfg.VoltageV*cfg.WidthS) > 1e-9 {
+		t.Fatalf("pulse area = %v, want %v", pulseArea, cfg.VoltageV*cfg.WidthS)
+	}
+	if math.Abs(area-want)/want > 0.01 {
+		t.Errorf("velocity area = %v, want %v (K * V * width)", area, want)
+	}
+
+	// The response must have decayed back to rest
+	if last := samples[len(samples)-1].Actual; math.Abs(last) > 1e-3 {
+		t.Errorf("final velocity = %v, want ~0", last)
+	}
+}
+
+func TestRunImpulse_InvalidConfig(t *testing.T) {
+	plant := sim.NewDCMotor()
+	for _, cfg := range []ImpulseConfig{
+		{DT: 0, Duration: 1},
+		{DT: 0.01, Duration: 0},
+	} {
+		if samples, _ := RunImpulse(plant, cfg); len(samples) != 0 {
+			t.Errorf("RunImpulse(%+v) produced %d samples, want 0", cfg, len(samples))
+		}
+	}
+}
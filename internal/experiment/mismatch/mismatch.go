@@ -0,0 +1,100 @@
+// Package mismatch runs a controller designed for a nominal DC motor against
+// motors whose parameters are perturbed (e.g., ±20% on the gain or the time
+// constant, see PlusMinus), and reports how much each metric degrades from
+// the nominal run. This is the model-vs-plant error a feedforward or a tuning
+// has to tolerate.
+package mismatch
+
+import (
+	"fmt"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+// SettleBandFrac is the settling band used for each run's metrics.
+const SettleBandFrac = 0.02
+
+// Perturbation scales the plant's parameters. A zero scale leaves the
+// parameter unchanged, so the zero Perturbation is the nominal plant.
+type Perturbation struct {
+	Name      string
+	GainScale float64 // multiplies GainRPMPerVolt (and GainHotRPMPerVolt)
+	TauScale  float64 // multiplies TauSeconds
+}
+
+// Apply scales m's parameters in place.
+func (p Perturbation) Apply(m *sim.DCMotor) {
+	if p.GainScale != 0 {
+		m.GainRPMPerVolt *= p.GainScale
+		m.GainHotRPMPerVolt *= p.GainScale
+	}
+	if p.TauScale != 0 {
+		m.TauSeconds *= p.TauScale
+	}
+}
+
+// PlusMinus returns the perturbations of gain and tau by ±frac (e.g., 0.2 for
+// ±20%), one parameter at a time.
+func PlusMinus(frac float64) []Perturbation {
+	pct := frac * 100
+	return []Perturbation{
+		{Name: fmt.Sprintf("gain%+g%%", pct), GainScale: 1 + frac},
+		{Name: fmt.Sprintf("gain%+g%%", -pct), GainScale: 1 - frac},
+		{Name: fmt.Sprintf("tau%+g%%", pct), TauScale: 1 + frac},
+		{Name: fmt.Sprintf("tau%+g%%", -pct), TauScale: 1 - frac},
+	}
+}
+
+// Scenario builds a run around plant, which already carries the perturbation.
+// It must build the same controller every time (gains, limits, feedforward
+// from the nominal model, ...): only the plant may differ between runs.
+type Scenario func(plant *sim.DCMotor) (system.System, *pid.Controller, experiment.StepConfig)
+
+// Result is the outcome of one perturbed run.
+type Result struct {
+	Perturbation Perturbation
+	Metrics      analysis.Metrics
+	// Degradation is each metric minus its nominal value, keyed by the
+	// metric's json name; positive means larger than on the nominal plant.
+	Degradation map[string]float64
+}
+
+// Run runs scenario on the nominal plant (sim.NewDCMotor) and on each
+// perturbation of it, and returns the nominal metrics and one Result per
+// perturbation, in order. It stops at the first run that fails.
+func Run(scenario Scenario, perturbations []Perturbation) (analysis.Metrics, []Result, error) {
+	nominal, err := run(scenario, Perturbation{Name: "nominal"})
+	if err != nil {
+		return analysis.Metrics{}, nil, err
+	}
+	base := nominal.Values()
+
+	results := make([]Result, 0, len(perturbations))
+	for _, p := range perturbations {
+		m, err := run(scenario, p)
+		if err != nil {
+			return nominal, results, err
+		}
+		deg := make(map[string]float64, len(base))
+		for k, v := range m.Values() {
+			deg[k] = v - base[k]
+		}
+		results = append(results, Result{Perturbation: p, Metrics: m, Degradation: deg})
+	}
+	return nominal, results, nil
+}
+
+func run(scenario Scenario, p Perturbation) (analysis.Metrics, error) {
+	plant := sim.NewDCMotor()
+	p.Apply(plant)
+	sys, ctrl, cfg := scenario(plant)
+	samples, _, err := experiment.RunStep(sys, ctrl, cfg)
+	if err != nil {
+		return analysis.Metrics{}, fmt.Errorf("%s: %w", p.Name, err)
+	}
+	return analysis.ComputeWithLimits(samples, SettleBandFrac, ctrl.OutMin, ctrl.OutMax), nil
+}
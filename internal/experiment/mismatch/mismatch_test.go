@@ -0,0 +1,108 @@
+package mismatch
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+// feedforwardP is a P-only loop whose feedforward uses the nominal motor model.
+func feedforwardP(plant *sim.DCMotor) (system.System, *pid.Controller, experiment.StepConfig) {
+	ctrl := pid.New(0.02, 0, 0)
+	ctrl.Feedforward = sim.NewDCMotor().SteadyStateVoltage
+	return plant, ctrl, experiment.StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 3}
+}
+
+func TestRun_OneResultPerPerturbation(t *testing.T) {
+	var plants []sim.DCMotor
+	var gains [][3]float64
+	scenario := func(plant *sim.DCMotor) (system.System, *pid.Controller, experiment.StepConfig) {
+		plants = append(plants, *plant)
+		sys, ctrl, cfg := feedforwardP(plant)
+		gains = append(gains, [3]float64{ctrl.Kp, ctrl.Ki, ctrl.Kd})
+		return sys, ctrl, cfg
+	}
+
+	perturbations := PlusMinus(0.2)
+	nominal, results, err := Run(scenario, perturbations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(perturbations) {
+		t.Fatalf("got %d results, want %d", len(results), len(perturbations))
+	}
+
+	// The nominal plant first, then each perturbed one, with the same controller
+	wantPlants := []struct{ gain, tau float64 }{{100, 0.5}, {120, 0.5}, {80, 0.5}, {100, 0.6}, {100, 0.4}}
+	if len(plants) != len(wantPlants) {
+		t.Fatalf("scenario called %d times, want %d", len(plants), len(wantPlants))
+	}
+	for i, w := range wantPlants {
+		if math.Abs(plants[i].GainRPMPerVolt-w.gain) > 1e-9 || math.Abs(plants[i].TauSeconds-w.tau) > 1e-9 {
+			t.Errorf("run %d plant gain=%v tau=%v, want %v, %v", i, plants[i].GainRPMPerVolt, plants[i].TauSeconds, w.gain, w.tau)
+		}
+		if gains[i] != gains[0] {
+			t.Errorf("run %d gains = %v, want the same controller %v", i, gains[i], gains[0])
+		}
+	}
+
+	// A matched model leaves no offset; a gain error does, and only the gain
+	if math.Abs(nominal.SteadyStateError) > 0.5 {
+		t.Errorf("nominal steady_state_error = %v, want ≈0", nominal.SteadyStateError)
+	}
+	for _, r := range results {
+		if r.Perturbation.Name == "" {
+			t.Errorf("perturbation %+v has no name", r.Perturbation)
+		}
+		if got := r.Degradation["iae"]; got != r.Metrics.IAE-nominal.IAE {
+			t.Errorf("%s: iae degradation = %v, want %v", r.Perturbation.Name, got, r.Metrics.IAE-nominal.IAE)
+		}
+		sse := math.Abs(r.Metrics.SteadyStateError)
+		switch r.Perturbation.Name {
+		case "gain+20%", "gain-20%":
+			// 20% of the 1000 RPM feedforward, reduced by the loop gain 1+Kp*K
+			if sse < 50 {
+				t.Errorf("%s: steady_state_error = %v, want an offset from the model error", r.Perturbation.Name, sse)
+			}
+		case "tau+20%", "tau-20%":
+			if sse > 0.5 {
+				t.Errorf("%s: steady_state_error = %v, want ≈0 (tau doesn't change the steady state)", r.Perturbation.Name, sse)
+			}
+		default:
+			t.Errorf("unexpected perturbation %q", r.Perturbation.Name)
+		}
+	}
+}
+
+func TestRun_SlowerPlantDegradesTracking(t *testing.T) {
+	_, results, err := Run(feedforwardP, []Perturbation{{Name: "slow", TauScale: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := results[0].Degradation["iae"]; !(d > 0) {
+		t.Errorf("iae degradation = %v, want > 0 for a slower plant", d)
+	}
+}
+
+func TestRun_FailingRunIsReported(t *testing.T) {
+	scenario := func(plant *sim.DCMotor) (system.System, *pid.Controller, experiment.StepConfig) {
+		sys, ctrl, cfg := feedforwardP(plant)
+		if plant.TauSeconds != 0.5 {
+			cfg.DT = 0
+		}
+		return sys, ctrl, cfg
+	}
+	_, results, err := Run(scenario, PlusMinus(0.2))
+	if !errors.Is(err, errs.ErrInvalidDT) {
+		t.Fatalf("error = %v, want ErrInvalidDT", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("got %d results before the failure, want the 2 gain perturbations", len(results))
+	}
+}
@@ -1,6 +1,9 @@
 package modifier
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 type Modifier interface {
 	Modify(u float64) float64
@@ -32,6 +35,150 @@ func (c *chain) Modify(u float64) float64 {
 	return u
 }
 
+// ModifyAt implements TimedModifier, dispatching per element: timed modifiers
+// get t and dt, the others fall back to Modify.
+func (c *chain) ModifyAt(u, t, dt float64) float64 {
+	for _, mod := range c.modifiers {
+		u = Apply(mod, u, t, dt)
+	}
+	return u
+}
+
 func Chain(mods ...Modifier) Modifier {
 	return &chain{modifiers: mods}
 }
+
+// TimedModifier is an optional extension of Modifier for modifiers that need
+// the current time or timestep (e.g., fault injection). Runners call ModifyAt
+// instead of Modify when a modifier implements it; see Apply.
+type TimedModifier interface {
+	Modifier
+	ModifyAt(u, t, dt float64) float64
+}
+
+// Apply runs m on u at time t, using ModifyAt if m is a TimedModifier and Modify otherwise.
+func Apply(m Modifier, u, t, dt float64) float64 {
+	if tm, ok := m.(TimedModifier); ok {
+		return tm.ModifyAt(u, t, dt)
+	}
+	return m.Modify(u)
+}
+
+// FaultModifier simulates an actuator fault: from FaultStartS on, the command
+// is replaced by StuckValue (zero models a dead actuator).
+//
+// The fault needs time, so it only triggers through ModifyAt (directly, via Apply,
+// or inside a Chain run by RunStep); Modify passes u through.
+type FaultModifier struct {
+	FaultStartS float64
+	StuckValue  float64
+}
+
+func (m *FaultModifier) Modify(u float64) float64 {
+	return u
+}
+
+func (m *FaultModifier) ModifyAt(u, t, dt float64) float64 {
+	if t >= m.FaultStartS {
+		return m.StuckValue
+	}
+	return u
+}
+
+// MovingAverageModifier smooths the command with a moving average over the last
+// Window commands (including the current one). It is stateful: use one instance
+// per run.
+//
+// A moving average of N samples delays the command by (N-1)/2 samples, i.e.
+// (N-1)/2*dt seconds of group delay (linear phase), which erodes the loop's phase
+// margin; keep Window small relative to the closed-loop time constant.
+//
+// Until Window commands have been seen, the average covers the commands so far.
+// Window <= 1 passes u through.
+type MovingAverageModifier struct {
+	Window int
+
+	buf  []float64 // ring buffer of the last Window commands
+	next int
+}
+
+func (m *MovingAverageModifier) Modify(u float64) float64 {
+	if m.Window <= 1 {
+		return u
+	}
+	if len(m.buf) < m.Window {
+		m.buf = append(m.buf, u)
+	} else {
+		m.buf[m.next] = u
+		m.next = (m.next + 1) % m.Window
+	}
+
+	// Summing the small window each step avoids the drift of a running sum
+	sum := 0.0
+	for _, v := range m.buf {
+		sum += v
+	}
+	return sum / float64(len(m.buf))
+}
+
+// Rounding is the tie-break policy for quantization (see Quantize).
+type Rounding int
+
+const (
+	// RoundHalfEven rounds to the nearest level, ties to the even level
+	// (banker's rounding, like math.RoundToEven). It is the default.
+	RoundHalfEven Rounding = iota
+	// RoundHalfUp rounds to the nearest level, ties away from zero (like
+	// math.Round), so the policy is symmetric: 2.5 -> 3 and -2.5 -> -3.
+	RoundHalfUp
+	// RoundTruncate drops the fraction of a level, rounding towards zero.
+	RoundTruncate
+)
+
+func (r Rounding) String() string {
+	switch r {
+	case RoundHalfEven:
+		return "half-even"
+	case RoundHalfUp:
+		return "half-up"
+	case RoundTruncate:
+		return "truncate"
+	}
+	return fmt.Sprintf("Rounding(%d)", int(r))
+}
+
+// Round rounds x to an integer with policy r.
+func (r Rounding) Round(x float64) float64 {
+	switch r {
+	case RoundHalfUp:
+		return math.Round(x)
+	case RoundTruncate:
+		return math.Trunc(x)
+	default:
+		return math.RoundToEven(x)
+	}
+}
+
+// Quantize returns x on the grid of multiples of step, rounded with r. A
+// non-positive step returns x unchanged.
+//
+// Ties are only exact when x/step is exactly representable (e.g., a step that
+// is a power of two); otherwise the division's rounding error decides them.
+func Quantize(x, step float64, r Rounding) float64 {
+	if !(step > 0) {
+		return x
+	}
+	return r.Round(x/step) * step
+}
+
+// QuantizeModifier models a finite-resolution actuator (e.g., a DAC or PWM
+// duty register): the command is quantized to multiples of Step with the
+// Rounding policy. Step <= 0 passes u through.
+type QuantizeModifier struct {
+	Step     float64
+	Rounding Rounding
+}
+
+func (m *QuantizeModifier) Modify(u float64) float64 {
+	return Quantize(u, m.Step, m.Rounding)
+}
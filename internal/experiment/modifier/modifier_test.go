@@ -253,3 +253,179 @@ func TestChainWithNegativeValues(t *testing.T) {
 		t.Errorf("Chain.Modify(-3.0) = %v, want %v", got, want)
 	}
 }
+
+func TestFaultModifier(t *testing.T) {
+	tests := []struct {
+		name  string
+		stuck float64
+		t     float64
+		input float64
+		want  float64
+	}{
+		{"before fault", 5.0, 0.99, 3.0, 3.0},
+		{"at fault start", 5.0, 1.0, 3.0, 5.0},
+		{"after fault", 5.0, 2.5, -3.0, 5.0},
+		{"dead actuator", 0.0, 2.0, 3.0, 0.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &FaultModifier{FaultStartS: 1.0, StuckValue: tt.stuck}
+			if got := Apply(m, tt.input, tt.t, 0.01); got != tt.want {
+				t.Errorf("Apply(%v, t=%v) = %v, want %v", tt.input, tt.t, got, tt.want)
+			}
+		})
+	}
+
+	// Without time the fault cannot trigger
+	m := &FaultModifier{FaultStartS: 0, StuckValue: 7}
+	if got := m.Modify(3.0); got != 3.0 {
+		t.Errorf("Modify(3.0) = %v, want pass-through 3.0", got)
+	}
+}
+
+// recordingModifier records the time arguments it is called with and adds 1.
+type recordingModifier struct {
+	calls [][2]float64
+}
+
+func (r *recordingModifier) Modify(u float64) float64 { return u + 1 }
+
+func (r *recordingModifier) ModifyAt(u, t, dt float64) float64 {
+	r.calls = append(r.calls, [2]float64{t, dt})
+	return u + 1
+}
+
+func TestChain_DispatchesPerElement(t *testing.T) {
+	rec := &recordingModifier{}
+	dz := &DeadzoneModifier{Threshold: 1.0}
+	c := Chain(dz, rec, &FaultModifier{FaultStartS: 5, StuckValue: -2})
+
+	if _, ok := c.(TimedModifier); !ok {
+		t.Fatal("Chain should implement TimedModifier")
+	}
+
+	// Before the fault: deadzone (3 -> 2), then the timed modifier (2 -> 3)
+	if got := Apply(c, 3.0, 1.5, 0.01); math.Abs(got-3.0) > eps {
+		t.Errorf("Apply before fault = %v, want 3", got)
+	}
+	// After the fault the last element overrides the command
+	if got := Apply(c, 3.0, 5.0, 0.01); got != -2 {
+		t.Errorf("Apply after fault = %v, want -2", got)
+	}
+	if len(rec.calls) != 2 || rec.calls[0] != [2]float64{1.5, 0.01} || rec.calls[1] != [2]float64{5.0, 0.01} {
+		t.Errorf("timed element calls = %v, want [[1.5 0.01] [5 0.01]]", rec.calls)
+	}
+}
+
+func TestApply_BackwardCompatible(t *testing.T) {
+	dz := &DeadzoneModifier{Threshold: 0.5}
+	chained := Chain(&DeadzoneModifier{Threshold: 1.0}, &DeadzoneModifier{Threshold: 0.5})
+
+	for _, u := range []float64{-3, -0.7, 0, 0.2, 1.2, 4} {
+		if got, want := Apply(dz, u, 9.9, 0.1), dz.Modify(u); got != want {
+			t.Errorf("Apply(deadzone, %v) = %v, want Modify result %v", u, got, want)
+		}
+		if got, want := Apply(chained, u, 9.9, 0.1), chained.Modify(u); got != want {
+			t.Errorf("Apply(chain of plain modifiers, %v) = %v, want Modify result %v", u, got, want)
+		}
+	}
+}
+
+func TestMovingAverageModifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		window int
+		inputs []float64
+		want   []float64
+	}{
+		{
+			name:   "window 3 with startup",
+			window: 3,
+			inputs: []float64{3, 6, 9, 12, 0, 0, 0},
+			// partial windows first: 3/1, 9/2, then full windows of three
+			want: []float64{3, 4.5, 6, 9, 7, 4, 0},
+		},
+		{
+			name:   "step response ramps over the window",
+			window: 4,
+			inputs: []float64{0, 0, 0, 0, 8, 8, 8, 8, 8},
+			want:   []float64{0, 0, 0, 0, 2, 4, 6, 8, 8},
+		},
+		{"window 1 passes through", 1, []float64{1, -2, 5}, []float64{1, -2, 5}},
+		{"window 0 passes through", 0, []float64{1, -2, 5}, []float64{1, -2, 5}},
+		{"negative window passes through", -3, []float64{1, -2, 5}, []float64{1, -2, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MovingAverageModifier{Window: tt.window}
+			for i, u := range tt.inputs {
+				if got := m.Modify(u); math.Abs(got-tt.want[i]) > eps {
+					t.Errorf("Modify(%v) at step %d = %v, want %v", u, i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQuantize_HalfStepBoundaries(t *testing.T) {
+	// With step 0.5 every input is exact, so x.25 and x.75 are true ties
+	tests := []struct {
+		x                       float64
+		halfEven, halfUp, trunc float64
+	}{
+		{0.25, 0, 0.5, 0},
+		{0.75, 1, 1, 0.5},
+		{1.25, 1, 1.5, 1},
+		{1.75, 2, 2, 1.5},
+		{-0.25, 0, -0.5, 0},
+		{-0.75, -1, -1, -0.5},
+		{-1.25, -1, -1.5, -1},
+		// Off the ties, the nearest-level policies agree
+		{0.3, 0.5, 0.5, 0},
+		{-0.2, 0, 0, 0},
+		{1, 1, 1, 1},
+	}
+	for _, tt := range tests {
+		for _, p := range []struct {
+			r    Rounding
+			want float64
+		}{
+			{RoundHalfEven, tt.halfEven},
+			{RoundHalfUp, tt.halfUp},
+			{RoundTruncate, tt.trunc},
+		} {
+			if got := Quantize(tt.x, 0.5, p.r); got != p.want {
+				t.Errorf("Quantize(%v, 0.5, %v) = %v, want %v", tt.x, p.r, got, p.want)
+			}
+		}
+	}
+}
+
+func TestQuantize_DefaultIsHalfEven(t *testing.T) {
+	var r Rounding
+	if r != RoundHalfEven {
+		t.Fatalf("zero Rounding = %v, want RoundHalfEven", r)
+	}
+	for _, x := range []float64{0.5, 1.5, 2.5, -0.5, -1.5} {
+		if got, want := r.Round(x), math.RoundToEven(x); got != want {
+			t.Errorf("Round(%v) = %v, want %v", x, got, want)
+		}
+	}
+}
+
+func TestQuantizeModifier(t *testing.T) {
+	m := &QuantizeModifier{Step: 0.25, Rounding: RoundHalfUp}
+	// 0.125 is half a step: a tie, rounded away from zero
+	for _, tt := range []struct{ u, want float64 }{
+		{0.125, 0.25}, {-0.125, -0.25}, {0.1, 0}, {3.3, 3.25}, {12, 12},
+	} {
+		if got := m.Modify(tt.u); got != tt.want {
+			t.Errorf("Modify(%v) = %v, want %v", tt.u, got, tt.want)
+		}
+	}
+
+	if got := (&QuantizeModifier{}).Modify(1.23); got != 1.23 {
+		t.Errorf("zero step: Modify(1.23) = %v, want unchanged", got)
+	}
+}
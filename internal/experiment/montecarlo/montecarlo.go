@@ -0,0 +1,126 @@
+// Package montecarlo runs repeated stochastic experiments and summarizes the
+// distribution of their metrics.
+//
+// It lives outside package experiment because it returns analysis types, and
+// analysis already depends on experiment.
+package montecarlo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// SettleBandFrac is the settling band used for each trial's metrics.
+const SettleBandFrac = 0.02
+
+// TrialFunc builds a fresh system, controller and config for one trial.
+// Randomness (noise, disturbances) must be derived from seed so that trials
+// are reproducible.
+type TrialFunc func(seed int64) (system.System, *pid.Controller, experiment.StepConfig)
+
+// Run executes n step-response trials with seeds seedBase, seedBase+1, ... and
+// returns their metrics in trial order. It stops at the first trial whose run
+// fails (see experiment.RunStep), returning the metrics so far and the error.
+func Run(n int, seedBase int64, trial TrialFunc) ([]analysis.Metrics, error) {
+	out := make([]analysis.Metrics, 0, n)
+	var buf []experiment.Sample
+	for i := 0; i < n; i++ {
+		seed := seedBase + int64(i)
+		sys, ctrl, cfg := trial(seed)
+		var err error
+		buf, _, err = experiment.RunStepInto(buf, sys, ctrl, cfg)
+		if err != nil {
+			return out, fmt.Errorf("trial %d (seed %d): %w", i, seed, err)
+		}
+		out = append(out, analysis.ComputeWithLimits(buf, SettleBandFrac, ctrl.OutMin, ctrl.OutMax))
+	}
+	return out, nil
+}
+
+// Stats summarizes the distribution of one metric across trials.
+// NaN values (e.g., trials that never settled) are excluded; N counts the rest.
+type Stats struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"` // sample standard deviation (n-1)
+	Min    float64 `json:"min"`
+	P05    float64 `json:"p05"`
+	P50    float64 `json:"p50"`
+	P95    float64 `json:"p95"`
+	Max    float64 `json:"max"`
+}
+
+// Aggregate computes Stats per metric, keyed by the metric's json name.
+func Aggregate(trials []analysis.Metrics) map[string]Stats {
+	values := map[string][]float64{}
+	for _, m := range trials {
+		for k, v := range m.Values() {
+			values[k] = append(values[k], v)
+		}
+	}
+
+	out := make(map[string]Stats, len(values))
+	for k, vs := range values {
+		out[k] = Summarize(vs)
+	}
+	return out
+}
+
+// Summarize computes Stats over vs, ignoring NaNs. Percentiles are linearly
+// interpolated between order statistics. All fields except N are NaN when no
+// values remain.
+func Summarize(vs []float64) Stats {
+	sorted := make([]float64, 0, len(vs))
+	for _, v := range vs {
+		if !math.IsNaN(v) {
+			sorted = append(sorted, v)
+		}
+	}
+	n := len(sorted)
+	if n == 0 {
+		nan := math.NaN()
+		return Stats{Mean: nan, StdDev: nan, Min: nan, P05: nan, P50: nan, P95: nan, Max: nan}
+	}
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(n)
+
+	var ss float64
+	for _, v := range sorted {
+		ss += (v - mean) * (v - mean)
+	}
+	std := 0.0
+	if n > 1 {
+		std = math.Sqrt(ss / float64(n-1))
+	}
+
+	return Stats{
+		N:      n,
+		Mean:   mean,
+		StdDev: std,
+		Min:    sorted[0],
+		P05:    percentile(sorted, 0.05),
+		P50:    percentile(sorted, 0.50),
+		P95:    percentile(sorted, 0.95),
+		Max:    sorted[n-1],
+	}
+}
+
+// percentile returns the p-quantile (0..1) of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	frac := pos - float64(lo)
+	return sorted[lo] + (sorted[hi]-sorted[lo])*frac
+}
@@ -0,0 +1,106 @@
+package montecarlo
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+const eps = 1e-9
+
+// noisyTrial returns a TrialFunc for a PI loop with measurement noise of stdDev RPM.
+func noisyTrial(stdDev float64) TrialFunc {
+	return func(seed int64) (system.System, *pid.Controller, experiment.StepConfig) {
+		sys := wrap.NewNoisySystem(sim.NewDCMotor(), stdDev, seed)
+		cfg := experiment.StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 3}
+		return sys, pid.New(0.02, 0.05, 0), cfg
+	}
+}
+
+func TestRun_DeterministicTrialsAreIdentical(t *testing.T) {
+	trials, err := Run(5, 100, noisyTrial(0))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(trials) != 5 {
+		t.Fatalf("got %d trials, want 5", len(trials))
+	}
+	for i, m := range trials[1:] {
+		if m != trials[0] {
+			t.Errorf("trial %d = %+v, want %+v", i+1, m, trials[0])
+		}
+	}
+
+	stats := Aggregate(trials)
+	if s := stats["iae"]; s.N != 5 || s.StdDev != 0 || s.Min != s.Max {
+		t.Errorf("iae stats = %+v, want zero spread", s)
+	}
+}
+
+func TestRun_NoisyTrialsVary(t *testing.T) {
+	trials, err := Run(8, 1, noisyTrial(5))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	stats := Aggregate(trials)
+
+	iae := stats["iae"]
+	if iae.N != 8 {
+		t.Fatalf("iae N = %d, want 8", iae.N)
+	}
+	if iae.StdDev <= 0 {
+		t.Errorf("iae stddev = %v, want > 0 with noise", iae.StdDev)
+	}
+	if !(iae.Min <= iae.P05 && iae.P05 <= iae.P50 && iae.P50 <= iae.P95 && iae.P95 <= iae.Max) {
+		t.Errorf("iae percentiles out of order: %+v", iae)
+	}
+
+	// Same seeds reproduce the same study
+	again, _ := Run(8, 1, noisyTrial(5))
+	for i := range trials {
+		if trials[i].IAE != again[i].IAE || trials[i].MaxActual != again[i].MaxActual {
+			t.Errorf("trial %d not reproducible", i)
+		}
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	trial := func(int64) (system.System, *pid.Controller, experiment.StepConfig) {
+		return sim.NewDCMotor(), pid.New(0.02, 0.05, 0), experiment.StepConfig{TargetRPM: 1000, Duration: 1}
+	}
+	trials, err := Run(3, 0, trial)
+	if !errors.Is(err, errs.ErrInvalidDT) || len(trials) != 0 {
+		t.Errorf("Run() = %d trials, %v, want none and ErrInvalidDT", len(trials), err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]float64{4, 1, math.NaN(), 3, 2, 5})
+
+	want := Stats{N: 5, Mean: 3, StdDev: math.Sqrt(2.5), Min: 1, P05: 1.2, P50: 3, P95: 4.8, Max: 5}
+	got := []float64{s.Mean, s.StdDev, s.Min, s.P05, s.P50, s.P95, s.Max}
+	exp := []float64{want.Mean, want.StdDev, want.Min, want.P05, want.P50, want.P95, want.Max}
+	if s.N != want.N {
+		t.Errorf("N = %d, want %d", s.N, want.N)
+	}
+	for i := range got {
+		if math.Abs(got[i]-exp[i]) > eps {
+			t.Errorf("Summarize() = %+v, want %+v", s, want)
+			break
+		}
+	}
+
+	if one := Summarize([]float64{7}); one.StdDev != 0 || one.P95 != 7 {
+		t.Errorf("single value: %+v, want zero stddev and all percentiles 7", one)
+	}
+	if none := Summarize([]float64{math.NaN()}); none.N != 0 || !math.IsNaN(none.Mean) {
+		t.Errorf("all NaN: %+v, want N=0 and NaN mean", none)
+	}
+}
@@ -0,0 +1,69 @@
+package experiment
+
+import "math"
+
+// Reference is a setpoint trajectory: the target velocity (RPM) at time t (s).
+// Set StepConfig.Reference to track one instead of the constant TargetRPM.
+type Reference interface {
+	Target(t float64) float64
+}
+
+// StepReference is a constant setpoint, equivalent to StepConfig.TargetRPM.
+type StepReference struct {
+	RPM float64
+}
+
+func (r StepReference) Target(float64) float64 { return r.RPM }
+
+// RampReference rises from 0 at RateRPMPerS and holds at FinalRPM once reached.
+// A negative FinalRPM ramps down at the same rate.
+type RampReference struct {
+	RateRPMPerS float64
+	FinalRPM    float64
+}
+
+func (r RampReference) Target(t float64) float64 {
+	v := math.Abs(r.RateRPMPerS) * t
+	if v >= math.Abs(r.FinalRPM) {
+		return r.FinalRPM
+	}
+	return math.Copysign(v, r.FinalRPM)
+}
+
+// SineReference oscillates around OffsetRPM: OffsetRPM + AmplitudeRPM*sin(2π·FreqHz·t).
+type SineReference struct {
+	OffsetRPM    float64
+	AmplitudeRPM float64
+	FreqHz       float64
+}
+
+func (r SineReference) Target(t float64) float64 {
+	return r.OffsetRPM + r.AmplitudeRPM*math.Sin(2*math.Pi*r.FreqHz*t)
+}
+
+// ChirpReference is a linear chirp around OffsetRPM whose frequency sweeps from
+// StartHz at t=0 to EndHz at t=DurationS (and keeps sweeping beyond it):
+//
+//	OffsetRPM + AmplitudeRPM*sin(2π·(StartHz·t + (EndHz-StartHz)/(2·DurationS)·t²))
+type ChirpReference struct {
+	OffsetRPM    float64
+	AmplitudeRPM float64
+	StartHz      float64
+	EndHz        float64
+	DurationS    float64
+}
+
+func (r ChirpReference) Target(t float64) float64 {
+	phase := r.StartHz * t
+	if r.DurationS > 0 {
+		phase += (r.EndHz - r.StartHz) / (2 * r.DurationS) * t * t
+	}
+	return r.OffsetRPM + r.AmplitudeRPM*math.Sin(2*math.Pi*phase)
+}
+
+var (
+	_ Reference = StepReference{}
+	_ Reference = RampReference{}
+	_ Reference = SineReference{}
+	_ Reference = ChirpReference{}
+)
@@ -0,0 +1,51 @@
+package experiment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestReference_Target(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  Reference
+		t    float64
+		want float64
+	}{
+		{"step", StepReference{RPM: 750}, 3, 750},
+		{"ramp rising", RampReference{RateRPMPerS: 500, FinalRPM: 1000}, 1, 500},
+		{"ramp holds", RampReference{RateRPMPerS: 500, FinalRPM: 1000}, 3, 1000},
+		{"ramp down", RampReference{RateRPMPerS: 500, FinalRPM: -1000}, 1, -500},
+		{"sine peak", SineReference{OffsetRPM: 1000, AmplitudeRPM: 100, FreqHz: 2}, 0.125, 1100},
+		{"sine zero crossing", SineReference{OffsetRPM: 1000, AmplitudeRPM: 100, FreqHz: 2}, 0.25, 1000},
+		{"chirp start", ChirpReference{OffsetRPM: 1000, AmplitudeRPM: 100, StartHz: 1, EndHz: 5, DurationS: 2}, 0, 1000},
+		// phase(2) = 1*2 + (4/4)*4 = 6 cycles
+		{"chirp end", ChirpReference{OffsetRPM: 1000, AmplitudeRPM: 100, StartHz: 1, EndHz: 5, DurationS: 2}, 2, 1000},
+		// phase(0.5) = 0.5 + 0.25 = 0.75 cycles
+		{"chirp trough", ChirpReference{OffsetRPM: 1000, AmplitudeRPM: 100, StartHz: 1, EndHz: 5, DurationS: 2}, 0.5, 900},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ref.Target(tt.t); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Target(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStep_Reference(t *testing.T) {
+	ref := SineReference{OffsetRPM: 500, AmplitudeRPM: 200, FreqHz: 1}
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 2, Reference: ref}
+	samples, _, err := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range samples {
+		if want := ref.Target(s.T); s.Target != want {
+			t.Fatalf("t=%v: target = %v, want %v (TargetRPM is ignored)", s.T, s.Target, want)
+		}
+	}
+}
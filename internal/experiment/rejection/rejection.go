@@ -0,0 +1,123 @@
+// Package rejection measures how well a closed loop rejects load disturbances
+// as a function of frequency: it injects a sinusoidal disturbance at each
+// frequency of a sweep and measures the steady-state amplitude of the output.
+package rejection
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+// Scenario builds a fresh plant and controller for one frequency of the sweep.
+// The plant must implement system.DisturbanceReceiver.
+type Scenario func() (system.System, *pid.Controller)
+
+// Config configures a disturbance-rejection sweep.
+type Config struct {
+	FreqsHz          []float64
+	AmplitudeRPMPerS float64 // disturbance amplitude
+
+	TargetRPM float64 // setpoint held during the sweep
+	DT        float64
+
+	// The start of each run is discarded while the transients (the step to
+	// TargetRPM and the onset of the disturbance) decay: at least SettleS
+	// seconds and SettleCycles periods of the disturbance. The amplitude is
+	// then measured over MeasureCycles periods. Zero cycles default to 5.
+	SettleS       float64
+	SettleCycles  int
+	MeasureCycles int
+}
+
+// Run sweeps the disturbance frequencies and returns one point per frequency,
+// in order: the magnitude is the output amplitude relative to the disturbance
+// amplitude, 20·log10(|y|/|d|) with |y|/|d| in seconds (RPM per RPM/s), and the
+// phase is that of the output relative to the disturbance. Lower magnitudes
+// mean better rejection.
+//
+// The amplitude and phase are those of the component of the output at the
+// disturbance frequency, fitted by least squares over the measured periods, so
+// noise and harmonics (e.g., from saturation) do not count.
+func Run(scenario Scenario, cfg Config) ([]analysis.FrequencyPoint, error) {
+	if !(cfg.DT > 0) {
+		return nil, fmt.Errorf("dt %g must be > 0", cfg.DT)
+	}
+	if cfg.AmplitudeRPMPerS == 0 {
+		return nil, fmt.Errorf("disturbance amplitude must be non-zero")
+	}
+	settleCycles, measureCycles := cfg.SettleCycles, cfg.MeasureCycles
+	if settleCycles == 0 {
+		settleCycles = 5
+	}
+	if measureCycles == 0 {
+		measureCycles = 5
+	}
+
+	out := make([]analysis.FrequencyPoint, 0, len(cfg.FreqsHz))
+	for _, f := range cfg.FreqsHz {
+		if !(f > 0) {
+			return out, fmt.Errorf("frequency %g Hz must be > 0", f)
+		}
+		period := 1 / f
+		settle := math.Max(cfg.SettleS, float64(settleCycles)*period)
+		n := int(math.Round(float64(measureCycles) * period / cfg.DT))
+		if n < 2 {
+			return out, fmt.Errorf("%g Hz: %d measured periods span fewer than 2 steps of %g s", f, measureCycles, cfg.DT)
+		}
+
+		plant, ctrl := scenario()
+		if _, ok := plant.(system.DisturbanceReceiver); !ok {
+			return out, fmt.Errorf("plant %T does not accept disturbances", plant)
+		}
+		sys := wrap.NewCompositeDisturbance(plant,
+			wrap.DisturbanceComponent{Name: "sine", Profile: wrap.SineProfile(cfg.AmplitudeRPMPerS, f, 0)})
+		steps := int(math.Round(settle/cfg.DT)) + n
+		samples, _, err := experiment.RunStep(sys, ctrl, experiment.StepConfig{
+			TargetRPM: cfg.TargetRPM,
+			DT:        cfg.DT,
+			Duration:  float64(steps) * cfg.DT,
+		})
+		if err != nil {
+			return out, fmt.Errorf("%g Hz: %w", f, err)
+		}
+		if len(samples) < n {
+			return out, fmt.Errorf("%g Hz: run has %d samples, want at least %d", f, len(samples), n)
+		}
+
+		amp, phase := fitSine(samples[len(samples)-n:], f)
+		out = append(out, analysis.FrequencyPoint{
+			FreqHz:      f,
+			MagnitudeDB: 20 * math.Log10(amp/math.Abs(cfg.AmplitudeRPMPerS)),
+			PhaseDeg:    phase,
+		})
+	}
+	return out, nil
+}
+
+// fitSine returns the amplitude and phase (degrees) of the component of Actual
+// at freqHz: the least-squares fit of a·sin(ωt) + b·cos(ωt) after removing the
+// mean, which is exact over whole periods.
+func fitSine(samples []experiment.Sample, freqHz float64) (amp, phaseDeg float64) {
+	var mean float64
+	for _, s := range samples {
+		mean += s.Actual
+	}
+	mean /= float64(len(samples))
+
+	w := 2 * math.Pi * freqHz
+	var a, b float64
+	for _, s := range samples {
+		y := s.Actual - mean
+		a += y * math.Sin(w*s.T)
+		b += y * math.Cos(w*s.T)
+	}
+	scale := 2 / float64(len(samples))
+	a, b = a*scale, b*scale
+	return math.Hypot(a, b), math.Atan2(b, a) * 180 / math.Pi
+}
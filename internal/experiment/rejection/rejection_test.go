@@ -0,0 +1,102 @@
+package rejection
+
+import (
+	"math"
+	"math/cmplx"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+// pLoop is a P-only loop around the default motor (K = 100 RPM/V, tau = 0.5 s).
+func pLoop(kp float64) Scenario {
+	return func() (system.System, *pid.Controller) {
+		return sim.NewDCMotor(), pid.New(kp, 0, 0)
+	}
+}
+
+// theoryDB is the disturbance response of the motor under P control:
+// v/d = -tau / (tau·s + 1 + kp·K).
+func theoryDB(kp, f float64) float64 {
+	const k, tau = 100, 0.5
+	h := complex(tau, 0) / complex(1+kp*k, 2*math.Pi*f*tau)
+	return 20 * math.Log10(cmplx.Abs(h))
+}
+
+func TestRun_ProportionalRejection(t *testing.T) {
+	freqs := []float64{0.05, 0.2, 1, 5, 20}
+	cfg := Config{FreqsHz: freqs, AmplitudeRPMPerS: 100, DT: 0.001, SettleS: 3, SettleCycles: 3, MeasureCycles: 3}
+
+	const kp = 0.05 // kp·K = 5
+	closed, err := Run(pLoop(kp), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open, err := Run(pLoop(0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != len(freqs) || len(open) != len(freqs) {
+		t.Fatalf("got %d and %d points, want %d", len(closed), len(open), len(freqs))
+	}
+
+	for i, f := range freqs {
+		if closed[i].FreqHz != f {
+			t.Errorf("point %d at %v Hz, want %v", i, closed[i].FreqHz, f)
+		}
+		for _, c := range []struct {
+			name string
+			kp   float64
+			got  float64
+		}{{"closed", kp, closed[i].MagnitudeDB}, {"open", 0, open[i].MagnitudeDB}} {
+			if want := theoryDB(c.kp, f); math.Abs(c.got-want) > 0.2 {
+				t.Errorf("%v Hz %s loop: %.2f dB, want %.2f dB", f, c.name, c.got, want)
+			}
+		}
+	}
+
+	// Feedback attenuates slow disturbances by 1/(1 + kp·K), about -15.6 dB,
+	// but does little against fast ones the motor's inertia filters anyway
+	improvement := func(i int) float64 { return closed[i].MagnitudeDB - open[i].MagnitudeDB }
+	if got := improvement(0); math.Abs(got-20*math.Log10(1.0/6)) > 0.3 {
+		t.Errorf("improvement at %v Hz = %.2f dB, want about -15.6 dB", freqs[0], got)
+	}
+	for i := 1; i < len(freqs); i++ {
+		if !(improvement(i) > improvement(i-1)) {
+			t.Errorf("improvement at %v Hz (%.2f dB) not smaller than at %v Hz (%.2f dB)",
+				freqs[i], improvement(i), freqs[i-1], improvement(i-1))
+		}
+	}
+	if got := improvement(len(freqs) - 1); got < -0.5 {
+		t.Errorf("improvement at %v Hz = %.2f dB, want close to 0", freqs[len(freqs)-1], got)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	ok := Config{FreqsHz: []float64{1}, AmplitudeRPMPerS: 10, DT: 0.01}
+	tests := []struct {
+		name     string
+		scenario Scenario
+		cfg      Config
+		want     string
+	}{
+		{"no dt", pLoop(0.05), Config{FreqsHz: []float64{1}, AmplitudeRPMPerS: 10}, "dt"},
+		{"no amplitude", pLoop(0.05), Config{FreqsHz: []float64{1}, DT: 0.01}, "amplitude"},
+		{"zero frequency", pLoop(0.05), Config{FreqsHz: []float64{0}, AmplitudeRPMPerS: 10, DT: 0.01}, "must be > 0"},
+		{"too fast for dt", pLoop(0.05), Config{FreqsHz: []float64{1000}, AmplitudeRPMPerS: 10, DT: 0.01, MeasureCycles: 1}, "fewer than 2 steps"},
+		{"plant without disturbances", func() (system.System, *pid.Controller) {
+			// Embedding the interface hides the motor's SetDisturbanceRPMPerS
+			return struct{ system.System }{sim.NewDCMotor()}, pid.New(0.05, 0, 0)
+		}, ok, "does not accept disturbances"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Run(tt.scenario, tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Run() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
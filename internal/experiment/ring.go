@@ -0,0 +1,67 @@
+package experiment
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RingRecorder is a SampleSink that keeps only the last N samples, for runs
+// that are too long (or unbounded, e.g. realtime) to buffer but whose recent
+// window is still wanted for live metrics or plots. Memory is fixed at N
+// samples.
+//
+// It is safe to read the window with Samples from another goroutine while
+// the run writes to it.
+type RingRecorder struct {
+	mu    sync.Mutex
+	buf   []Sample
+	next  int // index of the slot the next sample goes to
+	total int
+}
+
+var _ SampleSink = (*RingRecorder)(nil)
+
+// NewRingRecorder returns a recorder keeping the last n samples.
+// It panics if n <= 0.
+func NewRingRecorder(n int) *RingRecorder {
+	if n <= 0 {
+		panic(fmt.Sprintf("experiment: ring recorder size %d must be > 0", n))
+	}
+	return &RingRecorder{buf: make([]Sample, 0, n)}
+}
+
+// Write records s, evicting the oldest sample once the recorder is full.
+func (r *RingRecorder) Write(s Sample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.buf) < cap(r.buf) {
+		r.buf = append(r.buf, s)
+	} else {
+		r.buf[r.next] = s
+	}
+	r.next = (r.next + 1) % cap(r.buf)
+	r.total++
+	return nil
+}
+
+// Close implements SampleSink; the window stays readable.
+func (r *RingRecorder) Close() error { return nil }
+
+// Samples returns a copy of the recorded window, oldest first.
+func (r *RingRecorder) Samples() []Sample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Sample, 0, len(r.buf))
+	if len(r.buf) < cap(r.buf) {
+		return append(out, r.buf...)
+	}
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
+
+// Total returns the number of samples written, including evicted ones.
+func (r *RingRecorder) Total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
@@ -0,0 +1,88 @@
+package experiment
+
+import (
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestRingRecorder_KeepsLastN(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int
+		written int
+	}{
+		{"not full", 5, 3},
+		{"exactly full", 5, 5},
+		{"wrapped", 5, 12},
+		{"size one", 1, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRingRecorder(tt.n)
+			for i := 0; i < tt.written; i++ {
+				if err := r.Write(Sample{T: float64(i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := r.Samples()
+			want := min(tt.n, tt.written)
+			if len(got) != want {
+				t.Fatalf("kept %d samples, want %d", len(got), want)
+			}
+			for i, s := range got {
+				if wantT := float64(tt.written - want + i); s.T != wantT {
+					t.Errorf("sample %d has T=%v, want %v (oldest first)", i, s.T, wantT)
+				}
+			}
+			if r.Total() != tt.written {
+				t.Errorf("Total() = %d, want %d", r.Total(), tt.written)
+			}
+		})
+	}
+}
+
+func TestRingRecorder_SamplesIsACopy(t *testing.T) {
+	r := NewRingRecorder(2)
+	_ = r.Write(Sample{T: 1})
+	got := r.Samples()
+	got[0].T = 99
+	if r.Samples()[0].T != 1 {
+		t.Error("modifying the returned window changed the recorder")
+	}
+}
+
+func TestRingRecorder_AsStreamingSink(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 2}
+	full, _, err := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRingRecorder(250)
+	n, _, err := RunStepStreaming(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(full) || r.Total() != n {
+		t.Fatalf("streamed %d samples (recorder saw %d), want %d", n, r.Total(), len(full))
+	}
+	window := r.Samples()
+	tail := full[len(full)-250:]
+	for i := range window {
+		if window[i].T != tail[i].T || window[i].Actual != tail[i].Actual || window[i].U != tail[i].U {
+			t.Fatalf("window[%d] = %+v, want %+v", i, window[i], tail[i])
+		}
+	}
+}
+
+func TestNewRingRecorder_PanicsOnNonPositiveSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewRingRecorder(0) did not panic")
+		}
+	}()
+	NewRingRecorder(0)
+}
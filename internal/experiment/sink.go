@@ -0,0 +1,37 @@
+package experiment
+
+import "errors"
+
+// SampleSink consumes samples as they are produced, e.g. to stream them to disk
+// instead of buffering a whole run in memory.
+type SampleSink interface {
+	// Write consumes one sample. The sample's Signals map must be treated as read-only.
+	Write(s Sample) error
+	// Close flushes any buffered output and releases resources.
+	Close() error
+}
+
+// MultiSink returns a sink that writes each sample to every sink in order,
+// stopping at the first error. Close closes all of them and joins their errors.
+func MultiSink(sinks ...SampleSink) SampleSink {
+	return multiSink(sinks)
+}
+
+type multiSink []SampleSink
+
+func (m multiSink) Write(s Sample) error {
+	for _, sink := range m {
+		if err := sink.Write(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,127 @@
+package experiment
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// SoakSnapshot summarizes one interval of a soak run.
+type SoakSnapshot struct {
+	// T is the time of the interval's last sample (s).
+	T float64
+	// MeanError is the mean tracking error over the interval.
+	MeanError float64
+	// Integral is the controller's integrator at the end of the interval (see
+	// pid.Controller.Integral).
+	Integral float64
+	// U is the last applied command (V).
+	U float64
+}
+
+// SoakReport is the outcome of RunSoak.
+type SoakReport struct {
+	Snapshots []SoakSnapshot
+	// Steps is the number of samples simulated.
+	Steps int
+
+	// ErrorDrift and IntegralDrift are the least-squares slopes, per second, of
+	// MeanError and Integral over the snapshots after the first (which holds
+	// the initial transient). They are NaN with fewer than two such snapshots.
+	ErrorDrift    float64
+	IntegralDrift float64
+}
+
+// RunSoak runs a (typically very long) closed-loop experiment without
+// buffering its samples, and takes a snapshot of the error and the integrator
+// every interval seconds of simulated time, e.g. to check that float64
+// accumulation in the controller or the plant does not make a settled loop
+// drift over hours. A trailing partial interval gets a snapshot of its own.
+//
+// The run stops on the same configuration and divergence errors as RunStep;
+// the report then holds the snapshots taken so far. cfg.MaxSteps applies as for
+// RunStep, so runs longer than DefaultMaxSteps need a negative MaxSteps, even
+// though no samples are buffered.
+func RunSoak(sys system.System, ctrl *pid.Controller, cfg StepConfig, interval float64) (SoakReport, time.Duration, error) {
+	if !(interval > 0) || math.IsInf(interval, 0) {
+		return SoakReport{}, 0, fmt.Errorf("soak: snapshot interval %gs must be positive and finite", interval)
+	}
+
+	sink := &soakSink{ctrl: ctrl, every: max(int(math.Round(interval/cfg.DT)), 1)}
+	n, wall, err := RunStepStreaming(sys, ctrl, cfg, sink)
+	sink.flush()
+	report := SoakReport{Snapshots: sink.snapshots, Steps: n}
+	report.ErrorDrift, report.IntegralDrift = soakDrift(sink.snapshots)
+	return report, wall, err
+}
+
+// soakSink accumulates samples into a snapshot every `every` samples.
+type soakSink struct {
+	ctrl  *pid.Controller
+	every int
+
+	n         int
+	sumError  float64
+	last      Sample
+	snapshots []SoakSnapshot
+}
+
+func (s *soakSink) Write(sample Sample) error {
+	s.n++
+	s.sumError += sample.Error
+	s.last = sample
+	if s.n == s.every {
+		s.flush()
+	}
+	return nil
+}
+
+func (s *soakSink) Close() error { return nil }
+
+// flush takes a snapshot of the samples since the last one, if any.
+func (s *soakSink) flush() {
+	if s.n == 0 {
+		return
+	}
+	s.snapshots = append(s.snapshots, SoakSnapshot{
+		T:         s.last.T,
+		MeanError: s.sumError / float64(s.n),
+		Integral:  s.ctrl.Integral(),
+		U:         s.last.U,
+	})
+	s.n, s.sumError = 0, 0
+}
+
+// soakDrift returns the least-squares slopes of MeanError and Integral against
+// T over snapshots[1:].
+func soakDrift(snapshots []SoakSnapshot) (errorDrift, integralDrift float64) {
+	if len(snapshots) < 3 {
+		return math.NaN(), math.NaN()
+	}
+	pts := snapshots[1:]
+	var meanT float64
+	for _, p := range pts {
+		meanT += p.T
+	}
+	meanT /= float64(len(pts))
+
+	slope := func(y func(SoakSnapshot) float64) float64 {
+		var meanY float64
+		for _, p := range pts {
+			meanY += y(p)
+		}
+		meanY /= float64(len(pts))
+		var sxy, sxx float64
+		for _, p := range pts {
+			dt := p.T - meanT
+			sxy += dt * (y(p) - meanY)
+			sxx += dt * dt
+		}
+		return sxy / sxx
+	}
+	return slope(func(p SoakSnapshot) float64 { return p.MeanError }),
+		slope(func(p SoakSnapshot) float64 { return p.Integral })
+}
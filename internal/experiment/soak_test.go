@@ -0,0 +1,83 @@
+package experiment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestRunSoak_NoDrift(t *testing.T) {
+	// One simulated hour at 100 Hz: 360k steps of float64 accumulation
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 3600}
+	report, _, err := RunSoak(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg, 60)
+	if err != nil {
+		t.Fatalf("RunSoak() error = %v", err)
+	}
+	if report.Steps != 360000 || len(report.Snapshots) != 60 {
+		t.Fatalf("%d steps, %d snapshots; want 360000 and 60", report.Steps, len(report.Snapshots))
+	}
+	t.Logf("drift: error %g rpm/s, integral %g rpm*s/s", report.ErrorDrift, report.IntegralDrift)
+
+	// Over the hour, the settled loop must move less than a micro-RPM of error
+	// and of integrator-equivalent speed
+	if d := math.Abs(report.ErrorDrift) * cfg.Duration; d > 1e-6 {
+		t.Errorf("error drifted by %g rpm over the run", d)
+	}
+	if d := math.Abs(report.IntegralDrift) * cfg.Duration; d > 1e-6 {
+		t.Errorf("integral drifted by %g rpm*s over the run", d)
+	}
+	first, last := report.Snapshots[1], report.Snapshots[len(report.Snapshots)-1]
+	if math.Abs(last.Integral-first.Integral) > 1e-6 || math.Abs(last.MeanError) > 1e-6 {
+		t.Errorf("integral %v -> %v, final mean error %v; want a settled loop", first.Integral, last.Integral, last.MeanError)
+	}
+}
+
+func TestRunSoak_ReportsDrift(t *testing.T) {
+	// A PI loop follows a ramp with a constant lag, so its integrator keeps
+	// growing with the target: 2 rpm/s of ramp needs 0.02 V/s more on a
+	// 100 rpm/V plant, i.e. Ki*dIntegral/dt = 0.02
+	cfg := StepConfig{DT: 0.01, Duration: 600, Reference: RampReference{RateRPMPerS: 2, FinalRPM: 5000}}
+	report, _, err := RunSoak(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg, 30)
+	if err != nil {
+		t.Fatalf("RunSoak() error = %v", err)
+	}
+	if want := 0.02 / 0.05; math.Abs(report.IntegralDrift-want) > 0.01*want {
+		t.Errorf("integral drift = %v, want %v", report.IntegralDrift, want)
+	}
+	if math.Abs(report.ErrorDrift) > 1e-3 {
+		t.Errorf("error drift = %v, want a constant lag", report.ErrorDrift)
+	}
+}
+
+func TestRunSoak_PartialInterval(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 2.5}
+	report, _, err := RunSoak(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg, 1)
+	if err != nil {
+		t.Fatalf("RunSoak() error = %v", err)
+	}
+	if len(report.Snapshots) != 3 {
+		t.Fatalf("%d snapshots, want 2 full intervals and a partial one", len(report.Snapshots))
+	}
+	if last := report.Snapshots[2]; math.Abs(last.T-2.49) > eps {
+		t.Errorf("last snapshot at %v, want the last sample 2.49", last.T)
+	}
+}
+
+func TestRunSoak_Errors(t *testing.T) {
+	for _, interval := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, _, err := RunSoak(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 1}, interval); err == nil {
+			t.Errorf("interval %v: want an error", interval)
+		}
+	}
+	if _, _, err := RunSoak(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), StepConfig{TargetRPM: 1000, DT: 0, Duration: 1}, 1); err == nil {
+		t.Error("dt 0: want an error")
+	}
+
+	// Too few snapshots to fit a drift
+	report, _, err := RunSoak(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 2}, 1)
+	if err != nil || !math.IsNaN(report.IntegralDrift) || !math.IsNaN(report.ErrorDrift) {
+		t.Errorf("two snapshots: drift %v, %v (err %v), want NaN", report.ErrorDrift, report.IntegralDrift, err)
+	}
+}
@@ -1,19 +1,106 @@
 package experiment
 
 import (
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
 	"github.com/fabriziobonavita/motor-control-lab/internal/experiment/modifier"
 	"github.com/fabriziobonavita/motor-control-lab/internal/system"
 )
 
-// StepConfig defines a constant-setpoint step experiment.
+// StepConfig defines a closed-loop experiment, by default with a constant setpoint.
 type StepConfig struct {
 	TargetRPM float64
 	DT        float64
 	Duration  float64
 	Modifier  modifier.Modifier
+
+	// Reference, when non-nil, replaces the constant TargetRPM with a setpoint
+	// trajectory evaluated at each sample time (see Reference).
+	Reference Reference
+
+	// MaxAbsU, when positive, clamps the final command to ±MaxAbsU after the
+	// modifier runs, guarding the actuator against modifiers that amplify or
+	// overflow the (already clamped) controller output. NaN becomes 0. Each sample
+	// then carries the SignalUClamped signal (1 when the guard changed u, else 0).
+	MaxAbsU float64
+
+	// WarmStart starts the run at steady state: a system implementing
+	// system.SteadyStateInitializer is placed at the setpoint, and the controller's
+	// integrator is set so that its output holds it there (requires Ki != 0).
+	// It has no effect on other systems, including wrappers whose inner system
+	// cannot be initialized.
+	WarmStart bool
+
+	// SafeShutdown brings the actuator to zero at the end of the run: after the
+	// last step the runner applies Actuate(0) and steps the system once more,
+	// recorded as a final sample with U = 0 (and no controller terms), so the
+	// plant is not left running at the last command. If the run stops early
+	// (divergence or a sink error), the actuator is zeroed without a final step.
+	// Off by default; hardware-facing runs should enable it.
+	SafeShutdown bool
+
+	// Debug, when non-nil, is called after each step with the controller's full
+	// state for that step (see DebugRecord), e.g. to dump it for diagnosis.
+	Debug func(DebugRecord)
+
+	// MaxSteps caps the number of steps, Duration/DT: a longer run is rejected
+	// up front with an error wrapping errs.ErrTooManySteps instead of
+	// allocating its samples, e.g. for a tiny DT by mistake. Zero means
+	// DefaultMaxSteps; a negative value disables the cap.
+	MaxSteps int
+}
+
+// DefaultMaxSteps is the step cap of a StepConfig with MaxSteps zero: ten
+// million steps, about a gigabyte of buffered samples.
+const DefaultMaxSteps = 10_000_000
+
+// DebugRecord is the controller state of one step: the complete trace, the
+// applied command and the integrator, which a Sample does not carry.
+type DebugRecord struct {
+	T  float64
+	DT float64
+
+	Trace pid.Trace
+	// U is the applied command, after the modifier and the output guard.
+	U float64
+	// Integral is the integrator state after the step (see pid.Controller.Integral).
+	Integral float64
+}
+
+// SignalUClamped is the signal key set by RunStep when StepConfig.MaxAbsU is enabled.
+const SignalUClamped = "u_clamped"
+
+// SignalFeedforward is the signal key set by RunStep when the controller has a
+// Feedforward: the feedforward term of the controller output (V).
+const SignalFeedforward = "feedforward_v"
+
+// SignalIntegralReset is the signal key set by RunStep when the controller has
+// an IntegralResetJump: 1 on the steps where a setpoint jump reset the
+// integrator, else 0.
+const SignalIntegralReset = "integral_reset"
+
+// RunnerSignalKeys returns the signal keys the runner itself adds to samples
+// when running ctrl with cfg, in addition to those reported by the system:
+// SignalUClamped with MaxAbsU, SignalFeedforward with a Feedforward and
+// SignalIntegralReset with an IntegralResetJump.
+// Writers that fix their columns up front (e.g., a streaming CSV sink) must
+// declare these with the system's.
+func RunnerSignalKeys(cfg StepConfig, ctrl *pid.Controller) []string {
+	var keys []string
+	if cfg.MaxAbsU > 0 {
+		keys = append(keys, SignalUClamped)
+	}
+	if ctrl.Feedforward != nil {
+		keys = append(keys, SignalFeedforward)
+	}
+	if ctrl.IntegralResetJump > 0 {
+		keys = append(keys, SignalIntegralReset)
+	}
+	return keys
 }
 
 // Sample is a single time step of recorded run data.
@@ -38,73 +125,435 @@ type Sample struct {
 	// Signals contains additional numeric signals exposed by the system (e.g., disturbance_rpm_per_s).
 	// The map is nil or empty when no signals are available.
 	// Keys are stable snake_case identifiers suitable for CSV headers.
+	// The map is never aliased to the system's own state, but consecutive samples with
+	// identical signals may share one map, so treat it as read-only.
+	// System and runner signals are merged into this one map, whose iteration
+	// order is random; writers must emit keys in sorted order (as samples.csv
+	// does) so that output is identical across runs.
 	Signals map[string]float64
 }
 
 // RunStep executes the closed-loop experiment and returns the full time series.
 // The returned wall time is useful for profiling (sim should be much faster than realtime).
 //
-// RunStep is a clean generic harness: Observe -> ctrl.Step -> Modifier -> Actuate -> Step -> record sample.
+// RunStep is a clean generic harness: Observe -> ctrl.Step -> Modifier -> guard -> Actuate -> Step -> record sample.
 // It optionally queries system capabilities for logging purposes but does not apply or schedule any physics.
-func RunStep(sys system.System, ctrl *pid.Controller, cfg StepConfig) ([]Sample, time.Duration) {
+//
+// An invalid DT or Duration returns an error wrapping errs.ErrInvalidDT or
+// errs.ErrInvalidDuration and no samples, and so does a run longer than
+// cfg.MaxSteps, with errs.ErrTooManySteps. If the observed output becomes NaN or
+// infinite, the run stops and the samples before it are returned with an error
+// wrapping errs.ErrDiverged.
+func RunStep(sys system.System, ctrl *pid.Controller, cfg StepConfig) ([]Sample, time.Duration, error) {
+	return RunStepInto(nil, sys, ctrl, cfg)
+}
+
+// RealtimeFactor returns how many times faster than realtime a run simulating
+// durationS seconds went, given its wall time: durationS / wall seconds. It
+// returns 0 when wall is not positive (a run too short for the clock to measure).
+func RealtimeFactor(durationS float64, wall time.Duration) float64 {
+	if wall <= 0 {
+		return 0
+	}
+	return durationS / wall.Seconds()
+}
+
+// RunStepInto is like RunStep but fills dst (from index 0), growing it only if its
+// capacity is too small, and returns the filled slice. Reusing the returned slice
+// across runs avoids reallocating the sample buffer in large sweeps.
+//
+// Samples from a previous run stored in dst are overwritten, so callers must not
+// keep references to them when reusing the buffer.
+func RunStepInto(dst []Sample, sys system.System, ctrl *pid.Controller, cfg StepConfig) ([]Sample, time.Duration, error) {
 	start := time.Now()
 
-	if cfg.DT <= 0 || cfg.Duration <= 0 {
-		return nil, time.Since(start)
+	if err := cfg.Validate(); err != nil {
+		return dst[:0], time.Since(start), err
+	}
+
+	steps := int(cfg.Duration / cfg.DT)
+	out := dst[:0]
+	if cap(out) < steps {
+		out = make([]Sample, 0, steps)
+	}
+
+	r := newStepRunner(sys, ctrl, cfg)
+	for i := 0; i < steps; i++ {
+		s := r.step(i)
+		if err := diverged(s); err != nil {
+			r.abort()
+			return out, time.Since(start), err
+		}
+		out = append(out, s)
+	}
+	if cfg.SafeShutdown {
+		out = append(out, r.shutdown(steps))
+	}
+
+	return out, time.Since(start), nil
+}
+
+// RunStepProfiled is like RunStep but also returns the wall time spent computing
+// each step (controller, modifier, plant and signal snapshot), one entry per sample.
+// It is meant for finding simulation bottlenecks; the timing calls add a small
+// overhead, so RunStep does not profile.
+func RunStepProfiled(sys system.System, ctrl *pid.Controller, cfg StepConfig) ([]Sample, []time.Duration, time.Duration, error) {
+	start := time.Now()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, time.Since(start), err
 	}
 
 	steps := int(cfg.Duration / cfg.DT)
 	out := make([]Sample, 0, steps)
+	profile := make([]time.Duration, 0, steps)
 
-	// Optionally query system capabilities for logging (generic, no semantic knowledge)
-	var signalReporter system.SignalReporter
-	if sr, ok := sys.(system.SignalReporter); ok {
-		signalReporter = sr
+	r := newStepRunner(sys, ctrl, cfg)
+	for i := 0; i < steps; i++ {
+		t0 := time.Now()
+		s := r.step(i)
+		profile = append(profile, time.Since(t0))
+		if err := diverged(s); err != nil {
+			r.abort()
+			return out, profile[:len(out)], time.Since(start), err
+		}
+		out = append(out, s)
 	}
+	if cfg.SafeShutdown {
+		t0 := time.Now()
+		out = append(out, r.shutdown(steps))
+		profile = append(profile, time.Since(t0))
+	}
+
+	return out, profile, time.Since(start), nil
+}
 
+// RunStepStreaming is like RunStep but pushes each sample to sink as soon as it
+// is produced instead of buffering the whole run, keeping memory constant for
+// very long runs. It returns the number of samples written.
+//
+// The sink is not closed; the caller owns it. The run stops at the first write
+// error, and on the same configuration and divergence errors as RunStep.
+func RunStepStreaming(sys system.System, ctrl *pid.Controller, cfg StepConfig, sink SampleSink) (int, time.Duration, error) {
+	start := time.Now()
+
+	if err := cfg.Validate(); err != nil {
+		return 0, time.Since(start), err
+	}
+
+	steps := int(cfg.Duration / cfg.DT)
+	r := newStepRunner(sys, ctrl, cfg)
 	for i := 0; i < steps; i++ {
-		t := float64(i) * cfg.DT
+		s := r.step(i)
+		if err := diverged(s); err != nil {
+			r.abort()
+			return i, time.Since(start), err
+		}
+		if err := sink.Write(s); err != nil {
+			r.abort()
+			return i, time.Since(start), err
+		}
+	}
+	if cfg.SafeShutdown {
+		if err := sink.Write(r.shutdown(steps)); err != nil {
+			return steps, time.Since(start), err
+		}
+		steps++
+	}
 
-		actual := sys.Observe()
-		var tr pid.Trace
-		u := ctrl.Step(cfg.TargetRPM, actual, cfg.DT, &tr)
+	return steps, time.Since(start), nil
+}
+
+// target returns the setpoint at time t.
+func (cfg StepConfig) target(t float64) float64 {
+	if cfg.Reference != nil {
+		return cfg.Reference.Target(t)
+	}
+	return cfg.TargetRPM
+}
 
-		if cfg.Modifier != nil {
-			u = cfg.Modifier.Modify(u)
+// Validate checks the timing parameters of cfg and the step cap, as the
+// runners do before running, e.g. to reject a run before creating its artifacts.
+func (cfg StepConfig) Validate() error {
+	if !(cfg.DT > 0) || math.IsInf(cfg.DT, 0) {
+		return fmt.Errorf("step: dt %g: %w", cfg.DT, errs.ErrInvalidDT)
+	}
+	if !(cfg.Duration > 0) || math.IsInf(cfg.Duration, 0) {
+		return fmt.Errorf("step: duration %g: %w", cfg.Duration, errs.ErrInvalidDuration)
+	}
+	maxSteps := cfg.MaxSteps
+	if maxSteps == 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	// Compared as floats: the step count of a huge run overflows an int
+	if steps := math.Floor(cfg.Duration / cfg.DT); maxSteps > 0 && steps > float64(maxSteps) {
+		return fmt.Errorf("step: duration %gs at dt %gs is %.0f steps, more than the maximum %d: %w",
+			cfg.Duration, cfg.DT, steps, maxSteps, errs.ErrTooManySteps)
+	}
+	return nil
+}
+
+// diverged returns an errs.ErrDiverged error if the sample's output is not finite.
+func diverged(s Sample) error {
+	if math.IsNaN(s.Actual) || math.IsInf(s.Actual, 0) {
+		return fmt.Errorf("step: output %g at t=%gs: %w", s.Actual, s.T, errs.ErrDiverged)
+	}
+	return nil
+}
+
+// stepRunner holds the per-run state of the closed loop shared by the batch and
+// streaming runners.
+type stepRunner struct {
+	sys  system.System
+	ctrl *pid.Controller
+	cfg  StepConfig
+
+	// Optionally query system capabilities for logging (generic, no semantic knowledge)
+	signals *signalSnapshotter
+
+	// extraSignals (the runner's own signals) is reused across steps; snapshot copies it
+	extraSignals map[string]float64
+}
+
+// extra returns the reusable map for the runner's own signals.
+func (r *stepRunner) extra() map[string]float64 {
+	if r.extraSignals == nil {
+		r.extraSignals = make(map[string]float64, 2)
+	}
+	return r.extraSignals
+}
+
+func newStepRunner(sys system.System, ctrl *pid.Controller, cfg StepConfig) *stepRunner {
+	if cfg.WarmStart {
+		warmStart(sys, ctrl, cfg.target(0))
+	}
+	return &stepRunner{
+		sys:     sys,
+		ctrl:    ctrl,
+		cfg:     cfg,
+		signals: newSignalSnapshotter(sys),
+	}
+}
+
+// step executes step i of the loop and returns its sample.
+func (r *stepRunner) step(i int) Sample {
+	cfg := r.cfg
+	t := float64(i) * cfg.DT
+
+	actual := r.sys.Observe()
+	var tr pid.Trace
+	u := r.ctrl.Step(cfg.target(t), actual, cfg.DT, &tr)
+
+	if cfg.Modifier != nil {
+		u = modifier.Apply(cfg.Modifier, u, t, cfg.DT)
+	}
+
+	var extra map[string]float64
+	if cfg.MaxAbsU > 0 {
+		var clamped bool
+		u, clamped = guardOutput(u, cfg.MaxAbsU)
+		extra = r.extra()
+		extra[SignalUClamped] = 0
+		if clamped {
+			extra[SignalUClamped] = 1
 		}
+	}
+	if r.ctrl.Feedforward != nil {
+		extra = r.extra()
+		extra[SignalFeedforward] = tr.FF
+	}
+	if r.ctrl.IntegralResetJump > 0 {
+		extra = r.extra()
+		extra[SignalIntegralReset] = 0
+		if tr.IntegralReset {
+			extra[SignalIntegralReset] = 1
+		}
+	}
+
+	if cfg.Debug != nil {
+		cfg.Debug(DebugRecord{T: t, DT: cfg.DT, Trace: tr, U: u, Integral: r.ctrl.Integral()})
+	}
+
+	r.sys.Actuate(u)
+	r.sys.Step(cfg.DT)
+
+	// Query signals if system exposes them (for logging only)
+	sigs := r.signals.snapshot(extra)
+
+	return Sample{
+		T:          t,
+		DT:         cfg.DT,
+		Target:     tr.Target,
+		Actual:     tr.Actual,
+		Error:      tr.Error,
+		U:          u,
+		P:          tr.P,
+		I:          tr.I,
+		D:          tr.D,
+		OutRaw:     tr.OutRaw,
+		Saturated:  tr.Saturated,
+		Integrated: tr.Integrated,
+		Signals:    sigs,
+	}
+}
+
+// shutdown zeroes the actuator, steps the system once more (as step i) and
+// returns the sample recording it (see StepConfig.SafeShutdown).
+func (r *stepRunner) shutdown(i int) Sample {
+	t := float64(i) * r.cfg.DT
+	target := r.cfg.target(t)
+	actual := r.sys.Observe()
+
+	r.sys.Actuate(0)
+	r.sys.Step(r.cfg.DT)
+
+	// The runner's own signals keep their columns, at zero
+	var extra map[string]float64
+	if r.cfg.MaxAbsU > 0 {
+		extra = r.extra()
+		extra[SignalUClamped] = 0
+	}
+	if r.ctrl.Feedforward != nil {
+		extra = r.extra()
+		extra[SignalFeedforward] = 0
+	}
+	if r.ctrl.IntegralResetJump > 0 {
+		extra = r.extra()
+		extra[SignalIntegralReset] = 0
+	}
+
+	return Sample{
+		T:       t,
+		DT:      r.cfg.DT,
+		Target:  target,
+		Actual:  actual,
+		Error:   target - actual,
+		Signals: r.signals.snapshot(extra),
+	}
+}
+
+// abort zeroes the actuator of a run that stops early, if SafeShutdown is set.
+func (r *stepRunner) abort() {
+	if r.cfg.SafeShutdown {
+		r.sys.Actuate(0)
+	}
+}
+
+// signalSnapshotter copies system signals into per-sample maps.
+//
+// Signals usually stay constant for long stretches (e.g., a step disturbance),
+// so a new copy is only allocated when the reported values differ from the
+// previous snapshot; otherwise the previous snapshot is reused. Stored samples
+// are therefore never aliased to the system's own map, but consecutive samples
+// may share one snapshot and must treat Signals as read-only.
+//
+// On BenchmarkRunStep (10k steps, one signal) this halves allocations per run,
+// from ~40k to ~20k; the remainder comes from the system's own Signals() map.
+type signalSnapshotter struct {
+	sr   system.SignalReporter
+	prev map[string]float64
+}
+
+func newSignalSnapshotter(sys system.System) *signalSnapshotter {
+	sr, _ := sys.(system.SignalReporter)
+	return &signalSnapshotter{sr: sr}
+}
+
+// snapshot returns the current signals merged with extra (which takes precedence),
+// or nil if there are none.
+func (s *signalSnapshotter) snapshot(extra map[string]float64) map[string]float64 {
+	var raw map[string]float64
+	if s.sr != nil {
+		raw = s.sr.Signals()
+	}
+	if len(raw) == 0 && len(extra) == 0 {
+		s.prev = nil
+		return nil
+	}
+	if equalSignals(raw, extra, s.prev) {
+		return s.prev
+	}
+	sigs := make(map[string]float64, len(raw)+len(extra))
+	for k, v := range raw {
+		sigs[k] = v
+	}
+	for k, v := range extra {
+		sigs[k] = v
+	}
+	s.prev = sigs
+	return sigs
+}
 
-		sys.Actuate(u)
-		sys.Step(cfg.DT)
-
-		// Query signals if system exposes them (for logging only)
-		var sigs map[string]float64
-		if signalReporter != nil {
-			raw := signalReporter.Signals()
-			if len(raw) > 0 {
-				// Copy the map to avoid mutation affecting stored samples
-				sigs = make(map[string]float64, len(raw))
-				for k, v := range raw {
-					sigs[k] = v
-				}
-			}
+// equalSignals reports whether raw merged with extra equals prev, without
+// building the merged map.
+func equalSignals(raw, extra, prev map[string]float64) bool {
+	n := 0
+	for k, v := range raw {
+		if _, overridden := extra[k]; overridden {
+			continue
 		}
+		if pv, ok := prev[k]; !ok || pv != v {
+			return false
+		}
+		n++
+	}
+	for k, v := range extra {
+		if pv, ok := prev[k]; !ok || pv != v {
+			return false
+		}
+		n++
+	}
+	return n == len(prev)
+}
 
-		out = append(out, Sample{
-			T:          t,
-			DT:         cfg.DT,
-			Target:     tr.Target,
-			Actual:     tr.Actual,
-			Error:      tr.Error,
-			U:          u,
-			P:          tr.P,
-			I:          tr.I,
-			D:          tr.D,
-			OutRaw:     tr.OutRaw,
-			Saturated:  tr.Saturated,
-			Integrated: tr.Integrated,
-			Signals:    sigs,
-		})
-	}
-
-	return out, time.Since(start)
+// warmStart places sys at target and sets the integrator so that, at zero error,
+// the controller output (including any feedforward) equals the steady-state input.
+func warmStart(sys system.System, ctrl *pid.Controller, target float64) {
+	u, ok := system.InitSteadyState(sys, target)
+	if !ok {
+		return
+	}
+	if ctrl.Feedforward != nil {
+		// The feedforward term already supplies part of u
+		u -= ctrl.Feedforward(target)
+	}
+	if ctrl.Ki != 0 {
+		ctrl.SetIntegral(u / ctrl.Ki)
+	}
+}
+
+// ResumeController restores ctrl's state from the last sample of a prior run,
+// so a continuation run (on the same, still running system) proceeds as if the
+// prior run had not stopped. The integrator is reconstructed as last.I/Ki (left
+// unchanged if Ki == 0), the previous error is last.Error and the previous
+// target, for IntegralResetJump, is last.Target. The SatHysteresis saturation
+// state is the side of last.OutRaw relative to the middle of the limits when
+// last.Saturated, and not saturated otherwise.
+//
+// ctrl must have the gains and limits the prior run used.
+func ResumeController(ctrl *pid.Controller, last Sample) {
+	if ctrl.Ki != 0 {
+		ctrl.SetIntegral(last.I / ctrl.Ki)
+	}
+	ctrl.SetPrevError(last.Error)
+	ctrl.SetPrevTarget(last.Target)
+
+	sat := 0
+	switch {
+	case !last.Saturated:
+	case last.OutRaw >= (ctrl.OutMin+ctrl.OutMax)/2:
+		sat = 1
+	default:
+		sat = -1
+	}
+	ctrl.SetSaturationState(sat)
+}
+
+// guardOutput clamps u to ±limit, mapping NaN to 0, and reports whether u changed.
+func guardOutput(u, limit float64) (float64, bool) {
+	if math.IsNaN(u) {
+		return 0, true
+	}
+	g := math.Max(-limit, math.Min(u, limit))
+	return g, g != u
 }
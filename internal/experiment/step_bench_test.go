@@ -0,0 +1,22 @@
+package experiment
+
+import (
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
+)
+
+// BenchmarkRunStep measures a 10k-step run on a disturbed DC motor, which
+// reports a signal every step.
+func BenchmarkRunStep(b *testing.B) {
+	cfg := StepConfig{TargetRPM: 1000.0, DT: 0.001, Duration: 10.0}
+	dist := wrap.StepDisturbanceConfig{Enabled: true, StartS: 5.0, DurationS: 2.0, MagnitudeRPMPerS: 50.0}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sys := wrap.NewDisturbedSystem(sim.NewDCMotor(), dist)
+		RunStep(sys, pid.New(0.02, 0.05, 0.0), cfg)
+	}
+}
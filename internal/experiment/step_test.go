@@ -1,12 +1,17 @@
 package experiment
 
 import (
+	"errors"
 	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/errs"
 	"github.com/fabriziobonavita/motor-control-lab/internal/experiment/modifier"
 	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/wrap"
 )
 
 const eps = 1e-9
@@ -22,7 +27,7 @@ func TestRunStep_SmokeTest(t *testing.T) {
 		Duration:  2.0,   // Short duration for fast test
 	}
 
-	samples, _ := RunStep(plant, ctrl, cfg)
+	samples, _, _ := RunStep(plant, ctrl, cfg)
 
 	// Assert sample count > 0
 	if len(samples) == 0 {
@@ -81,35 +86,165 @@ func TestRunStep_InvalidConfig(t *testing.T) {
 	plant := sim.NewDCMotor()
 
 	tests := []struct {
-		name string
-		cfg  StepConfig
+		name    string
+		cfg     StepConfig
+		wantErr error
 	}{
 		{
-			name: "zero dt",
-			cfg:  StepConfig{TargetRPM: 1000.0, DT: 0.0, Duration: 1.0},
+			name:    "zero dt",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: 0.0, Duration: 1.0},
+			wantErr: errs.ErrInvalidDT,
 		},
 		{
-			name: "negative dt",
-			cfg:  StepConfig{TargetRPM: 1000.0, DT: -0.001, Duration: 1.0},
+			name:    "negative dt",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: -0.001, Duration: 1.0},
+			wantErr: errs.ErrInvalidDT,
 		},
 		{
-			name: "zero duration",
-			cfg:  StepConfig{TargetRPM: 1000.0, DT: 0.001, Duration: 0.0},
+			name:    "NaN dt",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: math.NaN(), Duration: 1.0},
+			wantErr: errs.ErrInvalidDT,
 		},
 		{
-			name: "negative duration",
-			cfg:  StepConfig{TargetRPM: 1000.0, DT: 0.001, Duration: -1.0},
+			name:    "zero duration",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: 0.001, Duration: 0.0},
+			wantErr: errs.ErrInvalidDuration,
+		},
+		{
+			name:    "negative duration",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: 0.001, Duration: -1.0},
+			wantErr: errs.ErrInvalidDuration,
+		},
+		{
+			name:    "infinite duration",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: 0.001, Duration: math.Inf(1)},
+			wantErr: errs.ErrInvalidDuration,
+		},
+		{
+			name:    "over the default step cap",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: 1e-6, Duration: 3600},
+			wantErr: errs.ErrTooManySteps,
+		},
+		{
+			name:    "over MaxSteps",
+			cfg:     StepConfig{TargetRPM: 1000.0, DT: 0.001, Duration: 1.0, MaxSteps: 999},
+			wantErr: errs.ErrTooManySteps,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			samples, _ := RunStep(plant, ctrl, tt.cfg)
+			samples, _, err := RunStep(plant, ctrl, tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RunStep() error = %v, want %v", err, tt.wantErr)
+			}
 			if len(samples) != 0 {
 				t.Errorf("RunStep() produced %d samples, want 0 for invalid config", len(samples))
 			}
+			if _, _, _, err := RunStepProfiled(plant, ctrl, tt.cfg); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RunStepProfiled() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunStep_MaxSteps(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxSteps int
+		wantErr  bool
+	}{
+		{"at the cap", 1000, false},
+		{"one over the cap", 999, true},
+		{"default", 0, false},
+		{"disabled", -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 1, MaxSteps: tt.maxSteps}
+			samples, _, err := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+			if tt.wantErr {
+				if !errors.Is(err, errs.ErrTooManySteps) || len(samples) != 0 {
+					t.Errorf("RunStep() = %d samples, %v; want none and ErrTooManySteps", len(samples), err)
+				}
+				return
+			}
+			if err != nil || len(samples) != 1000 {
+				t.Errorf("RunStep() = %d samples, %v; want 1000", len(samples), err)
+			}
 		})
 	}
+
+	// The error names the requested steps and the cap
+	cfg := StepConfig{TargetRPM: 1000, DT: 1e-9, Duration: 1e6}
+	err := cfg.Validate()
+	if !errors.Is(err, errs.ErrTooManySteps) || !strings.Contains(err.Error(), "1000000000000000 steps") ||
+		!strings.Contains(err.Error(), "maximum 10000000") {
+		t.Errorf("Validate() = %v, want the step count and the default cap", err)
+	}
+	if _, _, err := RunStepStreaming(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg, &countingSink{}); !errors.Is(err, errs.ErrTooManySteps) {
+		t.Errorf("RunStepStreaming() error = %v, want ErrTooManySteps", err)
+	}
+}
+
+// explodingSystem doubles its output every step, reaching +Inf after ~1024 steps.
+// It records the last command.
+type explodingSystem struct{ y, u float64 }
+
+func (s *explodingSystem) Observe() float64  { return s.y }
+func (s *explodingSystem) Actuate(u float64) { s.u = u }
+func (s *explodingSystem) Step(float64)      { s.y *= 2 }
+
+// countingSink counts the samples written to it.
+type countingSink struct{ n int }
+
+func (s *countingSink) Write(Sample) error { s.n++; return nil }
+func (s *countingSink) Close() error       { return nil }
+
+func TestRunStep_Diverged(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1, DT: 0.001, Duration: 2}
+	samples, _, err := RunStep(&explodingSystem{y: 1}, pid.New(0.02, 0.05, 0), cfg)
+	if !errors.Is(err, errs.ErrDiverged) {
+		t.Fatalf("RunStep() error = %v, want ErrDiverged", err)
+	}
+	if len(samples) == 0 || len(samples) >= 2000 {
+		t.Fatalf("got %d samples, want the finite prefix of the run", len(samples))
+	}
+	if last := samples[len(samples)-1].Actual; math.IsInf(last, 0) {
+		t.Errorf("last sample output = %v, want finite", last)
+	}
+
+	var sink countingSink
+	n, _, err := RunStepStreaming(&explodingSystem{y: 1}, pid.New(0.02, 0.05, 0), cfg, &sink)
+	if !errors.Is(err, errs.ErrDiverged) || n != len(samples) || sink.n != n {
+		t.Errorf("RunStepStreaming() = %d, %v, want %d samples and ErrDiverged", n, err, len(samples))
+	}
+}
+
+// failingSink fails every write and close with err.
+type failingSink struct{ err error }
+
+func (s failingSink) Write(Sample) error { return s.err }
+func (s failingSink) Close() error       { return s.err }
+
+func TestMultiSink(t *testing.T) {
+	var a, b countingSink
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 1}
+	n, _, err := RunStepStreaming(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg, MultiSink(&a, &b))
+	if err != nil || n != 100 || a.n != n || b.n != n {
+		t.Errorf("streamed %d samples (err %v), sinks got %d and %d; want 100 each", n, err, a.n, b.n)
+	}
+
+	// The first failure stops the write; Close reaches every sink
+	boom := errors.New("boom")
+	var after countingSink
+	sink := MultiSink(failingSink{boom}, &after)
+	if err := sink.Write(Sample{}); !errors.Is(err, boom) || after.n != 0 {
+		t.Errorf("Write() = %v with %d later writes, want boom and none", err, after.n)
+	}
+	if err := sink.Close(); !errors.Is(err, boom) {
+		t.Errorf("Close() = %v, want boom", err)
+	}
 }
 
 func TestRunStep_WithDeadzone(t *testing.T) {
@@ -127,7 +262,7 @@ func TestRunStep_WithDeadzone(t *testing.T) {
 		Modifier:  mod,
 	}
 
-	samples, _ := RunStep(plant, ctrl, cfg)
+	samples, _, _ := RunStep(plant, ctrl, cfg)
 
 	if len(samples) == 0 {
 		t.Fatal("no samples produced")
@@ -185,7 +320,7 @@ func TestRunStep_WithLargeDeadzone(t *testing.T) {
 		Modifier:  mod,
 	}
 
-	samples, _ := RunStep(plant, ctrl, cfg)
+	samples, _, _ := RunStep(plant, ctrl, cfg)
 
 	if len(samples) == 0 {
 		t.Fatal("no samples produced")
@@ -231,7 +366,7 @@ func TestRunStep_WithDeadzoneNegativeCommands(t *testing.T) {
 	// Start with some velocity
 	plant.VelocityRPM = 100.0
 
-	samples, _ := RunStep(plant, ctrl, cfg)
+	samples, _, _ := RunStep(plant, ctrl, cfg)
 
 	if len(samples) == 0 {
 		t.Fatal("no samples produced")
@@ -275,9 +410,9 @@ func TestRunStep_ModifierNilVsSet(t *testing.T) {
 		Modifier:  modifier.Chain(&modifier.DeadzoneModifier{Threshold: 0.1}),
 	}
 
-	samplesNoMod, _ := RunStep(plant, ctrl, cfgNoMod)
+	samplesNoMod, _, _ := RunStep(plant, ctrl, cfgNoMod)
 	plant2 := sim.NewDCMotor() // Fresh plant
-	samplesWithMod, _ := RunStep(plant2, ctrl, cfgWithMod)
+	samplesWithMod, _, _ := RunStep(plant2, ctrl, cfgWithMod)
 
 	if len(samplesNoMod) == 0 || len(samplesWithMod) == 0 {
 		t.Fatal("no samples produced")
@@ -315,3 +450,565 @@ func abs(f float64) float64 {
 	}
 	return f
 }
+
+// aliasingSignalSystem returns its internal signal map directly, so any
+// aliasing in RunStep would leak later mutations into stored samples.
+type aliasingSignalSystem struct {
+	v       float64
+	u       float64
+	steps   int
+	signals map[string]float64
+}
+
+func (s *aliasingSignalSystem) Observe() float64  { return s.v }
+func (s *aliasingSignalSystem) Actuate(u float64) { s.u = u }
+func (s *aliasingSignalSystem) Step(dt float64) {
+	s.v += s.u * dt
+	s.steps++
+	// Signal changes every 10 steps, constant in between
+	s.signals["level"] = float64(s.steps / 10)
+}
+func (s *aliasingSignalSystem) Signals() map[string]float64 { return s.signals }
+
+func TestRunStep_SignalSnapshotsAreIndependent(t *testing.T) {
+	sys := &aliasingSignalSystem{signals: map[string]float64{}}
+	ctrl := pid.New(0.1, 0, 0)
+
+	samples, _, _ := RunStep(sys, ctrl, StepConfig{TargetRPM: 10.0, DT: 0.01, Duration: 0.5})
+	if len(samples) != 50 {
+		t.Fatalf("got %d samples, want 50", len(samples))
+	}
+
+	// Each sample records the signal value reported after its step
+	for i, s := range samples {
+		want := float64((i + 1) / 10)
+		if got := s.Signals["level"]; got != want {
+			t.Errorf("sample %d: level = %v, want %v", i, got, want)
+		}
+	}
+
+	// Mutating the system's map after the run must not affect stored samples
+	sys.signals["level"] = -1
+	sys.signals["extra"] = 42
+	for i, s := range samples {
+		if s.Signals["level"] == -1 {
+			t.Fatalf("sample %d: signals alias the system's map", i)
+		}
+		if _, ok := s.Signals["extra"]; ok {
+			t.Fatalf("sample %d: signals alias the system's map (extra key visible)", i)
+		}
+	}
+}
+
+func TestRunStepInto_ReusesBufferAcrossRunLengths(t *testing.T) {
+	durations := []float64{1.0, 0.5, 2.0, 0.25}
+
+	var buf []Sample
+	for _, d := range durations {
+		cfg := StepConfig{TargetRPM: 1000.0, DT: 0.005, Duration: d}
+
+		want, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0.0), cfg)
+
+		prevCap := cap(buf)
+		var got []Sample
+		got, _, _ = RunStepInto(buf, sim.NewDCMotor(), pid.New(0.02, 0.05, 0.0), cfg)
+
+		if len(got) != len(want) {
+			t.Fatalf("duration %v: got %d samples, want %d", d, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].T != want[i].T || got[i].Actual != want[i].Actual || got[i].U != want[i].U {
+				t.Fatalf("duration %v: sample %d = %+v, want %+v", d, i, got[i], want[i])
+			}
+		}
+
+		// A shorter run must reuse the existing backing array
+		if len(want) <= prevCap && cap(got) != prevCap {
+			t.Errorf("duration %v: buffer reallocated (cap %d -> %d) although it was large enough", d, prevCap, cap(got))
+		}
+		buf = got
+	}
+}
+
+func TestRunStepInto_InvalidConfigResetsBuffer(t *testing.T) {
+	buf, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0.0), StepConfig{TargetRPM: 1000.0, DT: 0.01, Duration: 1.0})
+
+	got, _, _ := RunStepInto(buf, sim.NewDCMotor(), pid.New(0.02, 0.05, 0.0), StepConfig{TargetRPM: 1000.0, DT: 0, Duration: 1.0})
+	if len(got) != 0 {
+		t.Errorf("got %d samples, want 0 for invalid config", len(got))
+	}
+}
+
+// funcModifier adapts a function to modifier.Modifier.
+type funcModifier func(float64) float64
+
+func (f funcModifier) Modify(u float64) float64 { return f(u) }
+
+func TestRunStep_OutputGuard(t *testing.T) {
+	tests := []struct {
+		name        string
+		mod         modifier.Modifier
+		wantU       float64
+		wantClamped float64
+	}{
+		{"overflowing gain", funcModifier(func(u float64) float64 { return u * 1e308 * 1e308 }), 24, 1},
+		{"large negative", funcModifier(func(u float64) float64 { return -1000 }), -24, 1},
+		{"NaN", funcModifier(func(u float64) float64 { return math.NaN() }), 0, 1},
+		{"within limit", funcModifier(func(u float64) float64 { return 3 }), 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sys := &aliasingSignalSystem{signals: map[string]float64{}}
+			cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 0.1, Modifier: tt.mod, MaxAbsU: 24}
+			samples, _, _ := RunStep(sys, pid.New(0.1, 0, 0), cfg)
+
+			for i, s := range samples {
+				if s.U != tt.wantU {
+					t.Fatalf("sample %d: U = %v, want %v", i, s.U, tt.wantU)
+				}
+				if got := s.Signals[SignalUClamped]; got != tt.wantClamped {
+					t.Fatalf("sample %d: %s = %v, want %v", i, SignalUClamped, got, tt.wantClamped)
+				}
+				if _, ok := s.Signals["level"]; !ok {
+					t.Fatalf("sample %d: system signals were dropped", i)
+				}
+			}
+			if _, ok := sys.signals[SignalUClamped]; ok {
+				t.Error("guard signal leaked into the system's own signal map")
+			}
+		})
+	}
+}
+
+func TestRunStep_NoGuardNoSignal(t *testing.T) {
+	plant := sim.NewDCMotor()
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 0.1}
+	samples, _, _ := RunStep(plant, pid.New(0.1, 0, 0), cfg)
+
+	for i, s := range samples {
+		if s.Signals != nil {
+			t.Fatalf("sample %d: Signals = %v, want nil without the guard", i, s.Signals)
+		}
+	}
+	if keys := RunnerSignalKeys(cfg, pid.New(0.1, 0, 0)); keys != nil {
+		t.Errorf("SignalKeys() = %v, want nil", keys)
+	}
+}
+
+func TestRunStepProfiled(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 0.5}
+	samples, profile, wall, err := RunStepProfiled(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatalf("RunStepProfiled() error = %v", err)
+	}
+
+	if len(profile) != len(samples) {
+		t.Fatalf("len(profile) = %d, want %d (one per sample)", len(profile), len(samples))
+	}
+	var total time.Duration
+	for i, d := range profile {
+		if d < 0 {
+			t.Fatalf("profile[%d] = %v, want non-negative", i, d)
+		}
+		total += d
+	}
+	if total > wall {
+		t.Errorf("sum of step times %v exceeds the run's wall time %v", total, wall)
+	}
+
+	// Profiling must not change the results
+	want, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+	for i := range want {
+		if samples[i].U != want[i].U || samples[i].Actual != want[i].Actual {
+			t.Fatalf("sample %d differs from RunStep", i)
+		}
+	}
+
+	if s, p, _, _ := RunStepProfiled(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), StepConfig{DT: 0}); s != nil || p != nil {
+		t.Errorf("invalid config: got %d samples, %d profile entries, want none", len(s), len(p))
+	}
+}
+
+func TestRealtimeFactor(t *testing.T) {
+	tests := []struct {
+		name      string
+		durationS float64
+		wall      time.Duration
+		want      float64
+	}{
+		{"faster than realtime", 2, time.Second, 2},
+		{"sub-second wall time", 2, 500 * time.Millisecond, 4},
+		{"slower than realtime", 1, 4 * time.Second, 0.25},
+		{"zero wall time (unmeasurable)", 2, 0, 0},
+		{"negative wall time", 2, -time.Second, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RealtimeFactor(tt.durationS, tt.wall); got != tt.want {
+				t.Errorf("RealtimeFactor(%v, %v) = %v, want %v", tt.durationS, tt.wall, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStep_ActuatorFault(t *testing.T) {
+	cfg := StepConfig{
+		TargetRPM: 1000,
+		DT:        0.01,
+		Duration:  2.0,
+		Modifier:  &modifier.FaultModifier{FaultStartS: 1.0, StuckValue: 0},
+	}
+	samples, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+
+	for _, s := range samples {
+		switch {
+		case s.T < 1.0-eps && s.U == 0:
+			t.Fatalf("t=%v: command overridden before the fault", s.T)
+		case s.T >= 1.0-eps && s.U != 0:
+			t.Fatalf("t=%v: U = %v, want stuck at 0 after the fault", s.T, s.U)
+		}
+	}
+	// The controller keeps pushing, but the motor coasts down
+	if last := samples[len(samples)-1]; last.OutRaw == 0 || last.Actual >= samples[99].Actual {
+		t.Errorf("expected a live controller and a decaying speed after the fault, got %+v", last)
+	}
+}
+
+// timeRecorder is a TimedModifier that records the times it is called with.
+type timeRecorder struct {
+	ts, dts []float64
+}
+
+func (r *timeRecorder) Modify(u float64) float64 { return u }
+
+func (r *timeRecorder) ModifyAt(u, t, dt float64) float64 {
+	r.ts = append(r.ts, t)
+	r.dts = append(r.dts, dt)
+	return u
+}
+
+func TestRunStep_TimedModifierReceivesTime(t *testing.T) {
+	rec := &timeRecorder{}
+	cfg := StepConfig{TargetRPM: 100, DT: 0.02, Duration: 1.0, Modifier: modifier.Chain(rec)}
+	samples, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+
+	if len(rec.ts) != len(samples) {
+		t.Fatalf("ModifyAt called %d times, want %d", len(rec.ts), len(samples))
+	}
+	for i, s := range samples {
+		if rec.ts[i] != s.T || rec.dts[i] != cfg.DT {
+			t.Fatalf("call %d: t=%v dt=%v, want t=%v dt=%v", i, rec.ts[i], rec.dts[i], s.T, cfg.DT)
+		}
+	}
+}
+
+func TestRunStep_WarmStartHasNoInitialTransient(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 2.0}
+
+	cold, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+	cfg.WarmStart = true
+	warm, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+
+	if math.Abs(cold[0].Error) < 100 {
+		t.Fatalf("cold start should begin far from the setpoint, error = %v", cold[0].Error)
+	}
+	for i, s := range warm {
+		if math.Abs(s.Error) > 1e-6 {
+			t.Fatalf("sample %d: warm-started error = %v, want ≈0 throughout", i, s.Error)
+		}
+		if math.Abs(s.U-10.0) > 1e-6 {
+			t.Fatalf("sample %d: U = %v, want the steady-state 10V", i, s.U)
+		}
+	}
+}
+
+func TestRunStep_WarmStartThroughDisturbedSystem(t *testing.T) {
+	plant := sim.NewDCMotor()
+	sys := wrap.NewDisturbedSystem(plant, wrap.StepDisturbanceConfig{Enabled: true, StartS: 1.0, MagnitudeRPMPerS: 50})
+	cfg := StepConfig{TargetRPM: 800, DT: 0.001, Duration: 2.0, WarmStart: true}
+	samples, _, _ := RunStep(sys, pid.New(0.02, 0.05, 0), cfg)
+
+	var maxAfter float64
+	for _, s := range samples {
+		if s.T < 1.0 && math.Abs(s.Error) > 1e-6 {
+			t.Fatalf("t=%v: error = %v before the disturbance, want ≈0", s.T, s.Error)
+		}
+		if s.T >= 1.0 {
+			maxAfter = math.Max(maxAfter, math.Abs(s.Error))
+		}
+	}
+	if maxAfter < 1.0 {
+		t.Errorf("max error after the disturbance = %v, want a visible dip", maxAfter)
+	}
+}
+
+func TestResumeController_ContinuesRun(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 2}
+	tests := []struct {
+		name    string
+		newCtrl func() *pid.Controller
+	}{
+		{"plain", func() *pid.Controller { return pid.New(0.02, 0.05, 0.001) }},
+		{"sat hysteresis", func() *pid.Controller {
+			// The output hovers inside the hysteresis band below OutMax, still
+			// counted as saturated, when the run is split
+			c := pid.New(0.02, 0.05, 0.001)
+			c.OutMin, c.OutMax, c.SatHysteresis = -10.5, 10.5, 2
+			return c
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			full := cfg
+			full.Duration = 4
+			want, _, err := RunStep(sim.NewDCMotor(), tt.newCtrl(), full)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// First half, then a fresh controller resumed from its last sample on the same plant
+			plant := sim.NewDCMotor()
+			first, _, err := RunStep(plant, tt.newCtrl(), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctrl := tt.newCtrl()
+			ResumeController(ctrl, first[len(first)-1])
+			second, _, err := RunStep(plant, ctrl, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// I/Ki may differ from the original integrator in the last bit
+			for i, s := range second {
+				w := want[len(first)+i]
+				if math.Abs(s.U-w.U) > 1e-9 || math.Abs(s.D-w.D) > 1e-9 || math.Abs(s.Actual-w.Actual) > 1e-9 || s.Saturated != w.Saturated {
+					t.Fatalf("continued step %d: u=%v d=%v actual=%v saturated=%v, want u=%v d=%v actual=%v saturated=%v",
+						i, s.U, s.D, s.Actual, s.Saturated, w.U, w.D, w.Actual, w.Saturated)
+				}
+			}
+		})
+	}
+}
+
+func TestResumeController_IntegralResetOnJump(t *testing.T) {
+	// The prior run held 500 RPM; resuming at 1000 RPM is a setpoint jump
+	newCtrl := func() *pid.Controller {
+		c := pid.New(0.02, 0.05, 0)
+		c.IntegralResetJump = 100
+		return c
+	}
+	plant := sim.NewDCMotor()
+	first, _, err := RunStep(plant, newCtrl(), StepConfig{TargetRPM: 500, DT: 0.001, Duration: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := newCtrl()
+	ResumeController(ctrl, first[len(first)-1])
+	second, _, err := RunStep(plant, ctrl, StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := second[0].Signals[SignalIntegralReset]; got != 1 {
+		t.Errorf("integral_reset on the first resumed step = %v, want 1", got)
+	}
+}
+
+func TestRunStep_ModelFeedforward(t *testing.T) {
+	// P-only: feedback alone leaves a steady-state error, a matched model's
+	// feedforward removes it without an integrator
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 3}
+
+	feedback, _, _ := RunStep(sim.NewDCMotor(), pid.New(0.02, 0, 0), cfg)
+
+	ctrl := pid.New(0.02, 0, 0)
+	model := sim.NewDCMotor() // matched: same parameters as the plant
+	ctrl.Feedforward = model.SteadyStateVoltage
+	withFF, _, err := RunStep(sim.NewDCMotor(), ctrl, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e := feedback[len(feedback)-1].Error; math.Abs(e-1000.0/3) > 1 {
+		t.Fatalf("feedback-only final error = %v, want ≈333 (P-only offset)", e)
+	}
+	last := withFF[len(withFF)-1]
+	if math.Abs(last.Error) > 0.5 {
+		t.Errorf("final error with feedforward = %v, want ≈0 with a matched model", last.Error)
+	}
+	// The loop's time constant shrinks to tau/(1+Kp*K): within 2% well before 1s
+	for _, s := range withFF {
+		if s.T >= 0.7 && math.Abs(s.Error) > 20 {
+			t.Fatalf("t=%v: error %v still outside the 2%% band", s.T, s.Error)
+		}
+	}
+	if ff := last.Signals[SignalFeedforward]; math.Abs(ff-10) > 1e-9 {
+		t.Errorf("%s = %v, want the 10 V steady-state input", SignalFeedforward, ff)
+	}
+	if sum := last.P + last.I + last.D + last.Signals[SignalFeedforward]; math.Abs(sum-last.OutRaw) > 1e-9 {
+		t.Errorf("P+I+D+FF = %v, want OutRaw %v", sum, last.OutRaw)
+	}
+}
+
+func TestRunStep_WarmStartWithFeedforward(t *testing.T) {
+	ctrl := pid.New(0.02, 0.05, 0)
+	ctrl.Feedforward = sim.NewDCMotor().SteadyStateVoltage
+	samples, _, _ := RunStep(sim.NewDCMotor(), ctrl, StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 1, WarmStart: true})
+	for i, s := range samples {
+		if math.Abs(s.Error) > 1e-6 || math.Abs(s.U-10) > 1e-6 {
+			t.Fatalf("sample %d: error = %v, U = %v; want a flat 10 V steady state", i, s.Error, s.U)
+		}
+	}
+}
+
+func TestRunStep_Debug(t *testing.T) {
+	var records []DebugRecord
+	ctrl := pid.New(0.02, 0.05, 0.001)
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 0.5,
+		Debug: func(rec DebugRecord) { records = append(records, rec) }}
+	samples, _, err := RunStep(sim.NewDCMotor(), ctrl, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != len(samples) {
+		t.Fatalf("%d debug records for %d samples", len(records), len(samples))
+	}
+	for i, rec := range records {
+		s := samples[i]
+		if rec.T != s.T || rec.DT != s.DT || rec.U != s.U || rec.Trace.P != s.P || rec.Trace.I != s.I ||
+			rec.Trace.OutRaw != s.OutRaw || rec.Trace.Saturated != s.Saturated || rec.Trace.Integrated != s.Integrated {
+			t.Fatalf("record %d = %+v, does not match sample %+v", i, rec, s)
+		}
+		// The I term is Ki times the integrator state recorded after the step
+		if math.Abs(rec.Trace.I-ctrl.Ki*rec.Integral) > eps {
+			t.Fatalf("record %d: I = %v, want Ki*Integral = %v", i, rec.Trace.I, ctrl.Ki*rec.Integral)
+		}
+	}
+	if last := records[len(records)-1]; last.Integral != ctrl.Integral() {
+		t.Errorf("last record integral = %v, want the controller's %v", last.Integral, ctrl.Integral())
+	}
+}
+
+func TestRunStep_SafeShutdown(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 1, MaxAbsU: 24}
+
+	plain, _, err := RunStep(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := plain[len(plain)-1]; last.U == 0 {
+		t.Fatal("without SafeShutdown the last command should be the controller's")
+	}
+
+	cfg.SafeShutdown = true
+	plant := sim.NewDCMotor()
+	samples, _, err := RunStep(plant, pid.New(0.02, 0.05, 0), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != len(plain)+1 {
+		t.Fatalf("%d samples, want %d (one final shutdown sample)", len(samples), len(plain)+1)
+	}
+	for i := range plain {
+		if samples[i].U != plain[i].U {
+			t.Fatalf("sample %d: U = %v, want %v (the run itself is unchanged)", i, samples[i].U, plain[i].U)
+		}
+	}
+	final := samples[len(samples)-1]
+	if final.U != 0 || final.P != 0 || final.I != 0 || final.OutRaw != 0 {
+		t.Errorf("final sample = %+v, want a zero command", final)
+	}
+	if math.Abs(final.T-1) > eps || math.Abs(final.Error-(final.Target-final.Actual)) > eps {
+		t.Errorf("final sample = %+v, want t=1 with a consistent error", final)
+	}
+	if _, ok := final.Signals[SignalUClamped]; !ok {
+		t.Errorf("final sample lacks the %s signal", SignalUClamped)
+	}
+	// The plant was stepped with zero voltage: it slows down
+	if plant.Observe() >= final.Actual {
+		t.Errorf("velocity after shutdown = %v, want below %v", plant.Observe(), final.Actual)
+	}
+}
+
+func TestRunStepStreaming_SafeShutdown(t *testing.T) {
+	cfg := StepConfig{TargetRPM: 1000, DT: 0.01, Duration: 0.5, SafeShutdown: true}
+	ring := NewRingRecorder(1)
+	n, _, err := RunStepStreaming(sim.NewDCMotor(), pid.New(0.02, 0.05, 0), cfg, ring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 51 {
+		t.Errorf("streamed %d samples, want 51", n)
+	}
+	if last := ring.Samples()[0]; last.U != 0 {
+		t.Errorf("last streamed command = %v, want 0", last.U)
+	}
+}
+
+func TestRunStep_SafeShutdownOnDivergence(t *testing.T) {
+	sys := &explodingSystem{y: 1}
+	cfg := StepConfig{TargetRPM: 1, DT: 0.001, Duration: 2, SafeShutdown: true}
+	if _, _, err := RunStep(sys, pid.New(0.02, 0.05, 0), cfg); !errors.Is(err, errs.ErrDiverged) {
+		t.Fatalf("err = %v, want ErrDiverged", err)
+	}
+	if sys.u != 0 {
+		t.Errorf("actuator left at %v after divergence, want 0", sys.u)
+	}
+}
+
+// twoStepReference holds First until At, then Second.
+type twoStepReference struct{ First, Second, At float64 }
+
+func (r twoStepReference) Target(t float64) float64 {
+	if t < r.At {
+		return r.First
+	}
+	return r.Second
+}
+
+func TestRunStep_IntegralResetOnSetpointJump(t *testing.T) {
+	ref := twoStepReference{First: 500, Second: 1000, At: 5}
+	cfg := StepConfig{Reference: ref, DT: 0.001, Duration: 10}
+	run := func(jump float64) []Sample {
+		ctrl := pid.New(0.02, 0.05, 0)
+		ctrl.IntegralResetJump = jump
+		samples, _, err := RunStep(sim.NewDCMotor(), ctrl, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return samples
+	}
+	overshoot := func(samples []Sample) float64 {
+		peak := 0.0
+		for _, s := range samples[5000:] {
+			peak = math.Max(peak, s.Actual-ref.Second)
+		}
+		return peak
+	}
+
+	plain, reset := run(0), run(100)
+	if _, ok := plain[0].Signals[SignalIntegralReset]; ok {
+		t.Error("integral_reset signal present without IntegralResetJump")
+	}
+
+	// The reset fires once, at the jump, and clears the integral term
+	const jumpAt = 5000
+	for i, s := range reset {
+		if want := i == jumpAt; (s.Signals[SignalIntegralReset] == 1) != want {
+			t.Errorf("sample %d (t=%v): integral_reset = %v", i, s.T, s.Signals[SignalIntegralReset])
+		}
+	}
+	if before, after := reset[jumpAt-1].I, reset[jumpAt].I; !(math.Abs(after) < 0.01*before) {
+		t.Errorf("integral term %v before the jump, %v after; want it cleared", before, after)
+	}
+	if reset[jumpAt].I >= plain[jumpAt].I {
+		t.Errorf("integral term at the jump = %v, want below %v without reset", reset[jumpAt].I, plain[jumpAt].I)
+	}
+
+	if o, p := overshoot(reset), overshoot(plain); !(o < p) {
+		t.Errorf("overshoot of the second step = %v RPM with reset, want below %v without", o, p)
+	}
+}
@@ -0,0 +1,76 @@
+package tuning
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+// ViolationPenalty is added to the cost of a candidate for each violated
+// constraint. It dwarfs any realistic cost, so a feasible candidate always
+// beats an infeasible one; the penalty also grows with the size of the
+// violation, so a search in an infeasible region is led back towards the
+// feasible one.
+const ViolationPenalty = 1000.0
+
+// Constraints are practical limits on tuned gains and their step responses.
+// Zero limits are disabled; negative gains are rejected unless
+// AllowNegativeGains is set.
+type Constraints struct {
+	AllowNegativeGains bool
+
+	// MaxOvershootPercent bounds the overshoot of the response.
+	MaxOvershootPercent float64
+	// MaxSettlingS bounds the settling time; a response that never settles violates it.
+	MaxSettlingS float64
+}
+
+// projectGains returns gains with negative entries raised to zero (unless
+// allowed), and the penalty for the entries it changed.
+func (c Constraints) projectGains(gains [3]float64) ([3]float64, float64) {
+	if c.AllowNegativeGains {
+		return gains, 0
+	}
+	var p float64
+	for i, g := range gains {
+		if g < 0 {
+			p += ViolationPenalty * (1 + math.Abs(g))
+			gains[i] = 0
+		}
+	}
+	return gains, p
+}
+
+// metricsPenalty returns the penalty for the response limits m violates.
+func (c Constraints) metricsPenalty(m analysis.Metrics) float64 {
+	var p float64
+	if c.MaxOvershootPercent > 0 && m.OvershootPercent > c.MaxOvershootPercent {
+		p += ViolationPenalty * (1 + (m.OvershootPercent-c.MaxOvershootPercent)/c.MaxOvershootPercent)
+	}
+	if c.MaxSettlingS > 0 {
+		switch ts := m.SettlingTimeSeconds; {
+		case math.IsNaN(ts):
+			p += 2 * ViolationPenalty
+		case ts > c.MaxSettlingS:
+			p += ViolationPenalty * (1 + (ts-c.MaxSettlingS)/c.MaxSettlingS)
+		}
+	}
+	return p
+}
+
+// Penalized returns cost plus the penalty for the response limits of c that
+// a run's metrics violate.
+func (c Constraints) Penalized(cost CostFunc) CostFunc {
+	return func(m analysis.Metrics) float64 {
+		return cost(m) + c.metricsPenalty(m)
+	}
+}
+
+// ConstrainedObjective is like ObjectiveWith but penalizes candidates that
+// violate c (see ViolationPenalty). A candidate with rejected negative gains
+// costs as much as the same candidate with those gains at zero, plus the
+// penalty, so it is always worse than its feasible neighbor.
+func ConstrainedObjective(gains [3]float64, scenario Scenario, cost CostFunc, c Constraints) float64 {
+	gains, p := c.projectGains(gains)
+	return ObjectiveWith(gains, scenario, c.Penalized(cost)) + p
+}
@@ -0,0 +1,86 @@
+package tuning
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// laggedMetrics runs laggedMotor with gains and returns its metrics.
+func laggedMetrics(t *testing.T, gains []float64) analysis.Metrics {
+	t.Helper()
+	sys, ctrl, cfg := laggedMotor()
+	ctrl.Kp, ctrl.Ki, ctrl.Kd = gains[0], gains[1], gains[2]
+	samples, _, err := experiment.RunStep(sys, ctrl, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return analysis.Compute(samples, SettleBandFrac)
+}
+
+func TestTune_AvoidsOvershootRegion(t *testing.T) {
+	// Minimizing IAE alone on the lagged motor accepts some overshoot...
+	opts := NelderMeadOptions{MaxIter: 300}
+	free := Tune(laggedMotor, [3]float64{0.02, 0.05, 0}, IAECost, Constraints{}, opts)
+	freeM := laggedMetrics(t, free.X)
+	if freeM.OvershootPercent <= 1 {
+		t.Fatalf("unconstrained optimum overshoots %.2f%%, want > 1%% for this test", freeM.OvershootPercent)
+	}
+
+	// ...which a 1% limit excludes, at the price of a higher IAE
+	limited := Tune(laggedMotor, [3]float64{0.02, 0.05, 0}, IAECost, Constraints{MaxOvershootPercent: 1}, opts)
+	limitedM := laggedMetrics(t, limited.X)
+	if limited.F >= ViolationPenalty {
+		t.Fatalf("no feasible candidate found (cost %v)", limited.F)
+	}
+	if limitedM.OvershootPercent > 1 {
+		t.Errorf("constrained result overshoots %.3f%%, want ≤ 1%%", limitedM.OvershootPercent)
+	}
+	if !(limitedM.IAE > freeM.IAE) {
+		t.Errorf("constrained IAE %v, want above the unconstrained %v", limitedM.IAE, freeM.IAE)
+	}
+}
+
+func TestConstrainedObjective_NegativeGains(t *testing.T) {
+	negative := [3]float64{0.02, 0.05, -0.001}
+	projected := [3]float64{0.02, 0.05, 0}
+
+	got := ConstrainedObjective(negative, laggedMotor, IAECost, Constraints{})
+	want := ObjectiveWith(projected, laggedMotor, IAECost) + ViolationPenalty*1.001
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("cost of negative kd = %v, want %v (zero kd plus penalty)", got, want)
+	}
+
+	allowed := ConstrainedObjective(negative, laggedMotor, IAECost, Constraints{AllowNegativeGains: true})
+	if want := ObjectiveWith(negative, laggedMotor, IAECost); allowed != want {
+		t.Errorf("cost with negative gains allowed = %v, want the plain objective %v", allowed, want)
+	}
+}
+
+func TestConstraints_Penalized(t *testing.T) {
+	c := Constraints{MaxOvershootPercent: 5, MaxSettlingS: 1}
+	cost := c.Penalized(func(analysis.Metrics) float64 { return 1 })
+
+	tests := []struct {
+		name string
+		m    analysis.Metrics
+		want float64
+	}{
+		{"feasible", analysis.Metrics{OvershootPercent: 5, SettlingTimeSeconds: 1}, 1},
+		{"overshoot", analysis.Metrics{OvershootPercent: 10, SettlingTimeSeconds: 0.5}, 1 + 2*ViolationPenalty},
+		{"slow", analysis.Metrics{SettlingTimeSeconds: 1.5}, 1 + 1.5*ViolationPenalty},
+		{"never settles", analysis.Metrics{SettlingTimeSeconds: math.NaN()}, 1 + 2*ViolationPenalty},
+		{"both", analysis.Metrics{OvershootPercent: 7.5, SettlingTimeSeconds: 2}, 1 + 3.5*ViolationPenalty},
+	}
+	for _, tt := range tests {
+		if got := cost(tt.m); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: cost = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := (Constraints{}).Penalized(IAECost)(analysis.Metrics{OvershootPercent: 50, SettlingTimeSeconds: math.NaN(), IAE: 2}); got != 2 {
+		t.Errorf("cost without limits = %v, want the plain cost 2", got)
+	}
+}
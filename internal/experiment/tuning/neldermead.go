@@ -0,0 +1,160 @@
+package tuning
+
+import (
+	"math"
+	"sort"
+)
+
+// NelderMeadOptions configures NelderMead. Zero values select the defaults.
+type NelderMeadOptions struct {
+	// MaxIter bounds the number of iterations (default 200).
+	MaxIter int
+	// Tol stops the search once the costs at the simplex vertices differ by
+	// less than Tol (default 1e-6).
+	Tol float64
+	// Step is the initial simplex size along each dimension. By default it is
+	// 5% of the start value, or 0.00025 for a start value of zero.
+	Step []float64
+}
+
+// Point is one candidate evaluated during a search.
+type Point struct {
+	Iteration int
+	X         []float64
+	F         float64
+}
+
+// Result is the outcome of a search.
+type Result struct {
+	X          []float64 // best point found
+	F          float64   // its cost
+	Iterations int
+	Converged  bool // whether Tol was reached within MaxIter
+
+	// Trajectory is the best point after each iteration (the start simplex's
+	// best first, at iteration 0), so the search's progress can be inspected.
+	Trajectory []Point
+	// Evaluations counts the calls of f.
+	Evaluations int
+}
+
+// NelderMead minimizes f starting from x0 with the Nelder-Mead downhill simplex
+// method, using the standard coefficients (reflection 1, expansion 2,
+// contraction 0.5, shrink 0.5). It needs no derivatives and tolerates +Inf
+// costs (e.g., diverged runs), which are never accepted over a finite one.
+// The search is deterministic.
+func NelderMead(f func([]float64) float64, x0 []float64, opts NelderMeadOptions) Result {
+	if opts.MaxIter <= 0 {
+		opts.MaxIter = 200
+	}
+	if opts.Tol <= 0 {
+		opts.Tol = 1e-6
+	}
+	n := len(x0)
+
+	var res Result
+	eval := func(x []float64) float64 {
+		res.Evaluations++
+		return f(x)
+	}
+
+	// Start simplex: x0 and one vertex displaced along each dimension
+	simplex := make([]Point, n+1)
+	simplex[0] = Point{X: append([]float64(nil), x0...)}
+	for i := 0; i < n; i++ {
+		x := append([]float64(nil), x0...)
+		x[i] += initialStep(x0, opts.Step, i)
+		simplex[i+1] = Point{X: x}
+	}
+	for i := range simplex {
+		simplex[i].F = eval(simplex[i].X)
+	}
+
+	record := func(iter int) {
+		sort.SliceStable(simplex, func(a, b int) bool { return simplex[a].F < simplex[b].F })
+		best := simplex[0]
+		res.Trajectory = append(res.Trajectory, Point{Iteration: iter, X: append([]float64(nil), best.X...), F: best.F})
+	}
+	record(0)
+
+	for iter := 1; iter <= opts.MaxIter; iter++ {
+		if spread := simplex[n].F - simplex[0].F; spread < opts.Tol {
+			res.Converged = true
+			break
+		}
+		res.Iterations = iter
+
+		// Centroid of all vertices but the worst
+		centroid := make([]float64, n)
+		for _, p := range simplex[:n] {
+			for j, v := range p.X {
+				centroid[j] += v / float64(n)
+			}
+		}
+		along := func(coef float64) Point {
+			x := make([]float64, n)
+			for j := range x {
+				x[j] = centroid[j] + coef*(simplex[n].X[j]-centroid[j])
+			}
+			return Point{X: x, F: eval(x)}
+		}
+
+		best, secondWorst, worst := simplex[0].F, simplex[n-1].F, simplex[n].F
+		switch r := along(-1); {
+		case r.F < best:
+			if e := along(-2); e.F < r.F {
+				simplex[n] = e
+			} else {
+				simplex[n] = r
+			}
+		case r.F < secondWorst:
+			simplex[n] = r
+		default:
+			// Contract towards the better of the worst and the reflected point
+			c := along(0.5)
+			if r.F < worst {
+				c = along(-0.5)
+			}
+			if c.F < math.Min(r.F, worst) {
+				simplex[n] = c
+				break
+			}
+			// Shrink every vertex towards the best
+			for i := 1; i <= n; i++ {
+				for j := range simplex[i].X {
+					simplex[i].X[j] = simplex[0].X[j] + 0.5*(simplex[i].X[j]-simplex[0].X[j])
+				}
+				simplex[i].F = eval(simplex[i].X)
+			}
+		}
+		record(iter)
+	}
+	if !res.Converged && simplex[n].F-simplex[0].F < opts.Tol {
+		res.Converged = true
+	}
+
+	res.X = append([]float64(nil), simplex[0].X...)
+	res.F = simplex[0].F
+	return res
+}
+
+func initialStep(x0, steps []float64, i int) float64 {
+	if i < len(steps) && steps[i] != 0 {
+		return steps[i]
+	}
+	if x0[i] == 0 {
+		return 0.00025
+	}
+	return 0.05 * x0[i]
+}
+
+// Tune searches the PID gains (kp, ki, kd) that minimize cost on scenario
+// subject to constraints with NelderMead, starting from start. Result.X holds
+// the three gains. A best cost of ViolationPenalty or more means no candidate
+// satisfied the constraints.
+func Tune(scenario Scenario, start [3]float64, cost CostFunc, constraints Constraints, opts NelderMeadOptions) Result {
+	f := func(x []float64) float64 {
+		return ConstrainedObjective([3]float64{x[0], x[1], x[2]}, scenario, cost, constraints)
+	}
+	return NelderMead(f, start[:], opts)
+}
@@ -0,0 +1,101 @@
+package tuning
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment/modifier"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestNelderMead_Rosenbrock(t *testing.T) {
+	rosenbrock := func(x []float64) float64 {
+		return 100*math.Pow(x[1]-x[0]*x[0], 2) + math.Pow(1-x[0], 2)
+	}
+	res := NelderMead(rosenbrock, []float64{-1.2, 1}, NelderMeadOptions{MaxIter: 1000, Tol: 1e-12})
+
+	if !res.Converged {
+		t.Errorf("did not converge in %d iterations", res.Iterations)
+	}
+	if math.Abs(res.X[0]-1) > 1e-3 || math.Abs(res.X[1]-1) > 1e-3 {
+		t.Errorf("X = %v, want ≈ [1 1]", res.X)
+	}
+}
+
+func TestNelderMead_TrajectoryAndLimits(t *testing.T) {
+	evals := 0
+	quadratic := func(x []float64) float64 {
+		evals++
+		return math.Pow(x[0]-3, 2) + 2*math.Pow(x[1]+1, 2) + math.Pow(x[2], 2)
+	}
+	res := NelderMead(quadratic, []float64{0, 0, 1}, NelderMeadOptions{MaxIter: 10})
+
+	if res.Iterations != 10 || res.Converged {
+		t.Errorf("Iterations = %d, Converged = %v; want the 10-iteration limit hit", res.Iterations, res.Converged)
+	}
+	if res.Evaluations != evals {
+		t.Errorf("Evaluations = %d, want %d", res.Evaluations, evals)
+	}
+	if len(res.Trajectory) != res.Iterations+1 {
+		t.Fatalf("len(Trajectory) = %d, want one point per iteration plus the start", len(res.Trajectory))
+	}
+	for i := 1; i < len(res.Trajectory); i++ {
+		if res.Trajectory[i].F > res.Trajectory[i-1].F {
+			t.Errorf("trajectory cost rose at iteration %d: %v -> %v", i, res.Trajectory[i-1].F, res.Trajectory[i].F)
+		}
+	}
+	if last := res.Trajectory[len(res.Trajectory)-1]; last.F != res.F {
+		t.Errorf("last trajectory cost = %v, want the result's %v", last.F, res.F)
+	}
+}
+
+func TestNelderMead_SkipsInfiniteRegion(t *testing.T) {
+	// Diverging candidates (x < 0 here) cost +Inf and must never be accepted
+	f := func(x []float64) float64 {
+		if x[0] < 0 {
+			return math.Inf(1)
+		}
+		return math.Pow(x[0]-0.1, 2)
+	}
+	res := NelderMead(f, []float64{2}, NelderMeadOptions{Tol: 1e-14})
+	if math.Abs(res.X[0]-0.1) > 1e-4 {
+		t.Errorf("X = %v, want ≈ 0.1", res.X)
+	}
+}
+
+// laggedMotor is the DC motor behind a 0.1 s moving-average actuator lag. The
+// lag makes high gains overshoot, so the cost has an interior optimum: from
+// starts across the basin, the search settles at about kp=0.137, ki=0.206,
+// kd=0.0038 with a cost of about 0.0485 (untuned defaults cost about 0.33).
+func laggedMotor() (system.System, *pid.Controller, experiment.StepConfig) {
+	return sim.NewDCMotor(), pid.New(0, 0, 0), experiment.StepConfig{
+		TargetRPM: 100, DT: 0.005, Duration: 4,
+		Modifier: &modifier.MovingAverageModifier{Window: 20}, MaxAbsU: 24,
+	}
+}
+
+func TestTune_ConvergesToKnownOptimum(t *testing.T) {
+	optimum := [3]float64{0.137, 0.206, 0.0038}
+	for _, start := range [][3]float64{{0.02, 0.05, 0}, {0.01, 0.01, 0}, {0.05, 0.2, 0.001}} {
+		res := Tune(laggedMotor, start, IAEOvershootCost, Constraints{}, NelderMeadOptions{MaxIter: 300})
+
+		if !res.Converged {
+			t.Errorf("start %v: no convergence within %d iterations", start, res.Iterations)
+		}
+		if res.F > 0.0495 {
+			t.Errorf("start %v: best cost %v, want ≤ 0.0495", start, res.F)
+		}
+		for i, g := range optimum {
+			if math.Abs(res.X[i]-g) > 0.05*g {
+				t.Errorf("start %v: gains %v, want within 5%% of %v", start, res.X, optimum)
+				break
+			}
+		}
+		if startCost := Objective(start, laggedMotor); res.F > startCost {
+			t.Errorf("start %v: best cost %v is worse than the start's %v", start, res.F, startCost)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package tuning scores controller gains on a scenario, for automated tuning
+// by search and optimization loops.
+package tuning
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// SettleBandFrac is the settling band used for the metrics a cost is computed from.
+const SettleBandFrac = 0.02
+
+// Scenario builds a fresh system, controller and config for one evaluation.
+// The objective overrides the controller's gains; everything else about it
+// (limits, anti-windup, ...) is kept. Every call must build the same
+// scenario, so that the objective is deterministic.
+type Scenario func() (system.System, *pid.Controller, experiment.StepConfig)
+
+// CostFunc maps a run's metrics to a scalar cost; lower is better.
+type CostFunc func(analysis.Metrics) float64
+
+// Costs are the built-in cost functions by name, e.g. for a command-line flag.
+var Costs = map[string]CostFunc{
+	"iae-overshoot": IAEOvershootCost,
+	"iae":           IAECost,
+}
+
+// OvershootWeight is the cost of one percent of overshoot in IAEOvershootCost,
+// in seconds of normalized IAE.
+const OvershootWeight = 0.01
+
+// IAEOvershootCost is the IAE normalized by the target, i.e. the time-equivalent
+// of the error area in seconds, plus OvershootWeight per percent of overshoot.
+// Normalizing keeps the cost comparable across setpoints. A zero target is not
+// normalized.
+func IAEOvershootCost(m analysis.Metrics) float64 {
+	return IAECost(m) + OvershootWeight*m.OvershootPercent
+}
+
+// IAECost is the IAE normalized by the target (see IAEOvershootCost).
+func IAECost(m analysis.Metrics) float64 {
+	if m.Target == 0 {
+		return m.IAE
+	}
+	return m.IAE / math.Abs(m.Target)
+}
+
+// Objective runs scenario with gains (kp, ki, kd) and returns its
+// IAEOvershootCost. See ObjectiveWith.
+func Objective(gains [3]float64, scenario Scenario) float64 {
+	return ObjectiveWith(gains, scenario, IAEOvershootCost)
+}
+
+// ObjectiveWith runs scenario with gains (kp, ki, kd) and returns cost of the
+// run's metrics. A run that fails (e.g., diverges) or a non-finite cost
+// returns +Inf, so optimizers treat it as the worst possible candidate.
+func ObjectiveWith(gains [3]float64, scenario Scenario, cost CostFunc) float64 {
+	sys, ctrl, cfg := scenario()
+	ctrl.Kp, ctrl.Ki, ctrl.Kd = gains[0], gains[1], gains[2]
+
+	samples, _, err := experiment.RunStep(sys, ctrl, cfg)
+	if err != nil || len(samples) == 0 {
+		return math.Inf(1)
+	}
+	c := cost(analysis.ComputeWithLimits(samples, SettleBandFrac, ctrl.OutMin, ctrl.OutMax))
+	if math.IsNaN(c) {
+		return math.Inf(1)
+	}
+	return c
+}
@@ -0,0 +1,81 @@
+package tuning
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/control/pid"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func dcMotorStep() (system.System, *pid.Controller, experiment.StepConfig) {
+	return sim.NewDCMotor(), pid.New(0, 0, 0), experiment.StepConfig{TargetRPM: 1000, DT: 0.001, Duration: 3}
+}
+
+func TestObjective_Deterministic(t *testing.T) {
+	gains := [3]float64{0.02, 0.05, 0}
+	first := Objective(gains, dcMotorStep)
+	for i := 0; i < 3; i++ {
+		if got := Objective(gains, dcMotorStep); got != first {
+			t.Fatalf("evaluation %d = %v, want %v", i+1, got, first)
+		}
+	}
+	if !(first > 0) || math.IsInf(first, 0) {
+		t.Errorf("Objective() = %v, want a finite positive cost", first)
+	}
+}
+
+func TestObjective_DecreasesForBetterGains(t *testing.T) {
+	// On the DC motor, stronger gains (up to the 24 V limit) track faster
+	sluggish := Objective([3]float64{0.002, 0.005, 0}, dcMotorStep)
+	defaults := Objective([3]float64{0.02, 0.05, 0}, dcMotorStep)
+	tight := Objective([3]float64{0.1, 0.5, 0}, dcMotorStep)
+
+	if !(sluggish > defaults && defaults > tight) {
+		t.Errorf("costs sluggish=%v defaults=%v tight=%v, want strictly decreasing", sluggish, defaults, tight)
+	}
+}
+
+func TestObjective_KeepsControllerSettings(t *testing.T) {
+	limited := func() (system.System, *pid.Controller, experiment.StepConfig) {
+		sys, ctrl, cfg := dcMotorStep()
+		ctrl.OutMin, ctrl.OutMax = -5, 5
+		return sys, ctrl, cfg
+	}
+	gains := [3]float64{0.1, 0.5, 0}
+	if a, b := Objective(gains, dcMotorStep), Objective(gains, limited); !(b > a) {
+		t.Errorf("cost with a 5 V limit = %v, want above the 24 V cost %v", b, a)
+	}
+}
+
+func TestObjective_FailedRunIsInf(t *testing.T) {
+	invalid := func() (system.System, *pid.Controller, experiment.StepConfig) {
+		sys, ctrl, cfg := dcMotorStep()
+		cfg.DT = 0
+		return sys, ctrl, cfg
+	}
+	if got := Objective([3]float64{0.02, 0.05, 0}, invalid); !math.IsInf(got, 1) {
+		t.Errorf("Objective() = %v, want +Inf for a failed run", got)
+	}
+
+	nan := func(analysis.Metrics) float64 { return math.NaN() }
+	if got := ObjectiveWith([3]float64{0.02, 0.05, 0}, dcMotorStep, nan); !math.IsInf(got, 1) {
+		t.Errorf("ObjectiveWith() = %v, want +Inf for a NaN cost", got)
+	}
+}
+
+func TestCosts(t *testing.T) {
+	m := analysis.Metrics{Target: -500, IAE: 100, OvershootPercent: 5}
+	if got := IAECost(m); got != 0.2 {
+		t.Errorf("IAECost() = %v, want 0.2", got)
+	}
+	if got := IAEOvershootCost(m); math.Abs(got-0.25) > 1e-12 {
+		t.Errorf("IAEOvershootCost() = %v, want 0.25", got)
+	}
+	if got := IAECost(analysis.Metrics{IAE: 3}); got != 3 {
+		t.Errorf("IAECost() with zero target = %v, want the raw IAE 3", got)
+	}
+}
@@ -0,0 +1,99 @@
+package plotting
+
+import (
+	"os"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+// WriteBodePlot renders a frequency response as a standard two-panel Bode plot
+// (magnitude in dB on top, phase in degrees below, shared log frequency axis)
+// and saves it as a PNG at outPath.
+//
+// Points with non-positive frequency cannot be placed on a log axis and are skipped.
+// An empty response writes nothing and returns nil.
+func WriteBodePlot(outPath string, fr []analysis.FrequencyPoint, theme PlotTheme) error {
+	var pts []analysis.FrequencyPoint
+	for _, p := range fr {
+		if p.FreqHz > 0 {
+			pts = append(pts, p)
+		}
+	}
+	if len(pts) == 0 {
+		return nil
+	}
+
+	mag := newPlot(theme)
+	mag.Title.Text = "Bode Plot"
+	mag.Y.Label.Text = "Magnitude (dB)"
+
+	phase := newPlot(theme)
+	phase.X.Label.Text = "Frequency (Hz)"
+	phase.Y.Label.Text = "Phase (deg)"
+
+	for _, p := range []*plot.Plot{mag, phase} {
+		p.X.Scale = plot.LogScale{}
+		p.X.Tick.Marker = plot.LogTicks{Prec: -1}
+		if !theme.Grid { // newPlot already added one
+			addGrid(p, theme)
+		}
+	}
+
+	magPoints := make(plotter.XYs, len(pts))
+	phasePoints := make(plotter.XYs, len(pts))
+	for i, p := range pts {
+		magPoints[i].X = p.FreqHz
+		magPoints[i].Y = p.MagnitudeDB
+		phasePoints[i].X = p.FreqHz
+		phasePoints[i].Y = p.PhaseDeg
+	}
+
+	magLine, err := plotter.NewLine(magPoints)
+	if err != nil {
+		return err
+	}
+	magLine.Color = theme.lineColor(0)
+	magLine.Width = vg.Points(1.5)
+	mag.Add(magLine)
+
+	phaseLine, err := plotter.NewLine(phasePoints)
+	if err != nil {
+		return err
+	}
+	phaseLine.Color = theme.lineColor(1)
+	phaseLine.Width = vg.Points(1.5)
+	phase.Add(phaseLine)
+
+	// Stack both panels on a single canvas
+	img := vgimg.New(8*vg.Inch, 6*vg.Inch)
+	dc := draw.New(img)
+	tiles := draw.Tiles{
+		Rows:      2,
+		Cols:      1,
+		PadY:      vg.Millimeter,
+		PadTop:    vg.Points(2),
+		PadBottom: vg.Points(2),
+		PadLeft:   vg.Points(2),
+		PadRight:  vg.Points(2),
+	}
+	plots := [][]*plot.Plot{{mag}, {phase}}
+	canvases := plot.Align(plots, tiles, dc)
+	mag.Draw(canvases[0][0])
+	phase.Draw(canvases[1][0])
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	if _, err := (vgimg.PngCanvas{Canvas: img}).WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,53 @@
+package plotting
+
+import (
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+)
+
+// firstOrderResponse returns the frequency response of 1/(tau*s + 1) at log-spaced frequencies.
+func firstOrderResponse(tau float64, n int) []analysis.FrequencyPoint {
+	fr := make([]analysis.FrequencyPoint, n)
+	for i := range fr {
+		f := math.Pow(10, -2+4*float64(i)/float64(n-1))
+		h := 1 / complex(1, 2*math.Pi*f*tau)
+		fr[i] = analysis.FrequencyPoint{
+			FreqHz:      f,
+			MagnitudeDB: 20 * math.Log10(cmplx.Abs(h)),
+			PhaseDeg:    cmplx.Phase(h) * 180 / math.Pi,
+		}
+	}
+	return fr
+}
+
+func TestWriteBodePlot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bode.png")
+
+	if err := WriteBodePlot(path, firstOrderResponse(0.5, 50), PlotTheme{}); err != nil {
+		t.Fatalf("WriteBodePlot() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("plot file was not created: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Error("plot file is empty")
+	}
+}
+
+func TestWriteBodePlot_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bode.png")
+
+	if err := WriteBodePlot(path, nil, PlotTheme{}); err != nil {
+		t.Fatalf("WriteBodePlot(nil) error = %v, want nil", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("no file should be written for an empty response")
+	}
+}
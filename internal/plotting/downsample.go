@@ -0,0 +1,87 @@
+package plotting
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// MaxPlotPoints caps the points per line of the time-series plots (response,
+// control and tracking): longer runs are downsampled with Downsample before
+// plotting, which keeps rendering fast and the images small. The default is
+// far above the pixel width of a plot. Zero or negative plots every sample.
+var MaxPlotPoints = 4000
+
+// Downsample reduces samples to at most maxPoints samples that preserve the
+// visual shape of the Actual-over-time curve, using largest-triangle-three-
+// buckets (LTTB): the first and last samples are kept, and from each of
+// maxPoints-2 equal buckets in between the sample spanning the largest
+// triangle with its neighbours, so peaks and edges survive where a stride
+// would skip them.
+//
+// Samples are returned as is when there are no more than maxPoints, or when
+// maxPoints is zero or negative; otherwise the result is a new slice of at
+// least the two endpoints.
+func Downsample(samples []experiment.Sample, maxPoints int) []experiment.Sample {
+	return downsample(samples, maxPoints, func(s experiment.Sample) float64 { return s.Actual })
+}
+
+// downsample is Downsample preserving the shape of y over time.
+func downsample(samples []experiment.Sample, maxPoints int, y func(experiment.Sample) float64) []experiment.Sample {
+	if maxPoints <= 0 || len(samples) <= maxPoints {
+		return samples
+	}
+	idx := lttb(len(samples), maxPoints,
+		func(i int) float64 { return samples[i].T },
+		func(i int) float64 { return y(samples[i]) })
+	out := make([]experiment.Sample, len(idx))
+	for k, i := range idx {
+		out[k] = samples[i]
+	}
+	return out
+}
+
+// lttb returns the indices, in increasing order, of the points of an n-point
+// curve (x(i), y(i)) with x nondecreasing that largest-triangle-three-buckets
+// keeps for maxPoints < n points: at least the two endpoints.
+func lttb(n, maxPoints int, x, y func(i int) float64) []int {
+	if maxPoints < 3 {
+		return []int{0, n - 1}
+	}
+
+	out := make([]int, 0, maxPoints)
+	out = append(out, 0)
+	// Buckets split the points 1..n-2; every > 1 since maxPoints < n
+	every := float64(n-2) / float64(maxPoints-2)
+	prev := 0
+	for b := 0; b < maxPoints-2; b++ {
+		start := int(float64(b)*every) + 1
+		end := int(float64(b+1)*every) + 1
+
+		// The third vertex is the mean of the next bucket (the last point
+		// after the last bucket)
+		nextEnd := min(int(float64(b+2)*every)+1, n)
+		if nextEnd <= end {
+			nextEnd = end + 1
+		}
+		var cx, cy float64
+		for i := end; i < nextEnd; i++ {
+			cx += x(i)
+			cy += y(i)
+		}
+		cx /= float64(nextEnd - end)
+		cy /= float64(nextEnd - end)
+
+		ax, ay := x(prev), y(prev)
+		best, bestArea := start, -1.0
+		for i := start; i < end; i++ {
+			area := math.Abs((ax-cx)*(y(i)-ay) - (ax-x(i))*(cy-ay))
+			if area > bestArea {
+				best, bestArea = i, area
+			}
+		}
+		out = append(out, best)
+		prev = best
+	}
+	return append(out, n-1)
+}
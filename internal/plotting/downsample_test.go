@@ -0,0 +1,91 @@
+package plotting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// spikeFixture returns n samples of a flat response with a single-sample
+// spike at index spike.
+func spikeFixture(n, spike int) []experiment.Sample {
+	samples := make([]experiment.Sample, n)
+	for i := range samples {
+		samples[i] = experiment.Sample{T: float64(i) * 0.001, DT: 0.001, Target: 1000, Actual: 1000}
+	}
+	samples[spike].Actual = 1500
+	return samples
+}
+
+func TestDownsample(t *testing.T) {
+	samples := spikeFixture(100000, 31337)
+	tests := []struct {
+		name      string
+		maxPoints int
+		wantLen   int
+	}{
+		{"bounded", 500, 500},
+		{"three points", 3, 3},
+		{"two points", 2, 2},
+		{"one point keeps the endpoints", 1, 2},
+		{"no more than the limit", len(samples), len(samples)},
+		{"disabled", 0, len(samples)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Downsample(samples, tt.maxPoints)
+			if len(got) != tt.wantLen {
+				t.Fatalf("len = %d, want %d", len(got), tt.wantLen)
+			}
+			if got[0].T != samples[0].T || got[len(got)-1].T != samples[len(samples)-1].T {
+				t.Errorf("endpoints T = %v, %v, want %v, %v", got[0].T, got[len(got)-1].T, samples[0].T, samples[len(samples)-1].T)
+			}
+			for i := 1; i < len(got); i++ {
+				if got[i].T <= got[i-1].T {
+					t.Fatalf("T not increasing at %d: %v after %v", i, got[i].T, got[i-1].T)
+				}
+			}
+		})
+	}
+}
+
+func TestDownsample_KeepsPeaks(t *testing.T) {
+	// A stride of 200 would step over a single-sample spike
+	got := Downsample(spikeFixture(100000, 31337), 500)
+	peak := 0.0
+	for _, s := range got {
+		peak = max(peak, s.Actual)
+	}
+	if peak != 1500 {
+		t.Errorf("peak = %v, want the spike 1500 kept", peak)
+	}
+}
+
+func TestDownsample_ShortInputUnchanged(t *testing.T) {
+	samples := spikeFixture(10, 5)
+	got := Downsample(samples, 100)
+	if len(got) != len(samples) || &got[0] != &samples[0] {
+		t.Error("input within the limit should be returned as is")
+	}
+	if got := Downsample(nil, 100); got != nil {
+		t.Errorf("Downsample(nil) = %v, want nil", got)
+	}
+}
+
+func TestWritePlots_LongRun(t *testing.T) {
+	samples := spikeFixture(200000, 1000)
+	dir := t.TempDir()
+	if err := WriteVelocityPlot(dir, samples, PlotTheme{}); err != nil {
+		t.Fatalf("WriteVelocityPlot() error = %v", err)
+	}
+	if err := WriteControlPlotWith(dir, samples, ControlPlotOptions{OutMin: -24, OutMax: 24, ShowRaw: true}); err != nil {
+		t.Fatalf("WriteControlPlotWith() error = %v", err)
+	}
+	for _, name := range []string{"velocity.png", "control.png"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() == 0 {
+			t.Errorf("%s was not written: %v", name, err)
+		}
+	}
+}
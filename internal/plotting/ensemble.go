@@ -0,0 +1,113 @@
+package plotting
+
+import (
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// WriteEnsemblePlot overlays the velocity responses of several runs (e.g.,
+// Monte Carlo trials) and saves the plot as a PNG at outPath: each run as a
+// faint line, plus a bold mean and a shaded ±1σ band.
+//
+// Runs are aligned by sample index, so they must share dt; runs of different
+// lengths are truncated to the shortest. Empty input writes nothing and returns nil.
+func WriteEnsemblePlot(outPath string, runs [][]experiment.Sample, theme PlotTheme) error {
+	n := -1
+	for _, r := range runs {
+		if n < 0 || len(r) < n {
+			n = len(r)
+		}
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	p := newPlot(theme)
+	p.Title.Text = "Velocity Ensemble"
+	p.X.Label.Text = "Time (s)"
+	p.Y.Label.Text = "Velocity (RPM)"
+	p.Legend.Top = true
+
+	mean, std := ensembleStats(runs, n)
+	t := runs[0]
+
+	// ±1σ band (degenerate for a single run, so skipped)
+	if len(runs) > 1 {
+		band := make(plotter.XYs, 0, 2*n)
+		for i := 0; i < n; i++ {
+			band = append(band, plotter.XY{X: t[i].T, Y: mean[i] + std[i]})
+		}
+		for i := n - 1; i >= 0; i-- {
+			band = append(band, plotter.XY{X: t[i].T, Y: mean[i] - std[i]})
+		}
+		poly, err := plotter.NewPolygon(band)
+		if err != nil {
+			return err
+		}
+		poly.Color = color.NRGBA{R: 31, G: 119, B: 180, A: 60}
+		poly.LineStyle.Width = 0
+		p.Add(poly)
+		p.Legend.Add("±1σ", poly)
+	}
+
+	// Individual runs
+	for _, r := range runs {
+		pts := make(plotter.XYs, n)
+		for i := 0; i < n; i++ {
+			pts[i].X = r[i].T
+			pts[i].Y = r[i].Actual
+		}
+		line, err := plotter.NewLine(pts)
+		if err != nil {
+			return err
+		}
+		line.Color = color.NRGBA{R: 120, G: 120, B: 120, A: 70}
+		line.Width = vg.Points(0.5)
+		p.Add(line)
+	}
+
+	// Mean
+	meanPts := make(plotter.XYs, n)
+	for i := 0; i < n; i++ {
+		meanPts[i].X = t[i].T
+		meanPts[i].Y = mean[i]
+	}
+	meanLine, err := plotter.NewLine(meanPts)
+	if err != nil {
+		return err
+	}
+	meanLine.Color = theme.lineColor(0)
+	meanLine.Width = vg.Points(2)
+	p.Add(meanLine)
+	p.Legend.Add("Mean", meanLine)
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outPath)
+}
+
+// ensembleStats returns the per-index mean and population standard deviation
+// of Actual over the first n samples of each run.
+func ensembleStats(runs [][]experiment.Sample, n int) (mean, std []float64) {
+	mean = make([]float64, n)
+	std = make([]float64, n)
+	k := float64(len(runs))
+	for i := 0; i < n; i++ {
+		var sum float64
+		for _, r := range runs {
+			sum += r[i].Actual
+		}
+		m := sum / k
+		var ss float64
+		for _, r := range runs {
+			d := r[i].Actual - m
+			ss += d * d
+		}
+		mean[i] = m
+		std[i] = math.Sqrt(ss / k)
+	}
+	return mean, std
+}
@@ -0,0 +1,67 @@
+package plotting
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// syntheticRun returns a first-order response scaled by gain, sampled every 10ms.
+func syntheticRun(gain float64, n int) []experiment.Sample {
+	samples := make([]experiment.Sample, n)
+	for i := range samples {
+		tt := float64(i) * 0.01
+		samples[i] = experiment.Sample{T: tt, DT: 0.01, Target: 1000, Actual: gain * 1000 * (1 - math.Exp(-tt/0.5))}
+	}
+	return samples
+}
+
+func TestWriteEnsemblePlot(t *testing.T) {
+	tests := []struct {
+		name string
+		runs [][]experiment.Sample
+	}{
+		{"several runs", [][]experiment.Sample{syntheticRun(0.95, 300), syntheticRun(1.0, 300), syntheticRun(1.05, 280)}},
+		{"single run", [][]experiment.Sample{syntheticRun(1.0, 300)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ensemble.png")
+			if err := WriteEnsemblePlot(path, tt.runs, PlotTheme{}); err != nil {
+				t.Fatalf("WriteEnsemblePlot() error = %v", err)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("plot file was not created: %v", err)
+			}
+			if info.Size() == 0 {
+				t.Error("plot file is empty")
+			}
+		})
+	}
+}
+
+func TestWriteEnsemblePlot_Empty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ensemble.png")
+	if err := WriteEnsemblePlot(path, nil, PlotTheme{}); err != nil {
+		t.Fatalf("WriteEnsemblePlot() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("no file should be written for an empty ensemble")
+	}
+}
+
+func TestEnsembleStats(t *testing.T) {
+	runs := [][]experiment.Sample{
+		{{Actual: 1}, {Actual: 10}},
+		{{Actual: 3}, {Actual: 10}},
+	}
+	mean, std := ensembleStats(runs, 2)
+	if mean[0] != 2 || std[0] != 1 || mean[1] != 10 || std[1] != 0 {
+		t.Errorf("mean=%v std=%v, want [2 10] and [1 0]", mean, std)
+	}
+}
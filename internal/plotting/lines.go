@@ -0,0 +1,74 @@
+package plotting
+
+import (
+	"fmt"
+	"sort"
+
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// Line is one labeled series of WriteLinesPlot, with X and Y of equal length.
+type Line struct {
+	Label string
+	X, Y  []float64
+}
+
+// WriteLinesPlot plots lines against a shared x axis and saves the plot as a
+// PNG at outPath, e.g. arbitrary samples.csv columns for ad-hoc analysis. Lines
+// take the theme's colors in order. A line whose X is nondecreasing (such as
+// time) and longer than MaxPlotPoints is downsampled as by Downsample; other
+// lines are plotted point by point.
+//
+// Values must be finite. Lines without points are skipped; if none has any,
+// nothing is written and nil is returned.
+func WriteLinesPlot(outPath, title, xLabel, yLabel string, lines []Line, theme PlotTheme) error {
+	p := newPlot(theme)
+	p.Title.Text = title
+	p.X.Label.Text = xLabel
+	p.Y.Label.Text = yLabel
+	p.Legend.Top = true
+
+	drawn := 0
+	for i, l := range lines {
+		if len(l.X) != len(l.Y) {
+			return fmt.Errorf("line %q: %d x values, %d y values", l.Label, len(l.X), len(l.Y))
+		}
+		if len(l.X) == 0 {
+			continue
+		}
+		line, err := plotter.NewLine(linePoints(l))
+		if err != nil {
+			return fmt.Errorf("line %q: %w", l.Label, err)
+		}
+		line.Color = theme.lineColor(i)
+		line.Width = vg.Points(1.5)
+		p.Add(line)
+		p.Legend.Add(l.Label, line)
+		drawn++
+	}
+	if drawn == 0 {
+		return nil
+	}
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, outPath)
+}
+
+// linePoints returns the points of l, downsampled to MaxPlotPoints when X is
+// nondecreasing.
+func linePoints(l Line) plotter.XYs {
+	n := len(l.X)
+	idx := make([]int, 0, n)
+	if MaxPlotPoints > 0 && n > MaxPlotPoints && sort.Float64sAreSorted(l.X) {
+		idx = lttb(n, MaxPlotPoints, func(i int) float64 { return l.X[i] }, func(i int) float64 { return l.Y[i] })
+	} else {
+		for i := range l.X {
+			idx = append(idx, i)
+		}
+	}
+	pts := make(plotter.XYs, len(idx))
+	for k, i := range idx {
+		pts[k] = plotter.XY{X: l.X[i], Y: l.Y[i]}
+	}
+	return pts
+}
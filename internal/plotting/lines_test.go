@@ -0,0 +1,54 @@
+package plotting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteLinesPlot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lines.png")
+	lines := []Line{
+		{Label: "rising", X: []float64{0, 1, 2}, Y: []float64{0, 1, 4}},
+		{Label: "empty"},
+		{Label: "unordered x", X: []float64{2, 0, 1}, Y: []float64{1, 2, 3}},
+	}
+	if err := WriteLinesPlot(path, "Lines", "x", "y", lines, PlotTheme{}); err != nil {
+		t.Fatalf("WriteLinesPlot() error = %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Fatalf("plot was not written: %v", err)
+	}
+}
+
+func TestWriteLinesPlot_NothingToPlot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lines.png")
+	if err := WriteLinesPlot(path, "Lines", "x", "y", []Line{{Label: "empty"}}, PlotTheme{}); err != nil {
+		t.Fatalf("WriteLinesPlot() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("no file should be written without points")
+	}
+}
+
+func TestWriteLinesPlot_LengthMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lines.png")
+	if err := WriteLinesPlot(path, "Lines", "x", "y", []Line{{Label: "bad", X: []float64{0, 1}, Y: []float64{0}}}, PlotTheme{}); err == nil {
+		t.Error("WriteLinesPlot() with mismatched lengths should fail")
+	}
+}
+
+func TestLinePoints_Downsampled(t *testing.T) {
+	n := 3 * MaxPlotPoints
+	x, y := make([]float64, n), make([]float64, n)
+	for i := range x {
+		x[i] = float64(i)
+	}
+	if got := linePoints(Line{X: x, Y: y}); len(got) != MaxPlotPoints || got[0].X != 0 || got[len(got)-1].X != x[n-1] {
+		t.Errorf("sorted x: %d points from %v to %v, want %d with the endpoints", len(got), got[0].X, got[len(got)-1].X, MaxPlotPoints)
+	}
+	x[0], x[1] = x[1], x[0]
+	if got := linePoints(Line{X: x, Y: y}); len(got) != n {
+		t.Errorf("unsorted x: %d points, want all %d", len(got), n)
+	}
+}
@@ -0,0 +1,69 @@
+package plotting
+
+import (
+	"path/filepath"
+
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// WritePhasePortrait writes phase_portrait.png: the error's time derivative
+// against the error, one point per sample, joined in time order. An
+// oscillatory response spirals into the origin; a limit cycle traces a
+// closed loop. The start of the run is marked.
+//
+// The derivative needs two samples, so fewer writes nothing and returns nil.
+func WritePhasePortrait(runDir string, samples []experiment.Sample, theme PlotTheme) error {
+	if len(samples) < 2 {
+		return nil
+	}
+
+	p := newPlot(theme)
+	p.Title.Text = "Phase Portrait"
+	p.X.Label.Text = "Error"
+	p.Y.Label.Text = "Error rate (1/s)"
+	p.Legend.Top = true
+
+	rate := errorRate(samples)
+	points := make(plotter.XYs, len(samples))
+	for i, s := range samples {
+		points[i] = plotter.XY{X: s.Error, Y: rate[i]}
+	}
+	line, err := plotter.NewLine(points)
+	if err != nil {
+		return err
+	}
+	line.Color = theme.lineColor(0)
+	line.Width = vg.Points(1.2)
+	p.Add(line)
+
+	start, err := plotter.NewScatter(points[:1])
+	if err != nil {
+		return err
+	}
+	start.GlyphStyle.Color = theme.lineColor(1)
+	start.GlyphStyle.Radius = vg.Points(4)
+	start.GlyphStyle.Shape = draw.CircleGlyph{}
+	p.Add(start)
+	p.Legend.Add("Start", start)
+
+	return p.Save(6*vg.Inch, 6*vg.Inch, filepath.Join(runDir, "phase_portrait.png"))
+}
+
+// errorRate returns dError/dt at each sample: central differences inside the
+// run, one-sided differences at its ends. Samples with equal times (a
+// degenerate step) get a rate of zero.
+func errorRate(samples []experiment.Sample) []float64 {
+	n := len(samples)
+	rate := make([]float64, n)
+	for i := range samples {
+		lo, hi := max(i-1, 0), min(i+1, n-1)
+		if dt := samples[hi].T - samples[lo].T; dt > 0 {
+			rate[i] = (samples[hi].Error - samples[lo].Error) / dt
+		}
+	}
+	return rate
+}
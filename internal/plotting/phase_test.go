@@ -0,0 +1,68 @@
+package plotting
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// dampedOscillation returns an error ringing down around zero, which spirals
+// into the origin of the phase plane.
+func dampedOscillation(n int) []experiment.Sample {
+	samples := make([]experiment.Sample, n)
+	for i := range samples {
+		tt := float64(i) * 0.01
+		e := 100 * math.Exp(-0.5*tt) * math.Cos(2*math.Pi*tt)
+		samples[i] = experiment.Sample{T: tt, DT: 0.01, Target: 1000, Actual: 1000 - e, Error: e}
+	}
+	return samples
+}
+
+func TestWritePhasePortrait(t *testing.T) {
+	dir := t.TempDir()
+	if err := WritePhasePortrait(dir, dampedOscillation(800), PlotTheme{}); err != nil {
+		t.Fatalf("WritePhasePortrait() error = %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "phase_portrait.png"))
+	if err != nil {
+		t.Fatalf("phase_portrait.png was not created: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Error("phase_portrait.png is empty")
+	}
+}
+
+func TestWritePhasePortrait_TooFewSamples(t *testing.T) {
+	for _, samples := range [][]experiment.Sample{nil, dampedOscillation(1)} {
+		dir := t.TempDir()
+		if err := WritePhasePortrait(dir, samples, PlotTheme{}); err != nil {
+			t.Fatalf("WritePhasePortrait(%d samples) error = %v", len(samples), err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "phase_portrait.png")); !os.IsNotExist(err) {
+			t.Errorf("%d samples: no file should be written", len(samples))
+		}
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+	// e = 3t² has rate 6t; central differences are exact for a parabola
+	samples := make([]experiment.Sample, 5)
+	for i := range samples {
+		tt := float64(i) * 0.5
+		samples[i] = experiment.Sample{T: tt, Error: 3 * tt * tt}
+	}
+	rate := errorRate(samples)
+	want := []float64{1.5, 3, 6, 9, 10.5} // ends: one-sided slopes
+	for i := range want {
+		if math.Abs(rate[i]-want[i]) > 1e-12 {
+			t.Errorf("rate[%d] = %v, want %v", i, rate[i], want[i])
+		}
+	}
+
+	if r := errorRate([]experiment.Sample{{T: 1, Error: 1}, {T: 1, Error: 2}}); r[0] != 0 || r[1] != 0 {
+		t.Errorf("equal times: rate = %v, want zeros", r)
+	}
+}
@@ -1,28 +1,42 @@
 package plotting
 
 import (
+	"image/color"
 	"path/filepath"
 
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 
 	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
 )
 
-func WriteVelocityPlot(runDir string, samples []experiment.Sample) error {
+func WriteVelocityPlot(runDir string, samples []experiment.Sample, theme PlotTheme) error {
+	return writeResponsePlot(filepath.Join(runDir, "velocity.png"), "Velocity Response", "Velocity (RPM)", samples, theme)
+}
+
+// WritePositionPlot writes position.png, the response of a run whose
+// observation is the shaft position in revolutions.
+func WritePositionPlot(runDir string, samples []experiment.Sample, theme PlotTheme) error {
+	return writeResponsePlot(filepath.Join(runDir, "position.png"), "Position Response", "Position (rev)", samples, theme)
+}
+
+// writeResponsePlot plots the actual and target observation over time,
+// downsampled to MaxPlotPoints.
+func writeResponsePlot(path, title, yLabel string, samples []experiment.Sample, theme PlotTheme) error {
 	if len(samples) == 0 {
 		return nil
 	}
 
-	p := plot.New()
-	p.Title.Text = "Velocity Response"
+	samples = Downsample(samples, MaxPlotPoints)
+
+	p := newPlot(theme)
+	p.Title.Text = title
 	p.X.Label.Text = "Time (s)"
-	p.Y.Label.Text = "Velocity (RPM)"
+	p.Y.Label.Text = yLabel
 	p.Legend.Top = true
 
-	// Create plotter for actual velocity
+	// Create plotter for the actual response
 	actualPoints := make(plotter.XYs, len(samples))
 	for i, s := range samples {
 		actualPoints[i].X = s.T
@@ -32,12 +46,12 @@ func WriteVelocityPlot(runDir string, samples []experiment.Sample) error {
 	if err != nil {
 		return err
 	}
-	actualLine.Color = plotutil.Color(0)
+	actualLine.Color = theme.lineColor(0)
 	actualLine.Width = vg.Points(1.5)
 	p.Add(actualLine)
 	p.Legend.Add("Actual", actualLine)
 
-	// Create plotter for target velocity
+	// Create plotter for the target
 	targetPoints := make(plotter.XYs, len(samples))
 	for i, s := range samples {
 		targetPoints[i].X = s.T
@@ -47,45 +61,81 @@ func WriteVelocityPlot(runDir string, samples []experiment.Sample) error {
 	if err != nil {
 		return err
 	}
-	targetLine.Color = plotutil.Color(1)
+	targetLine.Color = theme.lineColor(1)
 	targetLine.Width = vg.Points(1.5)
 	targetLine.Dashes = []vg.Length{vg.Points(5), vg.Points(5)}
 	p.Add(targetLine)
 	p.Legend.Add("Target", targetLine)
 
 	// Save the plot
-	if err := p.Save(8*vg.Inch, 4*vg.Inch, filepath.Join(runDir, "velocity.png")); err != nil {
+	if err := p.Save(8*vg.Inch, 4*vg.Inch, path); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// WriteControlPlot writes control.png with the light theme and no limits (see
+// WriteControlPlotWith).
 func WriteControlPlot(runDir string, samples []experiment.Sample) error {
+	return WriteControlPlotWith(runDir, samples, ControlPlotOptions{})
+}
+
+// WriteControlPlotWithLimits is like WriteControlPlot but also draws the
+// controller output limits (typically Metrics.OutMin and Metrics.OutMax) as
+// dashed lines and shades the intervals where the controller was saturated.
+// Limits with outMax <= outMin are ignored.
+func WriteControlPlotWithLimits(runDir string, samples []experiment.Sample, outMin, outMax float64) error {
+	return WriteControlPlotWith(runDir, samples, ControlPlotOptions{OutMin: outMin, OutMax: outMax})
+}
+
+// ControlPlotOptions configures WriteControlPlotWith. The zero value plots the
+// applied control signal only.
+type ControlPlotOptions struct {
+	// OutMin and OutMax are the output limits, drawn as in
+	// WriteControlPlotWithLimits. Limits with OutMax <= OutMin are ignored.
+	OutMin, OutMax float64
+	// ShowRaw overlays the controller's unclamped output (the out_raw
+	// column) as a dashed line, so clamping shows as the gap to u.
+	ShowRaw bool
+	// Theme styles the plot; the zero value is the light theme.
+	Theme PlotTheme
+}
+
+// WriteControlPlotWith writes control.png with the given options. Runs longer
+// than MaxPlotPoints are downsampled, keeping the shape of u.
+func WriteControlPlotWith(runDir string, samples []experiment.Sample, opts ControlPlotOptions) error {
 	if len(samples) == 0 {
 		return nil
 	}
 
-	p := plot.New()
+	p := newPlot(opts.Theme)
 	p.Title.Text = "Control Signal"
 	p.X.Label.Text = "Time (s)"
 	p.Y.Label.Text = "Voltage (V)"
 	p.Legend.Top = true
 
-	// Create plotter for control signal
-	controlPoints := make(plotter.XYs, len(samples))
-	for i, s := range samples {
-		controlPoints[i].X = s.T
-		controlPoints[i].Y = s.U
+	if opts.OutMax > opts.OutMin {
+		if err := addSaturationLimits(p, samples, opts.OutMin, opts.OutMax, opts.Theme); err != nil {
+			return err
+		}
 	}
-	controlLine, err := plotter.NewLine(controlPoints)
-	if err != nil {
-		return err
+
+	// Saturated spans come from every sample; the lines from a downsampled u
+	lineSamples := downsample(samples, MaxPlotPoints, func(s experiment.Sample) float64 { return s.U })
+	for _, sr := range controlSeries(lineSamples, opts) {
+		line, err := plotter.NewLine(sr.points)
+		if err != nil {
+			return err
+		}
+		line.Color = opts.Theme.lineColor(sr.color)
+		line.Width = vg.Points(1.5)
+		if sr.dashed {
+			line.Dashes = []vg.Length{vg.Points(5), vg.Points(5)}
+		}
+		p.Add(line)
+		p.Legend.Add(sr.label, line)
 	}
-	controlLine.Color = plotutil.Color(2)
-	controlLine.Width = vg.Points(1.5)
-	p.Add(controlLine)
-	p.Legend.Add("Control (U)", controlLine)
 
 	// Save the plot
 	if err := p.Save(8*vg.Inch, 4*vg.Inch, filepath.Join(runDir, "control.png")); err != nil {
@@ -94,3 +144,80 @@ func WriteControlPlot(runDir string, samples []experiment.Sample) error {
 
 	return nil
 }
+
+// series is one labeled line of a plot.
+type series struct {
+	label  string
+	points plotter.XYs
+	color  int // index into the theme's line colors
+	dashed bool
+}
+
+// controlSeries returns the lines of the control plot. Legend labels carry
+// the samples.csv column names, so plot and data read the same.
+func controlSeries(samples []experiment.Sample, opts ControlPlotOptions) []series {
+	column := func(label string, color int, dashed bool, value func(experiment.Sample) float64) series {
+		pts := make(plotter.XYs, len(samples))
+		for i, s := range samples {
+			pts[i].X = s.T
+			pts[i].Y = value(s)
+		}
+		return series{label: label, points: pts, color: color, dashed: dashed}
+	}
+
+	out := []series{column("Control (u)", 2, false, func(s experiment.Sample) float64 { return s.U })}
+	if opts.ShowRaw {
+		// Drawn over u: where they coincide only the dashes show
+		out = append(out, column("Unclamped (out_raw)", 3, true, func(s experiment.Sample) float64 { return s.OutRaw }))
+	}
+	return out
+}
+
+// addSaturationLimits shades saturated intervals and draws dashed lines at the limits.
+func addSaturationLimits(p *plot.Plot, samples []experiment.Sample, outMin, outMax float64, theme PlotTheme) error {
+	for _, span := range saturatedSpans(samples) {
+		poly, err := plotter.NewPolygon(plotter.XYs{
+			{X: span[0], Y: outMin}, {X: span[1], Y: outMin},
+			{X: span[1], Y: outMax}, {X: span[0], Y: outMax},
+		})
+		if err != nil {
+			return err
+		}
+		poly.Color = color.NRGBA{R: 220, G: 60, B: 60, A: 40}
+		poly.LineStyle.Width = 0
+		p.Add(poly)
+	}
+
+	t0, t1 := samples[0].T, samples[len(samples)-1].T
+	for i, y := range []float64{outMax, outMin} {
+		line, err := plotter.NewLine(plotter.XYs{{X: t0, Y: y}, {X: t1, Y: y}})
+		if err != nil {
+			return err
+		}
+		line.Color = theme.mutedColor()
+		line.Dashes = []vg.Length{vg.Points(4), vg.Points(4)}
+		p.Add(line)
+		if i == 0 {
+			p.Legend.Add("Output limits", line)
+		}
+	}
+	return nil
+}
+
+// saturatedSpans returns the [start, end) time intervals of consecutive saturated
+// samples. Each sample covers [T, T+DT).
+func saturatedSpans(samples []experiment.Sample) [][2]float64 {
+	var spans [][2]float64
+	for i := 0; i < len(samples); i++ {
+		if !samples[i].Saturated {
+			continue
+		}
+		j := i
+		for j+1 < len(samples) && samples[j+1].Saturated {
+			j++
+		}
+		spans = append(spans, [2]float64{samples[i].T, samples[j].T + samples[j].DT})
+		i = j
+	}
+	return spans
+}
@@ -0,0 +1,136 @@
+package plotting
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/analysis"
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// saturationFixture returns samples that are saturated at indices [2,4] and [7].
+func saturationFixture() []experiment.Sample {
+	samples := make([]experiment.Sample, 10)
+	for i := range samples {
+		samples[i].T = float64(i) * 0.1
+		samples[i].DT = 0.1
+		samples[i].U = 5
+		samples[i].OutRaw = 5
+		switch i {
+		case 2, 3, 4, 7:
+			samples[i].Saturated = true
+			samples[i].U = 24
+			samples[i].OutRaw = 30
+		}
+	}
+	return samples
+}
+
+func TestSaturatedSpans(t *testing.T) {
+	spans := saturatedSpans(saturationFixture())
+	want := [][2]float64{{0.2, 0.5}, {0.7, 0.8}}
+	if len(spans) != len(want) {
+		t.Fatalf("spans = %v, want %v", spans, want)
+	}
+	for i := range want {
+		for j := 0; j < 2; j++ {
+			if d := spans[i][j] - want[i][j]; d > 1e-12 || d < -1e-12 {
+				t.Errorf("spans[%d] = %v, want %v", i, spans[i], want[i])
+			}
+		}
+	}
+}
+
+func TestWriteControlPlotWithLimits_FromMetrics(t *testing.T) {
+	dir := t.TempDir()
+	samples := saturationFixture()
+	m := analysis.ComputeWithLimits(samples, 0.02, -24, 24)
+
+	if err := WriteControlPlotWithLimits(dir, samples, m.OutMin, m.OutMax); err != nil {
+		t.Fatalf("WriteControlPlotWithLimits() error = %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "control.png"))
+	if err != nil {
+		t.Fatalf("control.png was not created: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Error("control.png is empty")
+	}
+}
+
+func TestControlSeries_ShowRaw(t *testing.T) {
+	samples := saturationFixture()
+
+	if got := controlSeries(samples, ControlPlotOptions{}); len(got) != 1 {
+		t.Fatalf("default: %d series, want u only", len(got))
+	}
+
+	got := controlSeries(samples, ControlPlotOptions{ShowRaw: true})
+	if len(got) != 2 {
+		t.Fatalf("ShowRaw: %d series, want u and out_raw", len(got))
+	}
+	u, raw := got[0], got[1]
+	// Legends name the samples.csv columns
+	if !strings.Contains(u.label, "(u)") || !strings.Contains(raw.label, "(out_raw)") {
+		t.Errorf("labels = %q, %q, want the u and out_raw column names", u.label, raw.label)
+	}
+	if u.dashed || !raw.dashed {
+		t.Errorf("dashed = %v, %v, want solid u and dashed out_raw", u.dashed, raw.dashed)
+	}
+	for i, s := range samples {
+		if u.points[i].Y != s.U || raw.points[i].Y != s.OutRaw {
+			t.Fatalf("point %d = (%v, %v), want (%v, %v)", i, u.points[i].Y, raw.points[i].Y, s.U, s.OutRaw)
+		}
+	}
+}
+
+func TestWriteControlPlotWith_ShowRawRendersBothLines(t *testing.T) {
+	samples := saturationFixture()
+	uColor, rawColor := PlotTheme{}.lineColor(2), PlotTheme{}.lineColor(3)
+
+	count := func(opts ControlPlotOptions) (nu, nraw int) {
+		dir := t.TempDir()
+		if err := WriteControlPlotWith(dir, samples, opts); err != nil {
+			t.Fatalf("WriteControlPlotWith() error = %v", err)
+		}
+		f, err := os.Open(filepath.Join(dir, "control.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		img, err := png.Decode(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return countColor(img, uColor), countColor(img, rawColor)
+	}
+
+	nu, nraw := count(ControlPlotOptions{OutMin: -24, OutMax: 24, ShowRaw: true})
+	if nu == 0 || nraw == 0 {
+		t.Errorf("pixels: u %d, out_raw %d; want both lines drawn", nu, nraw)
+	}
+	if _, nraw := count(ControlPlotOptions{OutMin: -24, OutMax: 24}); nraw != 0 {
+		t.Errorf("out_raw drawn (%d pixels) without ShowRaw", nraw)
+	}
+}
+
+// countColor counts the pixels of img that are exactly c.
+func countColor(img image.Image, c color.Color) int {
+	r0, g0, b0, a0 := c.RGBA()
+	n := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			if r == r0 && g == g0 && bl == b0 && a == a0 {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package plotting
+
+import (
+	"fmt"
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+)
+
+// PlotTheme controls the look shared by all plots: background, text and line
+// colors (light by default, or Dark), and optional grid lines. Every Write*Plot
+// function takes one; the zero value is the light theme without grid lines
+// (Bode plots always have a grid).
+type PlotTheme struct {
+	Dark bool
+	Grid bool
+}
+
+// ParseTheme validates a --plot-theme value ("light" or "dark") and returns the
+// matching theme without grid lines. An empty string selects the light theme.
+func ParseTheme(s string) (PlotTheme, error) {
+	switch s {
+	case "", "light":
+		return PlotTheme{}, nil
+	case "dark":
+		return PlotTheme{Dark: true}, nil
+	default:
+		return PlotTheme{}, fmt.Errorf("unknown plot theme %q (want \"light\" or \"dark\")", s)
+	}
+}
+
+// darkPalette is plotutil's default palette brightened for a dark background.
+var darkPalette = []color.Color{
+	color.RGBA{R: 102, G: 178, B: 255, A: 255},
+	color.RGBA{R: 255, G: 128, B: 128, A: 255},
+	color.RGBA{R: 128, G: 224, B: 128, A: 255},
+	color.RGBA{R: 200, G: 150, B: 255, A: 255},
+	color.RGBA{R: 255, G: 190, B: 100, A: 255},
+}
+
+func (t PlotTheme) background() color.Color {
+	if t.Dark {
+		return color.RGBA{R: 30, G: 30, B: 30, A: 255}
+	}
+	return color.White
+}
+
+func (t PlotTheme) foreground() color.Color {
+	if t.Dark {
+		return color.RGBA{R: 220, G: 220, B: 220, A: 255}
+	}
+	return color.Black
+}
+
+// lineColor returns the i-th data line color.
+func (t PlotTheme) lineColor(i int) color.Color {
+	if t.Dark {
+		return darkPalette[i%len(darkPalette)]
+	}
+	return plotutil.Color(i)
+}
+
+// mutedColor is used for reference lines such as output limits.
+func (t PlotTheme) mutedColor() color.Color {
+	if t.Dark {
+		return color.Gray{Y: 150}
+	}
+	return color.Gray{Y: 120}
+}
+
+// newPlot returns a plot styled with theme, with grid lines if enabled.
+func newPlot(theme PlotTheme) *plot.Plot {
+	p := plot.New()
+	fg := theme.foreground()
+	p.BackgroundColor = theme.background()
+	p.Title.TextStyle.Color = fg
+	p.Legend.TextStyle.Color = fg
+	for _, a := range []*plot.Axis{&p.X, &p.Y} {
+		a.Color = fg
+		a.Label.TextStyle.Color = fg
+		a.Tick.Color = fg
+		a.Tick.Label.Color = fg
+	}
+	if theme.Grid {
+		addGrid(p, theme)
+	}
+	return p
+}
+
+// addGrid adds grid lines in a color that suits theme.
+func addGrid(p *plot.Plot, theme PlotTheme) {
+	g := plotter.NewGrid()
+	if theme.Dark {
+		g.Vertical.Color = color.Gray{Y: 70}
+		g.Horizontal.Color = color.Gray{Y: 70}
+	}
+	p.Add(g)
+}
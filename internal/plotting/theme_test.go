@@ -0,0 +1,87 @@
+package plotting
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+func TestPlotTheme_AllPlotsRender(t *testing.T) {
+	for _, theme := range []PlotTheme{{Dark: true}, {Dark: true, Grid: true}, {Grid: true}} {
+		dir := t.TempDir()
+		samples := saturationFixture()
+
+		steps := map[string]func() error{
+			"velocity.png": func() error { return WriteVelocityPlot(dir, samples, theme) },
+			"position.png": func() error { return WritePositionPlot(dir, samples, theme) },
+			"control.png": func() error {
+				return WriteControlPlotWith(dir, samples, ControlPlotOptions{OutMin: -1, OutMax: 1, Theme: theme})
+			},
+			"tracking.png": func() error { return WriteTrackingPlot(dir, rampRun(100), theme) },
+			"ensemble.png": func() error {
+				return WriteEnsemblePlot(filepath.Join(dir, "ensemble.png"), [][]experiment.Sample{syntheticRun(0.9, 100), syntheticRun(1.1, 100)}, theme)
+			},
+			"bode.png": func() error { return WriteBodePlot(filepath.Join(dir, "bode.png"), firstOrderResponse(0.5, 20), theme) },
+		}
+		for name, write := range steps {
+			if err := write(); err != nil {
+				t.Fatalf("%+v: writing %s: %v", theme, name, err)
+			}
+			if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Size() == 0 {
+				t.Errorf("%+v: %s was not produced (%v)", theme, name, err)
+			}
+		}
+	}
+}
+
+func TestPlotTheme_PerCall(t *testing.T) {
+	// A dark plot must not change the look of a later plot without a theme
+	corner := func(theme PlotTheme) (r, g, b uint32) {
+		dir := t.TempDir()
+		if err := WriteVelocityPlot(dir, saturationFixture(), theme); err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(filepath.Join(dir, "velocity.png"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = f.Close() }()
+		img, err := png.Decode(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, g, b, _ = img.At(0, 0).RGBA()
+		return r, g, b
+	}
+
+	dr, dg, db := corner(PlotTheme{Dark: true})
+	lr, lg, lb := corner(PlotTheme{})
+	if dr == lr && dg == lg && db == lb {
+		t.Fatalf("dark and light backgrounds are both %v,%v,%v", dr, dg, db)
+	}
+	if lr != 0xffff || lg != 0xffff || lb != 0xffff {
+		t.Errorf("background after a dark plot = %v,%v,%v, want white", lr, lg, lb)
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PlotTheme
+		wantErr bool
+	}{
+		{"", PlotTheme{}, false},
+		{"light", PlotTheme{}, false},
+		{"dark", PlotTheme{Dark: true}, false},
+		{"solarized", PlotTheme{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTheme(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTheme(%q) = %+v, %v; want %+v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
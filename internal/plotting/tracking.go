@@ -0,0 +1,138 @@
+package plotting
+
+import (
+	"image/color"
+	"path/filepath"
+
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// WriteTrackingPlot writes tracking.png: actual and target velocity over time
+// with the tracking gap between them filled, for references that move (ramps,
+// trajectories, chirps). Intervals where the response lags the target
+// (actual < target) and where it leads are shaded in different colors.
+//
+// A flat (step) reference renders too; the fill is then the step's error area.
+// Runs longer than MaxPlotPoints are downsampled.
+// Empty input writes nothing and returns nil.
+func WriteTrackingPlot(runDir string, samples []experiment.Sample, theme PlotTheme) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	samples = Downsample(samples, MaxPlotPoints)
+
+	p := newPlot(theme)
+	p.Title.Text = "Reference Tracking"
+	p.X.Label.Text = "Time (s)"
+	p.Y.Label.Text = "Velocity (RPM)"
+	p.Legend.Top = true
+
+	var legendLag, legendLead bool
+	for _, span := range gapSpans(samples) {
+		poly, err := plotter.NewPolygon(gapPolygon(samples[span.from : span.to+1]))
+		if err != nil {
+			return err
+		}
+		poly.LineStyle.Width = 0
+		if span.lagging {
+			poly.Color = color.NRGBA{R: 220, G: 60, B: 60, A: 60}
+			if !legendLag {
+				p.Legend.Add("Lagging", poly)
+				legendLag = true
+			}
+		} else {
+			poly.Color = color.NRGBA{R: 60, G: 160, B: 60, A: 60}
+			if !legendLead {
+				p.Legend.Add("Leading", poly)
+				legendLead = true
+			}
+		}
+		p.Add(poly)
+	}
+
+	actual := make(plotter.XYs, len(samples))
+	target := make(plotter.XYs, len(samples))
+	for i, s := range samples {
+		actual[i] = plotter.XY{X: s.T, Y: s.Actual}
+		target[i] = plotter.XY{X: s.T, Y: s.Target}
+	}
+
+	targetLine, err := plotter.NewLine(target)
+	if err != nil {
+		return err
+	}
+	targetLine.Color = theme.lineColor(1)
+	targetLine.Width = vg.Points(1.5)
+	targetLine.Dashes = []vg.Length{vg.Points(5), vg.Points(5)}
+	p.Add(targetLine)
+	p.Legend.Add("Target", targetLine)
+
+	actualLine, err := plotter.NewLine(actual)
+	if err != nil {
+		return err
+	}
+	actualLine.Color = theme.lineColor(0)
+	actualLine.Width = vg.Points(1.5)
+	p.Add(actualLine)
+	p.Legend.Add("Actual", actualLine)
+
+	return p.Save(8*vg.Inch, 4*vg.Inch, filepath.Join(runDir, "tracking.png"))
+}
+
+// gapSpan is a run of samples [from, to] where the tracking error keeps its sign.
+type gapSpan struct {
+	from, to int
+	lagging  bool // actual < target
+}
+
+// gapSpans splits samples where the tracking error changes sign. Adjacent spans
+// share their boundary sample so the fills meet; samples with zero error extend
+// the current span. Spans of a single sample (no area) are dropped.
+func gapSpans(samples []experiment.Sample) []gapSpan {
+	var spans []gapSpan
+	cur := gapSpan{from: -1}
+	for i, s := range samples {
+		gap := s.Target - s.Actual
+		if gap == 0 {
+			continue
+		}
+		lagging := gap > 0
+		switch {
+		case cur.from < 0:
+			cur = gapSpan{from: max(i-1, 0), to: i, lagging: lagging}
+		case lagging == cur.lagging:
+			cur.to = i
+		default:
+			cur.to = i
+			spans = append(spans, cur)
+			cur = gapSpan{from: i, to: i, lagging: lagging}
+		}
+	}
+	if cur.from >= 0 {
+		cur.to = len(samples) - 1
+		spans = append(spans, cur)
+	}
+
+	out := spans[:0]
+	for _, sp := range spans {
+		if sp.to > sp.from {
+			out = append(out, sp)
+		}
+	}
+	return out
+}
+
+// gapPolygon outlines the region between target (forward) and actual (backward).
+func gapPolygon(samples []experiment.Sample) plotter.XYs {
+	pts := make(plotter.XYs, 0, 2*len(samples))
+	for _, s := range samples {
+		pts = append(pts, plotter.XY{X: s.T, Y: s.Target})
+	}
+	for i := len(samples) - 1; i >= 0; i-- {
+		pts = append(pts, plotter.XY{X: samples[i].T, Y: samples[i].Actual})
+	}
+	return pts
+}
@@ -0,0 +1,87 @@
+package plotting
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/experiment"
+)
+
+// rampRun returns a 500 RPM/s ramp reference tracked with a constant 100 RPM lag.
+func rampRun(n int) []experiment.Sample {
+	samples := make([]experiment.Sample, n)
+	for i := range samples {
+		tt := float64(i) * 0.01
+		target := 500 * tt
+		samples[i] = experiment.Sample{T: tt, DT: 0.01, Target: target, Actual: target - 100, Error: 100}
+	}
+	samples[0].Actual = 0
+	samples[0].Error = 0
+	return samples
+}
+
+func TestWriteTrackingPlot(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []experiment.Sample
+	}{
+		{"ramp reference", rampRun(300)},
+		{"flat step reference", syntheticRun(1.05, 300)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := WriteTrackingPlot(dir, tt.samples, PlotTheme{}); err != nil {
+				t.Fatalf("WriteTrackingPlot() error = %v", err)
+			}
+			info, err := os.Stat(filepath.Join(dir, "tracking.png"))
+			if err != nil {
+				t.Fatalf("plot file was not created: %v", err)
+			}
+			if info.Size() == 0 {
+				t.Error("plot file is empty")
+			}
+		})
+	}
+}
+
+func TestWriteTrackingPlot_Empty(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteTrackingPlot(dir, nil, PlotTheme{}); err != nil {
+		t.Fatalf("WriteTrackingPlot() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tracking.png")); !os.IsNotExist(err) {
+		t.Error("no file should be written for empty samples")
+	}
+}
+
+func TestGapSpans(t *testing.T) {
+	mk := func(gaps ...float64) []experiment.Sample {
+		samples := make([]experiment.Sample, len(gaps))
+		for i, g := range gaps {
+			samples[i] = experiment.Sample{T: float64(i), Target: 10, Actual: 10 - g}
+		}
+		return samples
+	}
+
+	tests := []struct {
+		name string
+		gaps []float64
+		want []gapSpan
+	}{
+		{"no gap", []float64{0, 0, 0}, nil},
+		{"lagging throughout", []float64{5, 4, 3}, []gapSpan{{0, 2, true}}},
+		{"lag then lead", []float64{0, 5, 2, -1, -2}, []gapSpan{{0, 3, true}, {3, 4, false}}},
+		{"zero inside a span", []float64{2, 0, 1}, []gapSpan{{0, 2, true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gapSpans(mk(tt.gaps...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("gapSpans() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
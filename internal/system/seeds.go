@@ -0,0 +1,23 @@
+package system
+
+// SeedReporter is an optional capability for systems with stochastic
+// components (noise, random disturbances). It reports the effective seed of
+// each component, so that a run's randomness can be recorded and reproduced.
+// Wrappers merge the seeds of the system they wrap.
+type SeedReporter interface {
+	// Seeds returns the seeds keyed by a stable snake_case component name
+	// (e.g. "measurement_noise"). The map may be empty, and may be modified by
+	// the caller.
+	Seeds() map[string]int64
+}
+
+// ReportedSeeds returns the seeds reported by sys, or an empty map if sys does
+// not implement SeedReporter. The result is never nil.
+func ReportedSeeds(sys System) map[string]int64 {
+	if sr, ok := sys.(SeedReporter); ok {
+		if seeds := sr.Seeds(); seeds != nil {
+			return seeds
+		}
+	}
+	return map[string]int64{}
+}
@@ -5,8 +5,28 @@ package system
 // Keys must be stable snake_case identifiers suitable for CSV headers.
 type SignalReporter interface {
 	// Signals returns a map of signal names to their current values.
-	// The map should be non-nil and may be empty if no signals are available.
+	// The map may be nil or empty if no signals are available.
 	// Keys should be stable snake_case identifiers suitable for CSV column headers.
 	// The returned map may be modified by the caller without affecting the system.
 	Signals() map[string]float64
 }
+
+// SignalDeclarer is an optional extension of SignalReporter for systems that can
+// declare up front the full set of signal keys they will ever emit.
+// Writers use it to fix CSV columns deterministically, even before a signal
+// first appears or becomes non-zero (e.g., when streaming samples).
+type SignalDeclarer interface {
+	SignalReporter
+
+	// SignalKeys returns every key Signals() may report.
+	SignalKeys() []string
+}
+
+// DeclaredSignalKeys returns the signal keys declared by sys, or nil if sys
+// does not implement SignalDeclarer.
+func DeclaredSignalKeys(sys System) []string {
+	if d, ok := sys.(SignalDeclarer); ok {
+		return d.SignalKeys()
+	}
+	return nil
+}
@@ -22,6 +22,26 @@ import (
 // parameter sweeps. It is not a full electromechanical motor model.
 // (Roadmap items like deadzone, Coulomb friction, load torque, encoder
 // quantization can be added on top.)
+//
+// Thermal gain drift is optional: when ThermalTauSeconds > 0, the gain starts at
+// GainRPMPerVolt (cold) and moves exponentially towards GainHotRPMPerVolt with
+// that time constant, and the current gain is reported as the
+// "gain_rpm_per_volt" signal.
+//
+// The back-EMF limit is optional: with BackEMFLimit set, the speed is capped
+// at ±MaxSpeedRPM() = MaxVoltage*Gain(), where the motor's back-EMF equals the
+// full supply voltage. The voltage clamp in Actuate already keeps the
+// steady-state speed within that bound for any command; the cap additionally
+// holds against what the clamp does not see, such as a load that assists the
+// motor (negative d) or an initial speed beyond the limit, so the plant cannot
+// be driven past its electrical limit.
+//
+// Step integrates with explicit Euler, v += (dt/tau)*(K*V - v). That update is
+// only well-behaved for dt <= tau: for tau < dt < 2*tau the velocity overshoots
+// and oscillates around K*V, and for dt > 2*tau it diverges. Step therefore
+// clamps dt/tau to 1 (see eulerAlpha), so a tau much smaller than dt (or zero)
+// makes the motor reach K*V within one step instead of blowing up. Choose
+// dt well below tau for an accurate transient.
 type DCMotor struct {
 	VelocityRPM float64
 
@@ -29,8 +49,16 @@ type DCMotor struct {
 	TauSeconds     float64
 	MaxVoltage     float64
 
+	GainHotRPMPerVolt float64
+	ThermalTauSeconds float64
+
+	BackEMFLimit bool
+
 	appliedVoltage     float64
 	disturbanceRPMPerS float64
+
+	// Simulation time, tracked for the thermal drift
+	t float64
 }
 
 func NewDCMotor() *DCMotor {
@@ -67,17 +95,87 @@ func (m *DCMotor) Step(dt float64) {
 	}
 
 	// first-order approach to target speed
-	target := m.GainRPMPerVolt * m.appliedVoltage
-	alpha := dt / m.TauSeconds
+	target := m.Gain() * m.appliedVoltage
+	alpha := eulerAlpha(dt, m.TauSeconds)
 	// Apply disturbance: dv = alpha*(target - v) - d*dt
 	m.VelocityRPM += alpha*(target-m.VelocityRPM) - m.disturbanceRPMPerS*dt
+	m.t += dt
+	if m.BackEMFLimit {
+		m.VelocityRPM = clamp(m.VelocityRPM, -m.MaxSpeedRPM(), m.MaxSpeedRPM())
+	}
+}
+
+// MaxSpeedRPM returns the speed at which the back-EMF equals the supply,
+// MaxVoltage*Gain() (see BackEMFLimit).
+func (m *DCMotor) MaxSpeedRPM() float64 {
+	return m.MaxVoltage * m.Gain()
+}
+
+// InitSteadyState implements system.SteadyStateInitializer: it sets the velocity
+// to y and applies the voltage y/Gain() that holds it (ignoring disturbances).
+// The voltage is clamped to MaxVoltage, so unreachable speeds are not held;
+// with BackEMFLimit, the velocity itself is capped at MaxSpeedRPM().
+func (m *DCMotor) InitSteadyState(y float64) (float64, bool) {
+	m.VelocityRPM = y
+	if m.BackEMFLimit {
+		m.VelocityRPM = clamp(y, -m.MaxSpeedRPM(), m.MaxSpeedRPM())
+	}
+	m.Actuate(m.SteadyStateVoltage(y))
+	return m.appliedVoltage, true
+}
+
+// SteadyStateVoltage returns the voltage that holds the motor at rpm without
+// disturbance, rpm/Gain(), i.e. the inverse of the steady-state map. It is not
+// clamped to MaxVoltage. Used as a model, it gives a controller feedforward
+// (see pid.Controller.Feedforward).
+func (m *DCMotor) SteadyStateVoltage(rpm float64) float64 {
+	return rpm / m.Gain()
+}
+
+// Gain returns the current steady-state gain (RPM/V), including thermal drift.
+func (m *DCMotor) Gain() float64 {
+	if m.ThermalTauSeconds <= 0 {
+		return m.GainRPMPerVolt
+	}
+	cold, hot := m.GainRPMPerVolt, m.GainHotRPMPerVolt
+	return hot + (cold-hot)*math.Exp(-m.t/m.ThermalTauSeconds)
+}
+
+// Signals implements system.SignalReporter.
+// It reports the current gain only when thermal drift is enabled, and returns
+// nil otherwise so the plain motor allocates nothing per step.
+func (m *DCMotor) Signals() map[string]float64 {
+	if m.ThermalTauSeconds <= 0 {
+		return nil
+	}
+	return map[string]float64{"gain_rpm_per_volt": m.Gain()}
+}
+
+// SignalKeys implements system.SignalDeclarer.
+func (m *DCMotor) SignalKeys() []string {
+	if m.ThermalTauSeconds <= 0 {
+		return nil
+	}
+	return []string{"gain_rpm_per_volt"}
 }
 
 var (
-	_ system.DisturbanceReceiver = (*DCMotor)(nil)
-	_ system.DisturbanceReporter = (*DCMotor)(nil)
+	_ system.DisturbanceReceiver    = (*DCMotor)(nil)
+	_ system.DisturbanceReporter    = (*DCMotor)(nil)
+	_ system.SignalDeclarer         = (*DCMotor)(nil)
+	_ system.SteadyStateInitializer = (*DCMotor)(nil)
 )
 
+// eulerAlpha returns the explicit Euler factor dt/tau for a first-order lag,
+// clamped to 1 (the exact dt >> tau limit) so the update cannot overshoot.
+// A non-positive tau is treated as an instantaneous response.
+func eulerAlpha(dt, tau float64) float64 {
+	if tau <= 0 {
+		return 1
+	}
+	return math.Min(dt/tau, 1)
+}
+
 func clamp(x, lo, hi float64) float64 {
 	return math.Min(math.Max(x, lo), hi)
 }
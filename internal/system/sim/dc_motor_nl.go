@@ -0,0 +1,82 @@
+package sim
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// DCMotorNL is DCMotor with a smooth saturation of the effective gain,
+// modeling magnetic saturation:
+//
+//	dv/dt = (1/tau) * (K*Vs*tanh(V/Vs) - v) - d(t)
+//
+// where Vs is the saturation voltage. For |V| << Vs the drive term is ≈ K*V,
+// matching DCMotor; as |V| grows the steady-state speed approaches K*Vs
+// smoothly instead of growing linearly. A SaturationVoltage <= 0 disables the
+// saturation, so the motor behaves exactly like DCMotor.
+type DCMotorNL struct {
+	VelocityRPM float64
+
+	GainRPMPerVolt    float64 // linear (small-signal) gain
+	SaturationVoltage float64 // Vs; <= 0 means no saturation
+	TauSeconds        float64
+	MaxVoltage        float64
+
+	appliedVoltage     float64
+	disturbanceRPMPerS float64
+}
+
+// NewDCMotorNL returns a DCMotorNL with the same defaults as NewDCMotor and
+// the given saturation voltage.
+func NewDCMotorNL(saturationVoltage float64) *DCMotorNL {
+	return &DCMotorNL{
+		GainRPMPerVolt:    100.0,
+		SaturationVoltage: saturationVoltage,
+		TauSeconds:        0.5,
+		MaxVoltage:        24.0,
+	}
+}
+
+func (m *DCMotorNL) Observe() float64 {
+	return m.VelocityRPM
+}
+
+func (m *DCMotorNL) Actuate(u float64) {
+	m.appliedVoltage = clamp(u, -m.MaxVoltage, m.MaxVoltage)
+}
+
+// SetDisturbanceRPMPerS implements system.DisturbanceReceiver.
+func (m *DCMotorNL) SetDisturbanceRPMPerS(d float64) {
+	m.disturbanceRPMPerS = d
+}
+
+// CurrentDisturbanceRPMPerS implements system.DisturbanceReporter.
+func (m *DCMotorNL) CurrentDisturbanceRPMPerS() float64 {
+	return m.disturbanceRPMPerS
+}
+
+// SteadyStateRPM returns the speed the motor settles at for voltage v without disturbance.
+func (m *DCMotorNL) SteadyStateRPM(v float64) float64 {
+	v = clamp(v, -m.MaxVoltage, m.MaxVoltage)
+	vs := m.SaturationVoltage
+	if vs <= 0 {
+		return m.GainRPMPerVolt * v
+	}
+	return m.GainRPMPerVolt * vs * math.Tanh(v/vs)
+}
+
+func (m *DCMotorNL) Step(dt float64) {
+	if dt <= 0 {
+		return
+	}
+
+	target := m.SteadyStateRPM(m.appliedVoltage)
+	alpha := eulerAlpha(dt, m.TauSeconds) // see DCMotor for the stability guard
+	m.VelocityRPM += alpha*(target-m.VelocityRPM) - m.disturbanceRPMPerS*dt
+}
+
+var (
+	_ system.DisturbanceReceiver = (*DCMotorNL)(nil)
+	_ system.DisturbanceReporter = (*DCMotorNL)(nil)
+)
@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// settle drives m at voltage v long enough (20 tau) to reach steady state.
+func settle(m system.System, v float64) float64 {
+	m.Actuate(v)
+	for i := 0; i < 10000; i++ {
+		m.Step(0.001)
+	}
+	return m.Observe()
+}
+
+func TestDCMotorNL_MatchesLinearAtLowVoltage(t *testing.T) {
+	for _, v := range []float64{0.05, 0.2, -0.3} {
+		nl := settle(NewDCMotorNL(10), v)
+		lin := settle(NewDCMotor(), v)
+		// tanh(x) ≈ x - x^3/3, so the relative deviation is ≈ (v/Vs)^2/3 < 0.1%
+		if math.Abs(nl-lin) > 1e-3*math.Abs(lin) {
+			t.Errorf("V=%v: nonlinear %v, linear %v, want within 0.1%%", v, nl, lin)
+		}
+	}
+}
+
+func TestDCMotorNL_SaturatesSmoothly(t *testing.T) {
+	const vs = 6.0
+	voltages := []float64{1, 2, 4, 8, 12, 16, 20, 24}
+
+	prevSpeed, prevSlope := 0.0, math.Inf(1)
+	prevV := 0.0
+	for _, v := range voltages {
+		speed := settle(NewDCMotorNL(vs), v)
+
+		// Monotonic, with a decreasing incremental gain (no kink, no clamp)
+		slope := (speed - prevSpeed) / (v - prevV)
+		if slope <= 0 {
+			t.Errorf("V=%v: speed %v did not increase", v, speed)
+		}
+		if slope >= prevSlope {
+			t.Errorf("V=%v: incremental gain %v did not decrease (previous %v)", v, slope, prevSlope)
+		}
+
+		// Always below both the linear response and the asymptote K*Vs
+		if speed >= 100*v || speed >= 100*vs {
+			t.Errorf("V=%v: speed %v not below linear %v and asymptote %v", v, speed, 100*v, 100*vs)
+		}
+		prevSpeed, prevSlope, prevV = speed, slope, v
+	}
+
+	// Deep in saturation the speed approaches K*Vs
+	if got := settle(NewDCMotorNL(vs), 24); math.Abs(got-100*vs) > 0.01*100*vs {
+		t.Errorf("speed at 24V = %v, want within 1%% of %v", got, 100*vs)
+	}
+}
+
+func TestDCMotorNL_NonPositiveSaturationVoltageIsLinear(t *testing.T) {
+	for _, vs := range []float64{0, -5} {
+		for _, v := range []float64{3, -12, 24} {
+			nl := settle(NewDCMotorNL(vs), v)
+			lin := settle(NewDCMotor(), v)
+			if math.IsNaN(nl) || nl != lin {
+				t.Errorf("Vs=%v, V=%v: speed %v, want the linear %v", vs, v, nl, lin)
+			}
+		}
+	}
+}
@@ -120,3 +120,149 @@ func TestDCMotor_DisturbanceReceiverInterface(t *testing.T) {
 	// This will fail at compile time if DCMotor doesn't implement DisturbanceReceiver
 	var _ system.DisturbanceReceiver = m
 }
+
+func TestDCMotor_ThermalGainDrift(t *testing.T) {
+	m := NewDCMotor()
+	m.GainRPMPerVolt = 100.0
+	m.GainHotRPMPerVolt = 80.0
+	m.ThermalTauSeconds = 2.0
+
+	dt := 0.001
+	gainAt := func(seconds float64) float64 {
+		for m.t < seconds-dt/2 {
+			m.Step(dt)
+		}
+		return m.Signals()["gain_rpm_per_volt"]
+	}
+
+	tests := []struct {
+		t    float64
+		want float64
+	}{
+		{0, 100.0},
+		{2.0, 80.0 + 20.0*math.Exp(-1)}, // one thermal time constant
+		{6.0, 80.0 + 20.0*math.Exp(-3)},
+		{30.0, 80.0}, // fully hot
+	}
+	for _, tt := range tests {
+		if got := gainAt(tt.t); math.Abs(got-tt.want) > 1e-3 {
+			t.Errorf("gain at t=%v = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+	if got := m.Gain(); math.Abs(got-80.0) > 1e-3 {
+		t.Errorf("Gain() = %v, want 80", got)
+	}
+}
+
+func TestDCMotor_NoDriftByDefault(t *testing.T) {
+	m := NewDCMotor()
+	m.Actuate(10)
+	for i := 0; i < 1000; i++ {
+		m.Step(0.01)
+	}
+
+	if m.Gain() != m.GainRPMPerVolt {
+		t.Errorf("Gain() = %v, want constant %v", m.Gain(), m.GainRPMPerVolt)
+	}
+	if sigs := m.Signals(); sigs != nil {
+		t.Errorf("Signals() = %v, want nil without drift", sigs)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { m.Signals() }); allocs != 0 {
+		t.Errorf("Signals() allocates %v times per call without drift, want 0", allocs)
+	}
+	if keys := m.SignalKeys(); keys != nil {
+		t.Errorf("SignalKeys() = %v, want nil without drift", keys)
+	}
+}
+
+func TestDCMotor_SmallTauStaysBounded(t *testing.T) {
+	// dt/tau = 10: unguarded explicit Euler would multiply the error by -9 per
+	// step; the clamped update reaches K*V in one step and holds it
+	const (
+		dt     = 0.01
+		tau    = 0.001
+		target = 1000.0
+	)
+
+	for _, tauS := range []float64{tau, 0} {
+		m := NewDCMotor()
+		m.TauSeconds = tauS
+		m.Actuate(10) // K*V = 1000 RPM
+		for i := 0; i < 20; i++ {
+			m.Step(dt)
+			if math.Abs(m.VelocityRPM-target) > eps {
+				t.Fatalf("tau=%v step %d: VelocityRPM = %v, want %v", tauS, i, m.VelocityRPM, target)
+			}
+		}
+	}
+}
+
+func TestDCMotor_GuardKeepsStableRegime(t *testing.T) {
+	// dt == tau is the largest unclamped step: the motor reaches K*V in one step.
+	m := NewDCMotor()
+	m.TauSeconds = 0.01
+	m.Actuate(5)
+	m.Step(0.01)
+	if math.Abs(m.VelocityRPM-500) > eps {
+		t.Errorf("VelocityRPM = %v, want 500", m.VelocityRPM)
+	}
+}
+
+func TestDCMotor_BackEMFLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     float64
+		disturbance float64 // RPM/s; negative assists the motor
+		limit       bool
+		want        float64
+	}{
+		{"full voltage", 24, 0, true, 2400},
+		{"command far beyond the supply", 1e6, 0, true, 2400},
+		{"negative command", -1e6, 0, true, -2400},
+		{"assisting load, limited", 1e6, -500, true, 2400},
+		// Without the limit the assisting load pushes the speed past K*Vmax:
+		// steady state K*V - tau*d = 2400 + 250
+		{"assisting load, unlimited", 1e6, -500, false, 2650},
+		{"below the limit", 10, 0, true, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewDCMotor()
+			m.BackEMFLimit = tt.limit
+			m.SetDisturbanceRPMPerS(tt.disturbance)
+			m.Actuate(tt.command)
+			for i := 0; i < 2000; i++ {
+				m.Step(0.01)
+				if tt.limit && math.Abs(m.VelocityRPM) > m.MaxSpeedRPM()+eps {
+					t.Fatalf("step %d: VelocityRPM = %v, beyond the back-EMF limit %v", i, m.VelocityRPM, m.MaxSpeedRPM())
+				}
+			}
+			if math.Abs(m.VelocityRPM-tt.want) > 1e-3 {
+				t.Errorf("steady-state VelocityRPM = %v, want %v", m.VelocityRPM, tt.want)
+			}
+		})
+	}
+}
+
+func TestDCMotor_BackEMFLimitFollowsGain(t *testing.T) {
+	m := NewDCMotor()
+	m.BackEMFLimit = true
+	m.GainHotRPMPerVolt = 80
+	m.ThermalTauSeconds = 1
+	m.SetDisturbanceRPMPerS(-1000)
+	m.Actuate(24)
+	for i := 0; i < 2000; i++ {
+		m.Step(0.01)
+	}
+	// The hot motor tops out at 24 V * 80 RPM/V
+	if math.Abs(m.VelocityRPM-1920) > 1e-3 {
+		t.Errorf("VelocityRPM = %v, want the hot limit 1920", m.VelocityRPM)
+	}
+
+	m = NewDCMotor()
+	m.BackEMFLimit = true
+	if u, ok := m.InitSteadyState(5000); !ok || m.VelocityRPM != 2400 || u != 24 {
+		t.Errorf("InitSteadyState(5000): velocity %v, u %v; want capped at 2400 with 24 V", m.VelocityRPM, u)
+	}
+}
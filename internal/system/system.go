@@ -13,3 +13,24 @@ type System interface {
 	Actuate(u float64)
 	Step(dt float64)
 }
+
+// SteadyStateInitializer is an optional capability for systems that can be
+// placed directly at an equilibrium, e.g. to start disturbance studies without
+// the initial transient.
+type SteadyStateInitializer interface {
+	// InitSteadyState places the system at equilibrium with output y and
+	// returns the constant input u that holds it there. If the system cannot
+	// be initialized (e.g., a wrapper around a system that cannot), ok is
+	// false and the system is left unchanged.
+	InitSteadyState(y float64) (u float64, ok bool)
+}
+
+// InitSteadyState places sys at equilibrium with output y if it implements
+// SteadyStateInitializer (see SteadyStateInitializer.InitSteadyState), and
+// reports false otherwise.
+func InitSteadyState(sys System, y float64) (u float64, ok bool) {
+	if ss, ok := sys.(SteadyStateInitializer); ok {
+		return ss.InitSteadyState(y)
+	}
+	return 0, false
+}
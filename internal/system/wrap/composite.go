@@ -0,0 +1,169 @@
+package wrap
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// DisturbanceProfile returns the load disturbance (RPM/s) at simulation time t.
+// CompositeDisturbance calls each profile once per Step, with increasing t, so
+// profiles may be stateful (e.g., NoiseProfile).
+type DisturbanceProfile func(t float64) float64
+
+// StepProfile returns the profile of a step disturbance, matching DisturbedSystem.
+func StepProfile(cfg StepDisturbanceConfig) DisturbanceProfile {
+	return func(t float64) float64 {
+		return computeDisturbance(t, cfg)
+	}
+}
+
+// SineProfile returns a periodic disturbance amplitude*sin(2π·freqHz·t + phaseRad).
+func SineProfile(amplitude, freqHz, phaseRad float64) DisturbanceProfile {
+	return func(t float64) float64 {
+		return amplitude * math.Sin(2*math.Pi*freqHz*t+phaseRad)
+	}
+}
+
+// NoiseProfile returns zero-mean Gaussian disturbance noise with standard
+// deviation stdDev, drawing one value per call. It is deterministic for a given seed.
+func NoiseProfile(stdDev float64, seed int64) DisturbanceProfile {
+	rng := rand.New(rand.NewSource(seed))
+	return func(float64) float64 {
+		return rng.NormFloat64() * stdDev
+	}
+}
+
+// DisturbanceComponent is one named profile of a CompositeDisturbance.
+type DisturbanceComponent struct {
+	// Name identifies the component in its signal key (see CompositeDisturbance).
+	Name    string
+	Profile DisturbanceProfile
+
+	seed   int64
+	seeded bool
+}
+
+// NoiseComponent returns a component with a NoiseProfile. Unlike a literal
+// component, it records its seed, which CompositeDisturbance reports as
+// "disturbance_<name>".
+func NoiseComponent(name string, stdDev float64, seed int64) DisturbanceComponent {
+	return DisturbanceComponent{Name: name, Profile: NoiseProfile(stdDev, seed), seed: seed, seeded: true}
+}
+
+// CompositeDisturbance wraps a system.System and applies the sum of several
+// disturbance profiles (e.g., a constant step, a periodic load and noise).
+//
+// Like DisturbedSystem, it tracks simulation time, evaluates the profiles at the
+// end of each step interval and applies the total through
+// system.DisturbanceReceiver if the inner system implements it.
+//
+// The total is reported as the "disturbance_rpm_per_s" signal. With
+// ReportComponents set, each component is also reported as
+// "disturbance_<name>_rpm_per_s"; those keys have no entry in
+// artifacts.DefaultUnits, so add them via CreateOptions.Units if needed.
+// The inner system's signals are merged in, and so are its seeds (see Seeds).
+type CompositeDisturbance struct {
+	inner      system.System
+	components []DisturbanceComponent
+
+	// ReportComponents adds one signal per component.
+	ReportComponents bool
+
+	t     float64
+	total float64
+	parts []float64
+}
+
+// NewCompositeDisturbance creates a CompositeDisturbance applying the sum of components.
+func NewCompositeDisturbance(inner system.System, components ...DisturbanceComponent) *CompositeDisturbance {
+	return &CompositeDisturbance{
+		inner:      inner,
+		components: components,
+		parts:      make([]float64, len(components)),
+	}
+}
+
+// Observe delegates to the inner system.
+func (c *CompositeDisturbance) Observe() float64 {
+	return c.inner.Observe()
+}
+
+// Actuate delegates to the inner system.
+func (c *CompositeDisturbance) Actuate(u float64) {
+	c.inner.Actuate(u)
+}
+
+// Step evaluates every profile, applies their sum to the inner system if it
+// supports disturbance injection, then steps the inner system.
+func (c *CompositeDisturbance) Step(dt float64) {
+	c.total = 0
+	for i, comp := range c.components {
+		c.parts[i] = comp.Profile(c.t + dt)
+		c.total += c.parts[i]
+	}
+
+	if distReceiver, ok := c.inner.(system.DisturbanceReceiver); ok {
+		distReceiver.SetDisturbanceRPMPerS(c.total)
+	}
+
+	c.inner.Step(dt)
+	c.t += dt
+}
+
+// Signals implements system.SignalReporter.
+func (c *CompositeDisturbance) Signals() map[string]float64 {
+	out := map[string]float64{"disturbance_rpm_per_s": c.total}
+	if c.ReportComponents {
+		for i, comp := range c.components {
+			out[componentSignalKey(comp.Name)] = c.parts[i]
+		}
+	}
+	if sr, ok := c.inner.(system.SignalReporter); ok {
+		for k, v := range sr.Signals() {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// SignalKeys implements system.SignalDeclarer.
+func (c *CompositeDisturbance) SignalKeys() []string {
+	keys := []string{"disturbance_rpm_per_s"}
+	if c.ReportComponents {
+		for _, comp := range c.components {
+			keys = append(keys, componentSignalKey(comp.Name))
+		}
+	}
+	return append(keys, system.DeclaredSignalKeys(c.inner)...)
+}
+
+// InitSteadyState implements system.SteadyStateInitializer by delegating to the
+// inner system (see DisturbedSystem.InitSteadyState).
+func (c *CompositeDisturbance) InitSteadyState(y float64) (float64, bool) {
+	return system.InitSteadyState(c.inner, y)
+}
+
+// Seeds implements system.SeedReporter: the seeds of the components built with
+// NoiseComponent, keyed "disturbance_<name>", and the inner system's seeds.
+func (c *CompositeDisturbance) Seeds() map[string]int64 {
+	out := system.ReportedSeeds(c.inner)
+	for _, comp := range c.components {
+		if comp.seeded {
+			out["disturbance_"+comp.Name] = comp.seed
+		}
+	}
+	return out
+}
+
+func componentSignalKey(name string) string {
+	return "disturbance_" + name + "_rpm_per_s"
+}
+
+var (
+	_ system.SignalReporter         = (*CompositeDisturbance)(nil)
+	_ system.SignalDeclarer         = (*CompositeDisturbance)(nil)
+	_ system.SteadyStateInitializer = (*CompositeDisturbance)(nil)
+	_ system.SeedReporter           = (*CompositeDisturbance)(nil)
+)
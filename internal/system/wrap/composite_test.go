@@ -0,0 +1,84 @@
+package wrap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestCompositeDisturbance_SumOfParts(t *testing.T) {
+	step := StepDisturbanceConfig{Enabled: true, StartS: 0.05, MagnitudeRPMPerS: 40}
+	components := func() []DisturbanceComponent {
+		return []DisturbanceComponent{
+			{Name: "step", Profile: StepProfile(step)},
+			{Name: "sine", Profile: SineProfile(10, 2, 0.3)},
+			{Name: "noise", Profile: NoiseProfile(5, 42)},
+		}
+	}
+
+	mock := &mockDisturbanceReceiver{}
+	c := NewCompositeDisturbance(mock, components()...)
+	c.ReportComponents = true
+
+	// Reference copies of each part, evaluated independently
+	step2, sine2, noise2 := StepProfile(step), SineProfile(10, 2, 0.3), NoiseProfile(5, 42)
+
+	const dt = 0.01
+	for i := 1; i <= 20; i++ {
+		c.Step(dt)
+		tt := float64(i) * dt
+		parts := map[string]float64{"step": step2(tt), "sine": sine2(tt), "noise": noise2(tt)}
+		want := parts["step"] + parts["sine"] + parts["noise"]
+
+		if math.Abs(mock.disturbance-want) > eps {
+			t.Fatalf("t=%v: applied disturbance = %v, want sum of parts %v", tt, mock.disturbance, want)
+		}
+		signals := c.Signals()
+		if math.Abs(signals["disturbance_rpm_per_s"]-want) > eps {
+			t.Errorf("t=%v: total signal = %v, want %v", tt, signals["disturbance_rpm_per_s"], want)
+		}
+		for name, v := range parts {
+			if got := signals["disturbance_"+name+"_rpm_per_s"]; math.Abs(got-v) > eps {
+				t.Errorf("t=%v: %s component = %v, want %v", tt, name, got, v)
+			}
+		}
+	}
+}
+
+func TestCompositeDisturbance_StepMatchesDisturbedSystem(t *testing.T) {
+	cfg := StepDisturbanceConfig{Enabled: true, StartS: 0.2, DurationS: 0.3, MagnitudeRPMPerS: 100}
+	a := NewDisturbedSystem(sim.NewDCMotor(), cfg)
+	b := NewCompositeDisturbance(sim.NewDCMotor(), DisturbanceComponent{Name: "step", Profile: StepProfile(cfg)})
+
+	for i := 0; i < 100; i++ {
+		a.Actuate(5)
+		b.Actuate(5)
+		a.Step(0.01)
+		b.Step(0.01)
+		if a.Observe() != b.Observe() {
+			t.Fatalf("step %d: composite output %v, want %v", i, b.Observe(), a.Observe())
+		}
+	}
+}
+
+func TestCompositeDisturbance_SignalKeysMatchSignals(t *testing.T) {
+	c := NewCompositeDisturbance(sim.NewDCMotor(),
+		DisturbanceComponent{Name: "sine", Profile: SineProfile(1, 1, 0)},
+		DisturbanceComponent{Name: "noise", Profile: NoiseProfile(1, 1)},
+	)
+	for _, report := range []bool{false, true} {
+		c.ReportComponents = report
+		c.Step(0.01)
+		keys := c.SignalKeys()
+		signals := c.Signals()
+		if len(keys) != len(signals) {
+			t.Errorf("report=%v: SignalKeys() = %v, Signals() = %v", report, keys, signals)
+		}
+		for _, k := range keys {
+			if _, ok := signals[k]; !ok {
+				t.Errorf("report=%v: declared key %q missing from Signals()", report, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package wrap
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// CurrentLimitConfig configures a CurrentLimitedSystem.
+//
+// The wrapped controller commands motor current (A) instead of voltage. An ideal
+// inner current loop converts the limited current command to the voltage that
+// produces it against the motor's back-EMF:
+//
+//	V = R*I + Ke*v
+//
+// For sim.DCMotor, Ke = 1/GainRPMPerVolt makes the steady-state speed match.
+type CurrentLimitConfig struct {
+	// LimitA is the maximum current magnitude (A), reflecting motor thermal
+	// limits. It is enforced before, and independently of, the plant's voltage clamp.
+	// Zero or negative disables limiting.
+	LimitA float64
+
+	ResistanceOhm  float64 // winding resistance R
+	BackEMFVPerRPM float64 // back-EMF constant Ke
+}
+
+// CurrentLimitedSystem wraps a voltage-driven system so that Actuate takes a
+// current command, clipped to ±LimitA, and converts it to a voltage for the inner system.
+//
+// It reports the clipped command as "current_cmd_a" and whether the limit was
+// active as "current_limit_active" (1 or 0), merged with the inner system's signals.
+type CurrentLimitedSystem struct {
+	inner system.System
+	cfg   CurrentLimitConfig
+
+	currentCmdA float64
+	limited     bool
+}
+
+// NewCurrentLimitedSystem creates a CurrentLimitedSystem around inner.
+func NewCurrentLimitedSystem(inner system.System, cfg CurrentLimitConfig) *CurrentLimitedSystem {
+	return &CurrentLimitedSystem{inner: inner, cfg: cfg}
+}
+
+// Observe delegates to the inner system.
+func (c *CurrentLimitedSystem) Observe() float64 {
+	return c.inner.Observe()
+}
+
+// Actuate clips the current command u (A) to the limit and applies the voltage
+// that drives that current at the present speed.
+func (c *CurrentLimitedSystem) Actuate(u float64) {
+	i := u
+	if c.cfg.LimitA > 0 {
+		i = math.Max(-c.cfg.LimitA, math.Min(u, c.cfg.LimitA))
+	}
+	c.currentCmdA = i
+	c.limited = i != u
+
+	c.inner.Actuate(c.cfg.ResistanceOhm*i + c.cfg.BackEMFVPerRPM*c.inner.Observe())
+}
+
+// Step delegates to the inner system.
+func (c *CurrentLimitedSystem) Step(dt float64) {
+	c.inner.Step(dt)
+}
+
+// Signals implements system.SignalReporter.
+// The inner system's signals, if any, are included.
+func (c *CurrentLimitedSystem) Signals() map[string]float64 {
+	out := map[string]float64{
+		"current_cmd_a":        c.currentCmdA,
+		"current_limit_active": 0,
+	}
+	if c.limited {
+		out["current_limit_active"] = 1
+	}
+	if sr, ok := c.inner.(system.SignalReporter); ok {
+		for k, v := range sr.Signals() {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// SignalKeys implements system.SignalDeclarer.
+func (c *CurrentLimitedSystem) SignalKeys() []string {
+	return append([]string{"current_cmd_a", "current_limit_active"}, system.DeclaredSignalKeys(c.inner)...)
+}
+
+// Seeds implements system.SeedReporter with the inner system's seeds.
+func (c *CurrentLimitedSystem) Seeds() map[string]int64 {
+	return system.ReportedSeeds(c.inner)
+}
+
+var (
+	_ system.SignalReporter = (*CurrentLimitedSystem)(nil)
+	_ system.SignalDeclarer = (*CurrentLimitedSystem)(nil)
+	_ system.SeedReporter   = (*CurrentLimitedSystem)(nil)
+)
@@ -0,0 +1,87 @@
+package wrap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestCurrentLimitedSystem_ClipsCommand(t *testing.T) {
+	cfg := CurrentLimitConfig{LimitA: 2.0, ResistanceOhm: 1.5, BackEMFVPerRPM: 0.01}
+
+	tests := []struct {
+		name        string
+		cmd         float64
+		wantCurrent float64
+		wantActive  float64
+	}{
+		{"within limit", 1.0, 1.0, 0},
+		{"at limit", 2.0, 2.0, 0},
+		{"above limit", 5.0, 2.0, 1},
+		{"below negative limit", -7.0, -2.0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockSystem{observed: 300.0}
+			c := NewCurrentLimitedSystem(mock, cfg)
+			c.Actuate(tt.cmd)
+
+			sigs := c.Signals()
+			if sigs["current_cmd_a"] != tt.wantCurrent {
+				t.Errorf("current_cmd_a = %v, want %v", sigs["current_cmd_a"], tt.wantCurrent)
+			}
+			if sigs["current_limit_active"] != tt.wantActive {
+				t.Errorf("current_limit_active = %v, want %v", sigs["current_limit_active"], tt.wantActive)
+			}
+
+			// V = R*I + Ke*v
+			wantV := 1.5*tt.wantCurrent + 0.01*300.0
+			if math.Abs(mock.actuated-wantV) > eps {
+				t.Errorf("inner voltage = %v, want %v", mock.actuated, wantV)
+			}
+		})
+	}
+}
+
+func TestCurrentLimitedSystem_NoLimit(t *testing.T) {
+	mock := &mockSystem{}
+	c := NewCurrentLimitedSystem(mock, CurrentLimitConfig{ResistanceOhm: 1.0})
+	c.Actuate(100.0)
+
+	if got := c.Signals()["current_limit_active"]; got != 0 {
+		t.Errorf("current_limit_active = %v, want 0 when LimitA is unset", got)
+	}
+	if mock.actuated != 100.0 {
+		t.Errorf("inner voltage = %v, want 100", mock.actuated)
+	}
+}
+
+func TestCurrentLimitedSystem_MergesInnerSignals(t *testing.T) {
+	motor := sim.NewDCMotor()
+	dist := NewDisturbedSystem(motor, StepDisturbanceConfig{Enabled: true, MagnitudeRPMPerS: 30})
+	c := NewCurrentLimitedSystem(dist, CurrentLimitConfig{LimitA: 1, ResistanceOhm: 2, BackEMFVPerRPM: 1 / motor.GainRPMPerVolt})
+
+	c.Actuate(3)
+	c.Step(0.001)
+
+	sigs := c.Signals()
+	if sigs["disturbance_rpm_per_s"] != 30 {
+		t.Errorf("disturbance_rpm_per_s = %v, want 30", sigs["disturbance_rpm_per_s"])
+	}
+	if sigs["current_limit_active"] != 1 {
+		t.Errorf("current_limit_active = %v, want 1", sigs["current_limit_active"])
+	}
+
+	keys := c.SignalKeys()
+	want := []string{"current_cmd_a", "current_limit_active", "disturbance_rpm_per_s"}
+	if len(keys) != len(want) {
+		t.Fatalf("SignalKeys() = %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("SignalKeys()[%d] = %q, want %q", i, keys[i], want[i])
+		}
+	}
+}
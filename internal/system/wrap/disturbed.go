@@ -1,6 +1,9 @@
 package wrap
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/fabriziobonavita/motor-control-lab/internal/system"
 )
 
@@ -11,6 +14,28 @@ type StepDisturbanceConfig struct {
 	StartS           float64
 	DurationS        float64 // 0 means infinite
 	MagnitudeRPMPerS float64
+
+	// StepCountTime derives the wrapper's time from a step counter times dt
+	// instead of summing dt. Summing accumulates rounding error over millions
+	// of steps, which can move the onset (or end) by a whole step; counting
+	// keeps it exact for a constant dt. A change of dt starts a new count
+	// from the current time.
+	StepCountTime bool
+}
+
+// Validate reports the first nonsensical field: a negative (or NaN) StartS or
+// DurationS, or a NaN magnitude. Disabled configs are validated too.
+func (cfg StepDisturbanceConfig) Validate() error {
+	if !(cfg.StartS >= 0) {
+		return fmt.Errorf("disturbance: start %gs must be >= 0", cfg.StartS)
+	}
+	if !(cfg.DurationS >= 0) {
+		return fmt.Errorf("disturbance: duration %gs must be >= 0 (0 means infinite)", cfg.DurationS)
+	}
+	if math.IsNaN(cfg.MagnitudeRPMPerS) {
+		return fmt.Errorf("disturbance: magnitude is NaN")
+	}
+	return nil
 }
 
 // DisturbedSystem wraps a system.System and applies time-varying load disturbances.
@@ -26,13 +51,23 @@ type DisturbedSystem struct {
 	// Internal simulation time (seconds)
 	t float64
 
+	// With cfg.StepCountTime: t = base + steps*stepDT
+	base   float64
+	steps  int64
+	stepDT float64
+
 	// Last applied disturbance value (for reporting)
 	lastDisturbanceRPMPerS float64
 }
 
 // NewDisturbedSystem creates a new DisturbedSystem wrapper around the given inner system.
 // The wrapper will apply disturbances according to cfg when Step() is called.
+//
+// It panics if cfg.Validate() fails; validate user-provided configs first.
 func NewDisturbedSystem(inner system.System, cfg StepDisturbanceConfig) *DisturbedSystem {
+	if err := cfg.Validate(); err != nil {
+		panic(err)
+	}
 	return &DisturbedSystem{
 		inner:                  inner,
 		cfg:                    cfg,
@@ -56,7 +91,14 @@ func (d *DisturbedSystem) Actuate(u float64) {
 func (d *DisturbedSystem) Step(dt float64) {
 	// Compute disturbance at the end of this step interval (after the step)
 	// This represents the disturbance active during the step
-	dist := computeDisturbance(d.t+dt, d.cfg)
+	end := d.t + dt
+	if d.cfg.StepCountTime {
+		if dt != d.stepDT {
+			d.base, d.steps, d.stepDT = d.t, 0, dt
+		}
+		end = d.base + float64(d.steps+1)*dt
+	}
+	dist := computeDisturbance(end, d.cfg)
 	d.lastDisturbanceRPMPerS = dist
 
 	// Apply disturbance to inner system if it supports it
@@ -67,16 +109,40 @@ func (d *DisturbedSystem) Step(dt float64) {
 	// Step the inner system
 	d.inner.Step(dt)
 
-	// Increment internal time after stepping
-	d.t += dt
+	// Advance internal time after stepping
+	if d.cfg.StepCountTime {
+		d.steps++
+	}
+	d.t = end
 }
 
 // Signals implements system.SignalReporter.
-// Returns a map containing the current disturbance signal.
+// Returns the current disturbance signal merged with the inner system's signals.
 func (d *DisturbedSystem) Signals() map[string]float64 {
-	return map[string]float64{
-		"disturbance_rpm_per_s": d.lastDisturbanceRPMPerS,
+	out := map[string]float64{"disturbance_rpm_per_s": d.lastDisturbanceRPMPerS}
+	if sr, ok := d.inner.(system.SignalReporter); ok {
+		for k, v := range sr.Signals() {
+			out[k] = v
+		}
 	}
+	return out
+}
+
+// SignalKeys implements system.SignalDeclarer.
+func (d *DisturbedSystem) SignalKeys() []string {
+	return append([]string{"disturbance_rpm_per_s"}, system.DeclaredSignalKeys(d.inner)...)
+}
+
+// InitSteadyState implements system.SteadyStateInitializer by delegating to the
+// inner system. It reports false if the inner system cannot be initialized.
+func (d *DisturbedSystem) InitSteadyState(y float64) (float64, bool) {
+	return system.InitSteadyState(d.inner, y)
+}
+
+// Seeds implements system.SeedReporter; the step disturbance is deterministic,
+// so these are the inner system's seeds.
+func (d *DisturbedSystem) Seeds() map[string]int64 {
+	return system.ReportedSeeds(d.inner)
 }
 
 // CurrentDisturbanceRPMPerS returns the disturbance value that was applied in the last Step() call.
@@ -89,6 +155,7 @@ func (d *DisturbedSystem) CurrentDisturbanceRPMPerS() float64 {
 // Useful for reusing the wrapper in multiple experiments.
 func (d *DisturbedSystem) ResetTime() {
 	d.t = 0.0
+	d.base, d.steps, d.stepDT = 0, 0, 0
 	d.lastDisturbanceRPMPerS = 0.0
 }
 
@@ -107,4 +174,9 @@ func computeDisturbance(t float64, cfg StepDisturbanceConfig) float64 {
 	return cfg.MagnitudeRPMPerS
 }
 
-var _ system.SignalReporter = (*DisturbedSystem)(nil)
+var (
+	_ system.SignalReporter         = (*DisturbedSystem)(nil)
+	_ system.SignalDeclarer         = (*DisturbedSystem)(nil)
+	_ system.SteadyStateInitializer = (*DisturbedSystem)(nil)
+	_ system.SeedReporter           = (*DisturbedSystem)(nil)
+)
@@ -142,6 +142,70 @@ func TestDisturbedSystem_ResetTime(t *testing.T) {
 	}
 }
 
+func TestDisturbedSystem_StepCountTimeKeepsOnsetExact(t *testing.T) {
+	const (
+		dt     = 1e-5
+		startS = 20.0
+		onset  = 2_000_000 // startS/dt: the first disturbed step ends at startS
+	)
+	// onsetStep returns the 1-based index of the first step with the disturbance.
+	onsetStep := func(count bool) int {
+		mock := &mockDisturbanceReceiver{}
+		w := NewDisturbedSystem(mock, StepDisturbanceConfig{
+			Enabled: true, StartS: startS, MagnitudeRPMPerS: 1, StepCountTime: count,
+		})
+		for k := 1; k <= onset+10; k++ {
+			w.Step(dt)
+			if mock.disturbance != 0 {
+				return k
+			}
+		}
+		return -1
+	}
+
+	k := onsetStep(true)
+	if got := float64(k) * dt; math.Abs(got-startS) > dt/2 {
+		t.Errorf("onset at step %d (t=%v), want step %d (t=%v)", k, got, onset, startS)
+	}
+	// Summing dt two million times ends just below startS, one step late;
+	// this fixture demonstrates the drift the option removes
+	if k := onsetStep(false); k != onset+1 {
+		t.Errorf("summed time: onset at step %d, want the late step %d", k, onset+1)
+	}
+}
+
+func TestDisturbedSystem_StepCountTimeFollowsDTChanges(t *testing.T) {
+	mock := &mockDisturbanceReceiver{}
+	w := NewDisturbedSystem(mock, StepDisturbanceConfig{
+		Enabled: true, StartS: 1.0, DurationS: 0.5, MagnitudeRPMPerS: 10, StepCountTime: true,
+	})
+
+	// 0.5s in coarse steps, then fine steps: the count restarts from 0.5s
+	for i := 0; i < 5; i++ {
+		w.Step(0.1)
+	}
+	var times []float64
+	for i := 1; i <= 150; i++ {
+		w.Step(0.01)
+		if mock.disturbance != 0 {
+			times = append(times, 0.5+float64(i)*0.01)
+		}
+	}
+	if len(times) == 0 {
+		t.Fatal("disturbance never applied")
+	}
+	if len(times) != 50 || math.Abs(times[0]-1.0) > eps || math.Abs(times[len(times)-1]-1.49) > eps {
+		t.Errorf("disturbed steps end at %v .. %v (%d steps), want 1.0 .. 1.49 (50 steps)",
+			times[0], times[len(times)-1], len(times))
+	}
+
+	w.ResetTime()
+	w.Step(0.5)
+	if mock.disturbance != 0 {
+		t.Errorf("after ResetTime, disturbance = %v, want 0", mock.disturbance)
+	}
+}
+
 func TestDisturbedSystem_WithDCMotor(t *testing.T) {
 	motor := sim.NewDCMotor()
 	motor.VelocityRPM = 0.0
@@ -225,3 +289,72 @@ func TestComputeDisturbance_Behavior(t *testing.T) {
 		})
 	}
 }
+
+func TestDisturbedSystem_MergesInnerSignals(t *testing.T) {
+	motor := sim.NewDCMotor()
+	motor.GainHotRPMPerVolt = 80
+	motor.ThermalTauSeconds = 1
+	wrapper := NewDisturbedSystem(motor, StepDisturbanceConfig{Enabled: true, MagnitudeRPMPerS: 10.0})
+	wrapper.Step(0.01)
+
+	signals := wrapper.Signals()
+	if got := signals["disturbance_rpm_per_s"]; got != 10.0 {
+		t.Errorf("disturbance_rpm_per_s = %v, want 10.0", got)
+	}
+	if got, ok := signals["gain_rpm_per_volt"]; !ok || got != motor.Gain() {
+		t.Errorf("gain_rpm_per_volt = %v (present %v), want the motor's %v", got, ok, motor.Gain())
+	}
+
+	keys := system.DeclaredSignalKeys(wrapper)
+	if len(keys) != 2 || keys[0] != "disturbance_rpm_per_s" || keys[1] != "gain_rpm_per_volt" {
+		t.Errorf("SignalKeys() = %v, want disturbance_rpm_per_s, gain_rpm_per_volt", keys)
+	}
+}
+
+func TestDisturbedSystem_SignalKeysMatchSignals(t *testing.T) {
+	wrapper := NewDisturbedSystem(&mockSystem{}, StepDisturbanceConfig{})
+
+	keys := system.DeclaredSignalKeys(wrapper)
+	signals := wrapper.Signals()
+	if len(keys) != len(signals) {
+		t.Fatalf("SignalKeys() = %v, Signals() = %v", keys, signals)
+	}
+	for _, k := range keys {
+		if _, ok := signals[k]; !ok {
+			t.Errorf("declared key %q not reported by Signals()", k)
+		}
+	}
+}
+
+func TestStepDisturbanceConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     StepDisturbanceConfig
+		wantErr bool
+	}{
+		{"valid", StepDisturbanceConfig{Enabled: true, StartS: 5, DurationS: 2, MagnitudeRPMPerS: 50}, false},
+		{"infinite duration", StepDisturbanceConfig{Enabled: true, StartS: 0, DurationS: 0, MagnitudeRPMPerS: -50}, false},
+		{"zero value", StepDisturbanceConfig{}, false},
+		{"negative start", StepDisturbanceConfig{Enabled: true, StartS: -1, MagnitudeRPMPerS: 50}, true},
+		{"NaN start", StepDisturbanceConfig{Enabled: true, StartS: math.NaN(), MagnitudeRPMPerS: 50}, true},
+		{"negative duration", StepDisturbanceConfig{Enabled: true, StartS: 1, DurationS: -2, MagnitudeRPMPerS: 50}, true},
+		{"NaN magnitude", StepDisturbanceConfig{Enabled: true, StartS: 1, MagnitudeRPMPerS: math.NaN()}, true},
+		{"invalid while disabled", StepDisturbanceConfig{StartS: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewDisturbedSystem_PanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewDisturbedSystem() did not panic on a negative duration")
+		}
+	}()
+	NewDisturbedSystem(&mockSystem{}, StepDisturbanceConfig{Enabled: true, DurationS: -1})
+}
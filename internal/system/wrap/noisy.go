@@ -0,0 +1,85 @@
+package wrap
+
+import (
+	"math/rand"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// NoisySystem wraps a system.System and adds Gaussian measurement noise to Observe.
+//
+// One noise value is drawn per Step (and one at construction), so repeated
+// Observe calls within a step agree. The noise is deterministic for a given seed,
+// which makes stochastic runs reproducible (e.g., Monte Carlo trials).
+// The current noise is reported as the "measurement_noise_rpm" signal, merged
+// with the inner system's signals, and the seed as "measurement_noise".
+type NoisySystem struct {
+	inner  system.System
+	stdDev float64
+	seed   int64
+	rng    *rand.Rand
+
+	noise float64
+}
+
+// NewNoisySystem creates a NoisySystem with noise standard deviation stdDev
+// (in the measurement's units), seeded with seed. A zero stdDev adds no noise.
+func NewNoisySystem(inner system.System, stdDev float64, seed int64) *NoisySystem {
+	n := &NoisySystem{
+		inner:  inner,
+		stdDev: stdDev,
+		seed:   seed,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+	n.draw()
+	return n
+}
+
+func (n *NoisySystem) draw() {
+	n.noise = n.rng.NormFloat64() * n.stdDev
+}
+
+// Observe returns the inner measurement plus the current noise.
+func (n *NoisySystem) Observe() float64 {
+	return n.inner.Observe() + n.noise
+}
+
+// Actuate delegates to the inner system.
+func (n *NoisySystem) Actuate(u float64) {
+	n.inner.Actuate(u)
+}
+
+// Step steps the inner system and draws new noise.
+func (n *NoisySystem) Step(dt float64) {
+	n.inner.Step(dt)
+	n.draw()
+}
+
+// Signals implements system.SignalReporter.
+func (n *NoisySystem) Signals() map[string]float64 {
+	out := map[string]float64{"measurement_noise_rpm": n.noise}
+	if sr, ok := n.inner.(system.SignalReporter); ok {
+		for k, v := range sr.Signals() {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// SignalKeys implements system.SignalDeclarer.
+func (n *NoisySystem) SignalKeys() []string {
+	return append([]string{"measurement_noise_rpm"}, system.DeclaredSignalKeys(n.inner)...)
+}
+
+// Seeds implements system.SeedReporter.
+func (n *NoisySystem) Seeds() map[string]int64 {
+	out := system.ReportedSeeds(n.inner)
+	out["measurement_noise"] = n.seed
+	return out
+}
+
+var (
+	_ system.SignalReporter = (*NoisySystem)(nil)
+	_ system.SignalDeclarer = (*NoisySystem)(nil)
+	_ system.SeedReporter   = (*NoisySystem)(nil)
+)
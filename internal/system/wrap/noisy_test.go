@@ -0,0 +1,82 @@
+package wrap
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestNoisySystem_Deterministic(t *testing.T) {
+	a := NewNoisySystem(&mockSystem{observed: 100}, 2.0, 42)
+	b := NewNoisySystem(&mockSystem{observed: 100}, 2.0, 42)
+	c := NewNoisySystem(&mockSystem{observed: 100}, 2.0, 43)
+
+	differs := false
+	for i := 0; i < 100; i++ {
+		if a.Observe() != b.Observe() {
+			t.Fatalf("step %d: same seed produced different observations", i)
+		}
+		if a.Observe() != c.Observe() {
+			differs = true
+		}
+		if a.Observe() != a.Observe() {
+			t.Fatalf("step %d: repeated Observe calls disagree within a step", i)
+		}
+		a.Step(0.01)
+		b.Step(0.01)
+		c.Step(0.01)
+	}
+	if !differs {
+		t.Error("different seeds produced identical noise")
+	}
+}
+
+func TestSeeds_MergedThroughWrappers(t *testing.T) {
+	inner := NewCompositeDisturbance(sim.NewDCMotor(),
+		DisturbanceComponent{Name: "step", Profile: StepProfile(StepDisturbanceConfig{Enabled: true, MagnitudeRPMPerS: 1})},
+		NoiseComponent("load_noise", 5, 11),
+	)
+	var sys system.System = NewNoisySystem(inner, 1, 22)
+	sys = NewPositionSystem(sys)
+
+	want := map[string]int64{"measurement_noise": 22, "disturbance_load_noise": 11}
+	if got := system.ReportedSeeds(sys); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReportedSeeds() = %v, want %v", got, want)
+	}
+
+	// Deterministic systems report none, as an empty map
+	for _, s := range []system.System{sim.NewDCMotor(), NewDisturbedSystem(sim.NewDCMotor(), StepDisturbanceConfig{})} {
+		if got := system.ReportedSeeds(s); got == nil || len(got) != 0 {
+			t.Errorf("ReportedSeeds(%T) = %#v, want an empty map", s, got)
+		}
+	}
+}
+
+func TestNoisySystem_Statistics(t *testing.T) {
+	const n = 20000
+	s := NewNoisySystem(&mockSystem{observed: 10}, 3.0, 1)
+
+	var sum, sumSq float64
+	for i := 0; i < n; i++ {
+		e := s.Observe() - 10
+		if math.Abs(e-s.Signals()["measurement_noise_rpm"]) > eps {
+			t.Fatal("measurement_noise_rpm does not match the applied noise")
+		}
+		sum += e
+		sumSq += e * e
+		s.Step(0.001)
+	}
+	mean := sum / n
+	std := math.Sqrt(sumSq/n - mean*mean)
+	if math.Abs(mean) > 0.1 || math.Abs(std-3.0) > 0.1 {
+		t.Errorf("noise mean=%v std=%v, want ≈0 and ≈3", mean, std)
+	}
+
+	quiet := NewNoisySystem(&mockSystem{observed: 10}, 0, 1)
+	if quiet.Observe() != 10 {
+		t.Errorf("zero stdDev: Observe() = %v, want 10", quiet.Observe())
+	}
+}
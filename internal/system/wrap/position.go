@@ -0,0 +1,84 @@
+package wrap
+
+import (
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// PositionSystem wraps a velocity plant (observing RPM) and observes its shaft
+// position in revolutions instead, so controllers can track a position setpoint.
+//
+// The position is the velocity integrated with the trapezoidal rule over each
+// Step, starting at zero. The inner velocity is reported as the "velocity_rpm"
+// signal, merged with the inner system's signals.
+type PositionSystem struct {
+	inner system.System
+
+	revolutions float64
+}
+
+// NewPositionSystem creates a PositionSystem at position zero.
+func NewPositionSystem(inner system.System) *PositionSystem {
+	return &PositionSystem{inner: inner}
+}
+
+// Observe returns the shaft position (revolutions).
+func (p *PositionSystem) Observe() float64 {
+	return p.revolutions
+}
+
+// Actuate delegates to the inner system.
+func (p *PositionSystem) Actuate(u float64) {
+	p.inner.Actuate(u)
+}
+
+// Step steps the inner system and integrates its velocity into the position.
+func (p *PositionSystem) Step(dt float64) {
+	if dt <= 0 {
+		return
+	}
+	v0 := p.inner.Observe()
+	p.inner.Step(dt)
+	v1 := p.inner.Observe()
+	p.revolutions += (v0 + v1) / 2 * dt / 60
+}
+
+// Signals implements system.SignalReporter.
+func (p *PositionSystem) Signals() map[string]float64 {
+	out := map[string]float64{"velocity_rpm": p.inner.Observe()}
+	if sr, ok := p.inner.(system.SignalReporter); ok {
+		for k, v := range sr.Signals() {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// SignalKeys implements system.SignalDeclarer.
+func (p *PositionSystem) SignalKeys() []string {
+	return append([]string{"velocity_rpm"}, system.DeclaredSignalKeys(p.inner)...)
+}
+
+// InitSteadyState implements system.SteadyStateInitializer: holding a position
+// means standing still, so it initializes the inner system at zero velocity and
+// moves the shaft to y revolutions. It reports false, without moving the shaft,
+// if the inner system cannot be initialized.
+func (p *PositionSystem) InitSteadyState(y float64) (float64, bool) {
+	u, ok := system.InitSteadyState(p.inner, 0)
+	if !ok {
+		return 0, false
+	}
+	p.revolutions = y
+	return u, true
+}
+
+// Seeds implements system.SeedReporter with the inner system's seeds.
+func (p *PositionSystem) Seeds() map[string]int64 {
+	return system.ReportedSeeds(p.inner)
+}
+
+var (
+	_ system.SignalReporter         = (*PositionSystem)(nil)
+	_ system.SignalDeclarer         = (*PositionSystem)(nil)
+	_ system.SteadyStateInitializer = (*PositionSystem)(nil)
+	_ system.SeedReporter           = (*PositionSystem)(nil)
+)
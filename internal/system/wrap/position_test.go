@@ -0,0 +1,78 @@
+package wrap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestPositionSystem_IntegratesVelocity(t *testing.T) {
+	// 120 RPM = 2 rev/s, so 1.5 s covers 3 revolutions
+	p := NewPositionSystem(&mockSystem{observed: 120})
+	for i := 0; i < 150; i++ {
+		p.Step(0.01)
+	}
+	if got := p.Observe(); math.Abs(got-3) > eps {
+		t.Errorf("Observe() = %v rev, want 3", got)
+	}
+	if got := p.Signals()["velocity_rpm"]; got != 120 {
+		t.Errorf("velocity_rpm = %v, want 120", got)
+	}
+}
+
+func TestPositionSystem_Trapezoidal(t *testing.T) {
+	// A motor starting from rest: the position is the integral of the
+	// first-order velocity response, v(t) = K*V*(1 - exp(-t/tau)).
+	m := sim.NewDCMotor()
+	p := NewPositionSystem(m)
+	p.Actuate(6) // 600 RPM = 10 rev/s
+
+	const dt, n = 0.001, 2000
+	for i := 0; i < n; i++ {
+		p.Step(dt)
+	}
+	tt, tau := float64(n)*dt, m.TauSeconds
+	want := 10 * (tt - tau*(1-math.Exp(-tt/tau)))
+	if got := p.Observe(); math.Abs(got-want) > 1e-2 {
+		t.Errorf("Observe() = %v rev, want ≈ %v", got, want)
+	}
+}
+
+func TestPositionSystem_InitSteadyState(t *testing.T) {
+	m := sim.NewDCMotor()
+	m.VelocityRPM = 300
+	p := NewPositionSystem(m)
+
+	u, ok := p.InitSteadyState(5)
+	if !ok || u != 0 || m.VelocityRPM != 0 {
+		t.Errorf("InitSteadyState(5) = %v, %v with velocity %v, want 0 V at rest", u, ok, m.VelocityRPM)
+	}
+	p.Step(0.01)
+	if got := p.Observe(); got != 5 {
+		t.Errorf("Observe() = %v rev, want 5 held", got)
+	}
+}
+
+func TestInitSteadyState_NonInitializableInner(t *testing.T) {
+	tests := []struct {
+		name string
+		sys  system.SteadyStateInitializer
+	}{
+		{"disturbed", NewDisturbedSystem(&mockSystem{observed: 7}, StepDisturbanceConfig{})},
+		{"composite", NewCompositeDisturbance(&mockSystem{observed: 7})},
+		{"position", NewPositionSystem(&mockSystem{observed: 7})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.sys.(system.System).Observe()
+			if u, ok := tt.sys.InitSteadyState(5); ok || u != 0 {
+				t.Errorf("InitSteadyState(5) = %v, %v; want 0, false around a mock that cannot be initialized", u, ok)
+			}
+			if got := tt.sys.(system.System).Observe(); got != before {
+				t.Errorf("Observe() = %v after a failed InitSteadyState, want unchanged %v", got, before)
+			}
+		})
+	}
+}
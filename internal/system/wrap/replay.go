@@ -0,0 +1,64 @@
+package wrap
+
+import (
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// ReplaySystem plays back a prerecorded measurement series instead of
+// simulating a plant, e.g. to validate metrics and plots against data recorded
+// on hardware, or to mock hardware in harness tests.
+//
+// Observe returns the k-th value of the series after k Steps, whatever the actuation, so a run
+// with the recording's dt reproduces its measurements sample by sample; past
+// the end of the series the last value is held (an empty series observes 0).
+// The applied commands are recorded, one per Step, for comparison with the
+// recording's own (see Commands).
+type ReplaySystem struct {
+	series []float64
+	k      int
+
+	u        float64
+	commands []float64
+}
+
+// NewReplaySystem creates a ReplaySystem playing back series. The slice is
+// not copied.
+func NewReplaySystem(series []float64) *ReplaySystem {
+	return &ReplaySystem{series: series}
+}
+
+// Observe returns the recorded measurement for the current step.
+func (r *ReplaySystem) Observe() float64 {
+	switch n := len(r.series); {
+	case n == 0:
+		return 0
+	case r.k >= n:
+		return r.series[n-1]
+	default:
+		return r.series[r.k]
+	}
+}
+
+// Actuate records the command; it does not affect the measurements.
+func (r *ReplaySystem) Actuate(u float64) {
+	r.u = u
+}
+
+// Step records the current command and advances to the next recorded value.
+func (r *ReplaySystem) Step(dt float64) {
+	r.commands = append(r.commands, r.u)
+	r.k++
+}
+
+// Commands returns the command applied at each Step so far, in order.
+func (r *ReplaySystem) Commands() []float64 {
+	return r.commands
+}
+
+// Exhausted reports whether the run has stepped past the end of the series,
+// i.e. whether Observe is holding the last value.
+func (r *ReplaySystem) Exhausted() bool {
+	return r.k >= len(r.series)
+}
+
+var _ system.System = (*ReplaySystem)(nil)
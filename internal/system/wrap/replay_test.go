@@ -0,0 +1,42 @@
+package wrap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplaySystem(t *testing.T) {
+	r := NewReplaySystem([]float64{0, 10, 25, 40})
+
+	// Measurements follow the series whatever the command, then hold the last value
+	commands := []float64{24, -24, 0, 5, 6}
+	want := []float64{0, 10, 25, 40, 40}
+	for i, u := range commands {
+		if got := r.Observe(); got != want[i] {
+			t.Errorf("step %d: Observe() = %v, want %v", i, got, want[i])
+		}
+		if r.Exhausted() != (i >= 4) {
+			t.Errorf("step %d: Exhausted() = %v", i, r.Exhausted())
+		}
+		r.Actuate(u)
+		r.Step(0.01)
+	}
+	if got := r.Observe(); got != 40 {
+		t.Errorf("past the end: Observe() = %v, want 40 held", got)
+	}
+	if !reflect.DeepEqual(r.Commands(), commands) {
+		t.Errorf("Commands() = %v, want %v", r.Commands(), commands)
+	}
+}
+
+func TestReplaySystem_Empty(t *testing.T) {
+	r := NewReplaySystem(nil)
+	r.Actuate(3)
+	r.Step(0.01)
+	if got := r.Observe(); got != 0 || !r.Exhausted() {
+		t.Errorf("empty series: Observe() = %v, Exhausted() = %v; want 0, true", got, r.Exhausted())
+	}
+	if got := r.Commands(); len(got) != 1 || got[0] != 3 {
+		t.Errorf("Commands() = %v, want [3]", got)
+	}
+}
@@ -0,0 +1,115 @@
+package wrap
+
+import (
+	"math"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system"
+)
+
+// VelocityLimitConfig configures a ClampedSystem.
+type VelocityLimitConfig struct {
+	// LimitRPM is the velocity magnitude the observation never exceeds.
+	// Zero or negative disables limiting.
+	LimitRPM float64
+
+	// Softness is the width of the transition below the limit, as a fraction of
+	// LimitRPM in [0, 1]. Velocities up to LimitRPM*(1-Softness) (the knee) pass
+	// unchanged; beyond it they bend smoothly towards the limit. Zero is a hard
+	// clamp; 1 bends the whole range, LimitRPM*tanh(v/LimitRPM).
+	Softness float64
+}
+
+// ClampedSystem wraps a system observing a velocity (RPM) and limits the
+// observed velocity to ±LimitRPM with a soft saturation, e.g. to model a
+// mechanical speed limit without the kink of a hard clamp. Beyond the knee k,
+// with w = LimitRPM - k:
+//
+//	|v'| = k + w*tanh((|v| - k) / w)
+//
+// which has the slope of v at the knee and approaches the limit without
+// reaching it. The wrapper sees the plant only through Observe, so the limit
+// applies to what the controller and the recorded samples see; the inner
+// system's state is unchanged.
+//
+// It reports the inner velocity as "velocity_unlimited_rpm" and whether the
+// observation is bent (beyond the knee) as "velocity_limit_active" (1 or 0),
+// merged with the inner system's signals.
+type ClampedSystem struct {
+	inner system.System
+	cfg   VelocityLimitConfig
+}
+
+// NewClampedSystem creates a ClampedSystem around inner.
+func NewClampedSystem(inner system.System, cfg VelocityLimitConfig) *ClampedSystem {
+	return &ClampedSystem{inner: inner, cfg: cfg}
+}
+
+// Observe returns the inner velocity, soft-limited.
+func (c *ClampedSystem) Observe() float64 {
+	v, _ := c.limit(c.inner.Observe())
+	return v
+}
+
+// limit applies the soft saturation to v and reports whether it is beyond the knee.
+func (c *ClampedSystem) limit(v float64) (float64, bool) {
+	limit := c.cfg.LimitRPM
+	if limit <= 0 {
+		return v, false
+	}
+	soft := math.Max(0, math.Min(c.cfg.Softness, 1))
+	knee := limit * (1 - soft)
+	a := math.Abs(v)
+	if a <= knee {
+		return v, false
+	}
+	if soft == 0 {
+		return math.Copysign(limit, v), true
+	}
+	w := limit - knee
+	return math.Copysign(knee+w*math.Tanh((a-knee)/w), v), true
+}
+
+// Actuate delegates to the inner system.
+func (c *ClampedSystem) Actuate(u float64) {
+	c.inner.Actuate(u)
+}
+
+// Step delegates to the inner system.
+func (c *ClampedSystem) Step(dt float64) {
+	c.inner.Step(dt)
+}
+
+// Signals implements system.SignalReporter.
+// The inner system's signals, if any, are included.
+func (c *ClampedSystem) Signals() map[string]float64 {
+	v := c.inner.Observe()
+	out := map[string]float64{
+		"velocity_unlimited_rpm": v,
+		"velocity_limit_active":  0,
+	}
+	if _, active := c.limit(v); active {
+		out["velocity_limit_active"] = 1
+	}
+	if sr, ok := c.inner.(system.SignalReporter); ok {
+		for k, v := range sr.Signals() {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// SignalKeys implements system.SignalDeclarer.
+func (c *ClampedSystem) SignalKeys() []string {
+	return append([]string{"velocity_unlimited_rpm", "velocity_limit_active"}, system.DeclaredSignalKeys(c.inner)...)
+}
+
+// Seeds implements system.SeedReporter with the inner system's seeds.
+func (c *ClampedSystem) Seeds() map[string]int64 {
+	return system.ReportedSeeds(c.inner)
+}
+
+var (
+	_ system.SignalReporter = (*ClampedSystem)(nil)
+	_ system.SignalDeclarer = (*ClampedSystem)(nil)
+	_ system.SeedReporter   = (*ClampedSystem)(nil)
+)
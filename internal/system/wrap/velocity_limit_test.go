@@ -0,0 +1,89 @@
+package wrap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fabriziobonavita/motor-control-lab/internal/system/sim"
+)
+
+func TestClampedSystem_SoftLimit(t *testing.T) {
+	mock := &mockSystem{}
+	c := NewClampedSystem(mock, VelocityLimitConfig{LimitRPM: 1000, Softness: 0.2}) // knee at 800
+
+	// Sweep the inner velocity: the observation is monotonic, continuous,
+	// unchanged up to the knee and strictly below the limit beyond it
+	prev, prevV := math.Inf(-1), 0.0
+	for v := -3000.0; v <= 3000; v += 1 {
+		mock.observed = v
+		got := c.Observe()
+		if math.Abs(got) >= 1000 {
+			t.Fatalf("v=%v: Observe() = %v, want below the 1000 RPM limit", v, got)
+		}
+		if math.Abs(v) <= 800 && got != v {
+			t.Fatalf("v=%v: Observe() = %v, want it unchanged below the knee", v, got)
+		}
+		if got < prev || (prev != math.Inf(-1) && got-prev > (v-prevV)+eps) {
+			t.Fatalf("v=%v: Observe() = %v after %v, want a smooth monotonic bend", v, got, prev)
+		}
+		active := c.Signals()["velocity_limit_active"]
+		if want := math.Abs(v) > 800; (active == 1) != want {
+			t.Fatalf("v=%v: velocity_limit_active = %v, want %v", v, active, want)
+		}
+		prev, prevV = got, v
+	}
+
+	// Approaches the limit
+	mock.observed = 5000
+	if got := c.Observe(); got < 999.9 {
+		t.Errorf("far beyond the limit: Observe() = %v, want it close to 1000", got)
+	}
+	if got := c.Signals()["velocity_unlimited_rpm"]; got != 5000 {
+		t.Errorf("velocity_unlimited_rpm = %v, want the inner 5000", got)
+	}
+}
+
+func TestClampedSystem_Modes(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  VelocityLimitConfig
+		v    float64
+		want float64
+	}{
+		{"disabled", VelocityLimitConfig{}, 5000, 5000},
+		{"hard clamp", VelocityLimitConfig{LimitRPM: 1000}, 1200, 1000},
+		{"hard clamp below", VelocityLimitConfig{LimitRPM: 1000}, -1200, -1000},
+		{"fully soft", VelocityLimitConfig{LimitRPM: 1000, Softness: 1}, 500, 1000 * math.Tanh(0.5)},
+		{"just past the knee", VelocityLimitConfig{LimitRPM: 1000, Softness: 0.5}, 600, 500 + 500*math.Tanh(0.2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClampedSystem(&mockSystem{observed: tt.v}, tt.cfg)
+			if got := c.Observe(); math.Abs(got-tt.want) > eps {
+				t.Errorf("Observe() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClampedSystem_WithMotor(t *testing.T) {
+	// Full voltage drives the motor well past a 1500 RPM limit
+	m := sim.NewDCMotor()
+	c := NewClampedSystem(m, VelocityLimitConfig{LimitRPM: 1500, Softness: 0.1})
+	c.Actuate(24)
+	prev := c.Observe()
+	for i := 0; i < 3000; i++ {
+		c.Step(0.001)
+		got := c.Observe()
+		if got >= 1500 || got < prev {
+			t.Fatalf("step %d: Observe() = %v after %v, want a rise that stays below 1500", i, got, prev)
+		}
+		prev = got
+	}
+	if m.VelocityRPM <= 1500 {
+		t.Fatalf("motor at %v RPM, want it beyond the limit for this test", m.VelocityRPM)
+	}
+	if c.Signals()["velocity_limit_active"] != 1 || prev < 1450 {
+		t.Errorf("Observe() = %v, velocity_limit_active = %v; want near the limit and active", prev, c.Signals()["velocity_limit_active"])
+	}
+}